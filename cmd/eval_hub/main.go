@@ -8,14 +8,22 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/julpayne/eval-hub-backend-svc/cmd/eval_hub/server"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
 	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/dispatcher"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
 	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore"
+	"github.com/julpayne/eval-hub-backend-svc/internal/mlflow"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes"
 	"github.com/julpayne/eval-hub-backend-svc/internal/storage"
 	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/internal/webhooks"
 )
 
 var (
@@ -43,6 +51,11 @@ func main() {
 		startUpFailed(nil, err, "Failed to create service config", logger)
 	}
 
+	// "eval_hub migrate up|down|status" runs the database migrations and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrate(serviceConfig, logger, os.Args[2:]))
+	}
+
 	// set up the validator
 	validate, err := validation.NewValidator()
 	if err != nil {
@@ -51,15 +64,61 @@ func main() {
 	}
 	// serviceConfig.Validator = validator
 
-	// set up the storage
-	storage, err := storage.NewStorage(serviceConfig, logger)
+	// set up the storage, the storage publishes the changes of the jobs to the event streams
+	bus := events.NewBus(events.DefaultHistorySize)
+	storage, err := storage.NewStorage(serviceConfig, bus, logger)
 	if err != nil {
 		// we do this as no point trying to continue
 		startUpFailed(serviceConfig, err, "Failed to create storage", logger)
 	}
 	// serviceConfig.Storage = storage
 
-	srv, err := server.NewServer(logger, serviceConfig, storage, validate)
+	// set up the store of the benchmark logs
+	logStore, err := logstore.NewLogStore(serviceConfig, logger)
+	if err != nil {
+		// we do this as no point trying to continue
+		startUpFailed(serviceConfig, err, "Failed to create log store", logger)
+	}
+
+	// set up the runtime, this is nil if no runtime is enabled
+	runtime, err := runtimes.NewRuntime(serviceConfig, logStore, logger)
+	if err != nil {
+		// we do this as no point trying to continue
+		startUpFailed(serviceConfig, err, "Failed to create runtime", logger)
+	}
+
+	// set up the dispatcher that runs the pending evaluation jobs with the runtime
+	var jobDispatcher *dispatcher.Dispatcher
+	if runtime != nil {
+		jobDispatcher, err = dispatcher.NewDispatcher(serviceConfig.Dispatcher, strings.TrimSpace(serviceConfig.Service.ResultsToken), storage, runtime, logger)
+		if err != nil {
+			// we do this as no point trying to continue
+			startUpFailed(serviceConfig, err, "Failed to create dispatcher", logger)
+		}
+	}
+
+	// set up the dispatcher that delivers the webhook events, this is nil if the webhooks are not enabled
+	webhookDispatcher, err := webhooks.NewDispatcher(serviceConfig.Webhooks, storage, logger)
+	if err != nil {
+		// we do this as no point trying to continue
+		startUpFailed(serviceConfig, err, "Failed to create webhook dispatcher", logger)
+	}
+
+	// set up the recorder that records the reported results in MLflow, this is nil if the MLflow tracking is not enabled
+	recorder, err := mlflow.NewRecorder(serviceConfig.MLflow, storage, logger)
+	if err != nil {
+		// we do this as no point trying to continue
+		startUpFailed(serviceConfig, err, "Failed to create MLflow recorder", logger)
+	}
+
+	// load the catalog of the providers and the benchmarks
+	providerCatalog, err := catalog.NewCatalog(serviceConfig.Catalog, logger)
+	if err != nil {
+		// we do this as no point trying to continue
+		startUpFailed(serviceConfig, err, "Failed to load catalog", logger)
+	}
+
+	srv, err := server.NewServer(logger, serviceConfig, storage, validate, server.Dependencies{Catalog: providerCatalog, Bus: bus, LogStore: logStore})
 	if err != nil {
 		// we do this as no point trying to continue
 		startUpFailed(serviceConfig, err, "Failed to create server", logger)
@@ -73,8 +132,21 @@ func main() {
 		"build_date", serviceConfig.Service.BuildDate,
 		"storage", storage.GetDatasourceName(),
 		"validator", validate != nil,
+		"runtime", runtime != nil,
+		"webhooks", webhookDispatcher != nil,
+		"mlflow", recorder != nil,
 	)
 
+	if jobDispatcher != nil {
+		jobDispatcher.Start()
+	}
+	if webhookDispatcher != nil {
+		webhookDispatcher.Start()
+	}
+	if recorder != nil {
+		recorder.Start()
+	}
+
 	// Start server in a goroutine
 	go func() {
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
@@ -90,19 +162,48 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	// shutdown the storage
-	if err := storage.Close(); err != nil {
-		logger.Error("Failed to close storage", "error", err.Error(), "storage", storage.GetDatasourceName())
-	}
-
 	// Create a context with timeout for graceful shutdown
 	waitForShutdown := 30 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), waitForShutdown)
 	defer cancel()
 
+	// stop serving the requests first so that no request uses the storage once it is closed
+	serverErr := srv.Shutdown(ctx)
+
+	// stop claiming evaluation jobs, the jobs still running are recovered by another replica
+	if jobDispatcher != nil {
+		if err := jobDispatcher.Stop(ctx); err != nil {
+			logger.Warn("Dispatcher stopped with running evaluation jobs", "error", err.Error())
+		}
+	}
+
+	// stop delivering the webhook events, the deliveries that are not recorded are retried by another replica
+	if webhookDispatcher != nil {
+		if err := webhookDispatcher.Stop(ctx); err != nil {
+			logger.Warn("Webhook dispatcher stopped with running deliveries", "error", err.Error())
+		}
+	}
+
+	// stop recording the results in MLflow, the recordings that are not completed are retried by another replica
+	if recorder != nil {
+		if err := recorder.Stop(ctx); err != nil {
+			logger.Warn("MLflow recorder stopped with a running recording", "error", err.Error())
+		}
+	}
+
+	// stop watching the catalog
+	if err := providerCatalog.Close(); err != nil {
+		logger.Error("Failed to close catalog", "error", err.Error())
+	}
+
+	// shutdown the storage last as it is used by the server, the dispatchers and the recorder
+	if err := storage.Close(); err != nil {
+		logger.Error("Failed to close storage", "error", err.Error(), "storage", storage.GetDatasourceName())
+	}
+
 	// shutdown the logger
-	if err := srv.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", "error", err.Error(), "timeout", waitForShutdown)
+	if serverErr != nil {
+		logger.Error("Server forced to shutdown", "error", serverErr.Error(), "timeout", waitForShutdown)
 		_ = logShutdown() // ignore the error
 	} else {
 		logger.Info("Server shutdown gracefully")
@@ -110,6 +211,22 @@ func main() {
 	}
 }
 
+// migrate runs the migrate command and returns the process exit code
+func migrate(serviceConfig *config.Config, logger *slog.Logger, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: eval_hub migrate up|down|status")
+		return 2
+	}
+	status, err := storage.Migrate(serviceConfig, logger, args[0])
+	if err != nil {
+		logger.Error("Database migration failed", "command", args[0], "error", err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Println(status)
+	return 0
+}
+
 func startUpFailed(conf *config.Config, err error, msg string, logger *slog.Logger) {
 	termErr := server.SetTerminationMessage(server.GetTerminationFile(conf, logger), fmt.Sprintf("%s: %s", msg, err.Error()), logger)
 	if termErr != nil {
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+)
+
+// authenticate wraps the handler with the authentication of the requests. The public routes
+// are not authenticated and the results are authenticated by the route as they can also be
+// authenticated with the results token of the job, the other requests are rejected when they
+// are not authenticated. The principal is added to the context of the request.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.authentication == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, report := jobReport(r); report || s.authentication.IsPublic(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, err := s.authentication.Authenticate(r)
+		if err != nil {
+			_, logger := s.loggerWithRequest(r)
+			logger.Info("Rejected the request that is not authenticated", "error", err.Error())
+			w.Header().Set("WWW-Authenticate", `Bearer realm="eval-hub"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// mtlsConfig returns the mTLS configuration when the mTLS authentication is enabled
+func (s *Server) mtlsConfig() *config.MTLSConfig {
+	authConfig := s.serviceConfig.Auth
+	if s.authentication == nil || authConfig.MTLS == nil || !authConfig.MTLS.Enabled {
+		return nil
+	}
+	return authConfig.MTLS
+}
+
+// jobReport returns the id of the job of the requests that report the results of a job and
+// upload its artifacts, these are made by the runtimes and adapters with the results token of
+// the job. The path must be exactly /api/v1/evaluations/jobs/{id}/results, or
+// /api/v1/evaluations/jobs/{id}/artifacts for an upload.
+func jobReport(r *http.Request) (string, bool) {
+	rest, found := strings.CutPrefix(r.URL.Path, "/api/v1/evaluations/jobs/")
+	if !found {
+		return "", false
+	}
+	id, action, found := strings.Cut(rest, "/")
+	if !found || id == "" {
+		return "", false
+	}
+	switch {
+	case action == "results":
+		return id, true
+	case action == "artifacts" && r.Method == http.MethodPost:
+		return id, true
+	default:
+		return "", false
+	}
+}
+
+// newJobReportExecutionContext authenticates the request that reports the results of the job
+// or uploads its artifacts. The request is authenticated with the results token of the job when
+// it has one, the other requests are authenticated with the enabled methods as the other
+// requests and are rejected when the authentication is not enabled. It returns false when the
+// response has already been written.
+func (s *Server) newJobReportExecutionContext(w http.ResponseWriter, r *http.Request, id string) (*executioncontext.ExecutionContext, bool) {
+	if ctx, ok := s.authenticateJob(r, id); ok {
+		return ctx, true
+	}
+	if s.authentication == nil {
+		return nil, unauthorized(w)
+	}
+	principal, err := s.authentication.Authenticate(r)
+	if err != nil {
+		_, logger := s.loggerWithRequest(r)
+		logger.Info("Rejected the report that is not authenticated", "error", err.Error())
+		return nil, unauthorized(w)
+	}
+	return s.newTenantExecutionContext(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+}
+
+// authenticateJob checks the bearer token of the request against the results token of the
+// job, the token is derived from the results secret, the id and the tenant of the job. The
+// context of the request is scoped to the tenant of the job. It returns false when no results
+// secret is configured, the job does not exist or the token is not the results token of the job.
+func (s *Server) authenticateJob(r *http.Request, id string) (*executioncontext.ExecutionContext, bool) {
+	ctx := s.newExecutionContext(r)
+	secret := strings.TrimSpace(s.serviceConfig.Service.ResultsToken)
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if secret == "" || !found {
+		return nil, false
+	}
+	// the job is looked up for all the tenants as the tenant is only known from the job
+	job, err := s.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		if !errors.Is(err, abstractions.ErrNotFound) {
+			ctx.Logger.Error("Failed to get the evaluation job of the results token", "error", err.Error())
+		}
+		return nil, false
+	}
+	if !auth.VerifyJobToken(secret, strings.TrimSpace(token), job.ID, job.Tenant) {
+		ctx.Logger.Debug("The bearer token is not the results token of the job")
+		return nil, false
+	}
+	ctx.Principal = &auth.Principal{Subject: job.ID, Method: auth.MethodResultsToken}
+	ctx.Tenant = job.Tenant
+	ctx.Logger = ctx.Logger.With("tenant", string(job.Tenant))
+	return ctx, true
+}
+
+func unauthorized(w http.ResponseWriter) bool {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="eval-hub"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return false
+}
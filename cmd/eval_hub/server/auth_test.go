@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+func TestAuthentication(t *testing.T) {
+	hash := sha256.Sum256([]byte("ci-key"))
+	srv, err := createServerWithConfig(8080, func(serviceConfig *config.Config) {
+		serviceConfig.Service.ResultsToken = "results-token"
+		serviceConfig.Tenancy.Subjects = []config.TenantSubject{{Method: "api_key", Subject: "ci", Tenant: "team-a"}}
+		serviceConfig.Auth = &config.AuthConfig{
+			Enabled:      true,
+			PublicRoutes: []string{"/api/v1/health", "/metrics"},
+			APIKeys:      &config.APIKeyConfig{Enabled: true, Keys: "ci:" + hex.EncodeToString(hash[:])},
+		}
+	})
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.SetupRoutes()
+	if err != nil {
+		t.Fatalf("SetupRoutes() returned error: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"public health", http.MethodGet, "/api/v1/health", nil, http.StatusOK},
+		{"public metrics", http.MethodGet, "/metrics", nil, http.StatusOK},
+		{"anonymous request", http.MethodGet, "/api/v1/evaluations/jobs", nil, http.StatusUnauthorized},
+		{"anonymous catalog request", http.MethodGet, "/api/v1/evaluations/benchmarks", nil, http.StatusUnauthorized},
+		{"invalid API key", http.MethodGet, "/api/v1/evaluations/jobs", map[string]string{"X-API-Key": "other"}, http.StatusUnauthorized},
+		{"API key", http.MethodGet, "/api/v1/evaluations/jobs", map[string]string{"X-API-Key": "ci-key"}, http.StatusOK},
+		{"remote user is not trusted", http.MethodGet, "/api/v1/evaluations/jobs", map[string]string{"Remote-User": "admin"}, http.StatusUnauthorized},
+		{"results with the results secret", http.MethodPost, "/api/v1/evaluations/jobs/unknown/results", map[string]string{"Authorization": "Bearer results-token"}, http.StatusUnauthorized},
+		{"results without credentials", http.MethodPost, "/api/v1/evaluations/jobs/unknown/results", nil, http.StatusUnauthorized},
+		{"results with an API key", http.MethodPost, "/api/v1/evaluations/jobs/unknown/results", map[string]string{"X-API-Key": "ci-key"}, http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"benchmarks":[]}`))
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tc.status {
+				t.Errorf("Expected status %d for %s %s, got %d", tc.status, tc.method, tc.path, w.Code)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("Expected the WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestJobResultsToken(t *testing.T) {
+	srv, err := createServerWithConfig(8080, func(serviceConfig *config.Config) {
+		serviceConfig.Service.ResultsToken = "results-secret"
+		serviceConfig.Tenancy = &config.TenancyConfig{Header: "X-Tenant", DefaultTenant: "default"}
+	})
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.SetupRoutes()
+	if err != nil {
+		t.Fatalf("SetupRoutes() returned error: %v", err)
+	}
+	request := func(method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	createJob := func(tenant string) string {
+		w := request(http.MethodPost, "/api/v1/evaluations/jobs", `{"model":{"url":"http://localhost:8000","name":"test-model"}}`, map[string]string{"X-Tenant": tenant})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		job := struct {
+			ID string `json:"id"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		return job.ID
+	}
+	id := createJob("team-a")
+	other := createJob("team-a")
+	bearer := func(token string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + token}
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"the token of the job", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/results", bearer(auth.NewJobToken("results-secret", id, "team-a")), http.StatusOK},
+		{"the token of another job", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/results", bearer(auth.NewJobToken("results-secret", other, "team-a")), http.StatusUnauthorized},
+		{"the token of another tenant", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/results", bearer(auth.NewJobToken("results-secret", id, "team-b")), http.StatusUnauthorized},
+		{"the results secret", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/results", bearer("results-secret"), http.StatusUnauthorized},
+		{"no token", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/results", nil, http.StatusUnauthorized},
+		{"an artifact upload without a token", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/artifacts", nil, http.StatusUnauthorized},
+		{"a path that is not the results of a job", http.MethodPost, "/api/v1/evaluations/jobs/" + id + "/results/other", bearer(auth.NewJobToken("results-secret", id, "team-a")), http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := request(tc.method, tc.path, `{"benchmarks":[]}`, tc.headers); w.Code != tc.status {
+				t.Errorf("Expected status %d for %s %s, got %d: %s", tc.status, tc.method, tc.path, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package server_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// sseEvent is an event read from a text/event-stream response
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// sseStream reads the events of a stream in the background
+type sseStream struct {
+	events chan sseEvent
+}
+
+func openStream(t *testing.T, url string, headers map[string]string) *sseStream {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create the request: %v", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	stream := &sseStream{events: make(chan sseEvent, 16)}
+	go func() {
+		defer close(stream.events)
+		scanner := bufio.NewScanner(resp.Body)
+		event := sseEvent{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.event != "" {
+					stream.events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return stream
+}
+
+// next returns the state of the job of the next event
+func (s *sseStream) next(t *testing.T) (sseEvent, string, string) {
+	t.Helper()
+	select {
+	case event, ok := <-s.events:
+		if !ok {
+			t.Fatalf("The stream ended before the next event")
+		}
+		job := struct {
+			ID     string `json:"id"`
+			Status struct {
+				State string `json:"state"`
+			} `json:"status"`
+		}{}
+		if err := json.Unmarshal([]byte(event.data), &job); err != nil {
+			t.Fatalf("Failed to unmarshal the event data %q: %v", event.data, err)
+		}
+		return event, job.ID, job.Status.State
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the next event")
+	}
+	return sseEvent{}, "", ""
+}
+
+func TestEventStreams(t *testing.T) {
+	srv, err := createServerWithConfig(8080, func(serviceConfig *config.Config) {
+		serviceConfig.Tenancy = &config.TenancyConfig{Header: "X-Tenant", DefaultTenant: "default"}
+	})
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	handler, err := srv.SetupRoutes()
+	if err != nil {
+		t.Fatalf("SetupRoutes() returned error: %v", err)
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	request := func(method string, path string, body string, tenant string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to create the request: %v", err)
+		}
+		req.Header.Set("X-Tenant", tenant)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	createJob := func(tenant string) string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/evaluations/jobs", strings.NewReader(`{"model":{"url":"http://localhost:8000","name":"test-model"}}`))
+		req.Header.Set("X-Tenant", tenant)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to create the job: %v", err)
+		}
+		defer resp.Body.Close()
+		job := struct {
+			ID string `json:"id"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&job); err != nil || resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Expected the job to be created, got %d %v", resp.StatusCode, err)
+		}
+		return job.ID
+	}
+
+	t.Run("the job stream starts with the job and sends its changes", func(t *testing.T) {
+		id := createJob("team-a")
+		stream := openStream(t, ts.URL+"/api/v1/evaluations/jobs/"+id+"/events", map[string]string{"X-Tenant": "team-a"})
+
+		snapshot, jobID, _ := stream.next(t)
+		if snapshot.event != "job" || jobID != id || snapshot.id == "" {
+			t.Fatalf("Expected the snapshot of the job %s, got %+v", id, snapshot)
+		}
+		if resp := request(http.MethodDelete, "/api/v1/evaluations/jobs/"+id, "", "team-a"); resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected the job to be cancelled, got %d", resp.StatusCode)
+		}
+		change, jobID, state := stream.next(t)
+		if jobID != id || state != "cancelled" || change.id == snapshot.id {
+			t.Errorf("Expected the cancelled job after the snapshot, got %+v %s", change, state)
+		}
+
+		// a client that resumes after the snapshot is sent the missed change instead of the job
+		resumed := openStream(t, ts.URL+"/api/v1/evaluations/jobs/"+id+"/events", map[string]string{"X-Tenant": "team-a", "Last-Event-ID": snapshot.id})
+		if replayed, _, state := resumed.next(t); replayed.id != change.id || state != "cancelled" {
+			t.Errorf("Expected the event %s to be replayed, got %+v", change.id, replayed)
+		}
+	})
+
+	t.Run("the job stream of another tenant is not found", func(t *testing.T) {
+		id := createJob("team-a")
+		if resp := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id+"/events", "", "team-b"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("the service stream only sends the events of the tenant", func(t *testing.T) {
+		stream := openStream(t, ts.URL+"/api/v1/evaluations/events", map[string]string{"X-Tenant": "team-b"})
+		createJob("team-a")
+		id := createJob("team-b")
+		if event, jobID, _ := stream.next(t); jobID != id {
+			t.Errorf("Expected the event of the job %s of the tenant, got %+v", id, event)
+		}
+	})
+
+	t.Run("an invalid last event id is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/evaluations/events", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
@@ -3,8 +3,9 @@ package server
 import (
 	"context"
 	"net/http"
-	"time"
 
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
 	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
 )
 
@@ -16,6 +17,7 @@ import (
 //   - Enhances the logger with request-specific fields via logging.LoggerWithRequest
 //   - Sets default timeout (60 minutes) and retry attempts (3)
 //   - Initializes an empty metadata map
+//   - Sets the principal authenticated by the authentication middleware
 //
 // This enables automatic request ID tracking (from X-Global-Transaction-Id header or
 // auto-generated UUID) and structured logging with consistent request metadata.
@@ -37,7 +39,7 @@ func (s *Server) newExecutionContext(r *http.Request) *executioncontext.Executio
 	}
 	baseURL := scheme + "://" + r.Host
 
-	return executioncontext.NewExecutionContext(
+	ctx := executioncontext.NewExecutionContext(
 		context.Background(),
 		requestID,
 		enhancedLogger,
@@ -50,10 +52,12 @@ func (s *Server) newExecutionContext(r *http.Request) *executioncontext.Executio
 		"",
 		"",
 		"",
-		time.Minute*60,
-		3,
+		constants.DefaultJobTimeout,
+		constants.DefaultJobRetryAttempts,
 		make(map[string]interface{}),
 		nil,
 		"",
 	)
+	ctx.Principal = auth.PrincipalFromContext(r.Context())
+	return ctx
 }
@@ -0,0 +1,36 @@
+package server_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the tests from the root of the repository so that the server finds the
+// server.yaml and the OpenAPI spec the way it does when it is started from the repository
+func TestMain(m *testing.M) {
+	if err := chdirRepositoryRoot(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// chdirRepositoryRoot changes the working directory to the first parent directory with a go.mod
+func chdirRepositoryRoot() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return os.Chdir(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("the root of the repository was not found")
+		}
+		dir = parent
+	}
+}
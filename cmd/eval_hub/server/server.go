@@ -10,8 +10,15 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.com/julpayne/eval-hub-backend-svc/internal/artifactstore"
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
 	"github.com/julpayne/eval-hub-backend-svc/internal/config"
 	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
 	"github.com/julpayne/eval-hub-backend-svc/internal/handlers"
 
 	"github.com/google/uuid"
@@ -25,6 +32,32 @@ type Server struct {
 	serviceConfig *config.Config
 	storage       abstractions.Storage
 	validate      *validator.Validate
+	catalog       *catalog.Catalog
+	bus           *events.Bus
+	// logStore is nil when the benchmark logs are not served
+	logStore abstractions.LogStore
+	// artifactStore stores the files uploaded for the jobs, the uploads larger than
+	// artifactMaxSize are rejected
+	artifactStore   abstractions.ArtifactStore
+	artifactMaxSize int64
+	// streams is cancelled on shutdown to end the event streams
+	streams      context.Context
+	closeStreams context.CancelFunc
+	// authentication is nil when the authentication is not enabled
+	authentication *auth.Authentication
+	// authorizer is nil when the authorization is not enabled
+	authorizer *authz.Authorizer
+}
+
+// Dependencies are the dependencies of the server that are shared with the rest of the service
+type Dependencies struct {
+	// Catalog is the catalog of the providers and the benchmarks, it is required
+	Catalog *catalog.Catalog
+	// Bus is the bus the storage publishes the changes of the jobs to, the event streams are
+	// not served when it is nil
+	Bus *events.Bus
+	// LogStore is the store of the benchmark logs, the logs are not served when it is nil
+	LogStore abstractions.LogStore
 }
 
 // NewServer creates a new HTTP server instance with the provided logger and configuration.
@@ -37,16 +70,21 @@ type Server struct {
 //   - Routes manually switch on HTTP method in handler functions
 //
 // All routes are wrapped with Prometheus metrics middleware for request duration and
-// status code tracking.
+// status code tracking, and with the authentication middleware when the authentication
+// is enabled.
 //
 // Parameters:
 //   - logger: The structured logger for the server
 //   - serviceConfig: The service configuration containing port and other settings
+//   - storage: The storage for the evaluation jobs and collections
+//   - validate: The validator for the request bodies
+//   - dependencies: The catalog, the event bus and the log store shared with the rest of the service
 //
 // Returns:
 //   - *Server: A configured server instance
-//   - error: An error if logger or serviceConfig is nil
-func NewServer(logger *slog.Logger, serviceConfig *config.Config, storage abstractions.Storage, validate *validator.Validate) (*Server, error) {
+//   - error: An error if logger or serviceConfig is nil, or the authentication, the
+//     authorization, the MLflow tracking or the artifact store is not valid
+func NewServer(logger *slog.Logger, serviceConfig *config.Config, storage abstractions.Storage, validate *validator.Validate, dependencies Dependencies) (*Server, error) {
 	if logger == nil {
 		return nil, fmt.Errorf("logger is required for the server")
 	}
@@ -59,13 +97,50 @@ func NewServer(logger *slog.Logger, serviceConfig *config.Config, storage abstra
 	if validate == nil {
 		return nil, fmt.Errorf("validator is required for the server")
 	}
+	if dependencies.Catalog == nil {
+		return nil, fmt.Errorf("catalog is required for the server")
+	}
+	authentication, err := auth.NewAuthentication(serviceConfig.Auth, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the authentication: %w", err)
+	}
+	if serviceConfig.Tenancy != nil {
+		if err := serviceConfig.Tenancy.CheckConfig(authentication != nil); err != nil {
+			return nil, err
+		}
+	}
+	authorizer, err := authz.NewAuthorizer(serviceConfig.Authorization, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the authorization: %w", err)
+	}
+	if authorizer != nil && authentication == nil {
+		return nil, fmt.Errorf("the authorization requires the authentication to be enabled")
+	}
+	artifactStore, err := artifactstore.NewArtifactStore(serviceConfig, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the artifact store: %w", err)
+	}
+	artifactMaxSize := config.DefaultArtifactMaxSize
+	if serviceConfig.Artifacts != nil {
+		artifactMaxSize = serviceConfig.Artifacts.MaxSize
+	}
 
+	streams, closeStreams := context.WithCancel(context.Background())
 	return &Server{
-		port:          serviceConfig.Service.Port,
-		logger:        logger,
-		serviceConfig: serviceConfig,
-		storage:       storage,
-		validate:      validate,
+		port:            serviceConfig.Service.Port,
+		logger:          logger,
+		serviceConfig:   serviceConfig,
+		storage:         storage,
+		validate:        validate,
+		catalog:         dependencies.Catalog,
+		bus:             dependencies.Bus,
+		logStore:        dependencies.LogStore,
+		artifactStore:   artifactStore,
+		artifactMaxSize: artifactMaxSize,
+		streams:         streams,
+		closeStreams:    closeStreams,
+		authentication:  authentication,
+		authorizer:      authorizer,
 	}, nil
 }
 
@@ -83,7 +158,8 @@ func (s *Server) GetPort() int {
 //   - uri: Request path (from URL.Path or RequestURI)
 //   - user_agent: Client user agent from User-Agent header
 //   - remote_addr: Client IP address
-//   - remote_user: Authenticated user from URL user info or Remote-User header
+//   - remote_user: The authenticated principal, or the user from URL user info or Remote-User header
+//     when the authentication is not enabled
 //   - referer: HTTP referer header
 //
 // This enables correlating logs across services using the request_id and provides
@@ -131,13 +207,18 @@ func (s *Server) loggerWithRequest(r *http.Request) (string, *slog.Logger) {
 		enhancedLogger = enhancedLogger.With(constants.LOG_REMOTE_ADR, remoteAddr)
 	}
 
-	// Extract remote_user from URL user info or header
+	// Extract remote_user from the principal, or from URL user info or header when the
+	// authentication is not enabled (these are not trusted otherwise)
 	remoteUser := ""
-	if r.URL != nil && r.URL.User != nil {
-		remoteUser = r.URL.User.Username()
-	}
-	if remoteUser == "" {
-		remoteUser = r.Header.Get("Remote-User")
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+		remoteUser = principal.Subject
+	} else if s.authentication == nil {
+		if r.URL != nil && r.URL.User != nil {
+			remoteUser = r.URL.User.Username()
+		}
+		if remoteUser == "" {
+			remoteUser = r.Header.Get("Remote-User")
+		}
 	}
 	if remoteUser != "" {
 		enhancedLogger = enhancedLogger.With(constants.LOG_USER, remoteUser)
@@ -153,7 +234,19 @@ func (s *Server) loggerWithRequest(r *http.Request) (string, *slog.Logger) {
 
 func (s *Server) setupRoutes() (http.Handler, error) {
 	router := http.NewServeMux()
-	h := handlers.New(s.storage, s.validate)
+	aggregator, err := aggregation.NewAggregator(s.serviceConfig.Aggregation)
+	if err != nil {
+		return nil, err
+	}
+	h := handlers.NewWithDependencies(s.storage, s.validate, handlers.Dependencies{
+		Aggregator:      aggregator,
+		Catalog:         s.catalog,
+		Authorizer:      s.authorizer,
+		Bus:             s.bus,
+		LogStore:        s.logStore,
+		ArtifactStore:   s.artifactStore,
+		ArtifactMaxSize: s.artifactMaxSize,
+	})
 
 	// Health and status endpoints
 	router.HandleFunc("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
@@ -167,7 +260,10 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 
 	// Evaluation jobs endpoints
 	router.HandleFunc("/api/v1/evaluations/jobs", func(w http.ResponseWriter, r *http.Request) {
-		ctx := s.newExecutionContext(r)
+		ctx, ok := s.newTenantExecutionContext(w, r)
+		if !ok {
+			return
+		}
 		switch r.Method {
 		case http.MethodPost:
 			h.HandleCreateEvaluation(ctx, w)
@@ -179,12 +275,54 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 	})
 	// Handle summary endpoint first (more specific)
 	router.HandleFunc("/api/v1/evaluations/jobs/", func(w http.ResponseWriter, r *http.Request) {
-		ctx := s.newExecutionContext(r)
 		path := r.URL.Path
+		// the results are reported by the runtimes and adapters with the results token of the
+		// job, they are made for the tenant of the job
+		if id, ok := jobReport(r); ok {
+			ctx, ok := s.newJobReportExecutionContext(w, r, id)
+			if !ok {
+				return
+			}
+			if strings.HasSuffix(path, "/results") {
+				h.HandleSubmitEvaluationResults(ctx, w)
+			} else {
+				h.HandleUploadArtifact(ctx, w)
+			}
+			return
+		}
+		ctx, ok := s.newTenantExecutionContext(w, r)
+		if !ok {
+			return
+		}
 		if strings.HasSuffix(path, "/summary") && r.Method == http.MethodGet {
 			h.HandleGetEvaluationSummary(ctx, w)
 			return
 		}
+		if strings.HasSuffix(path, "/deliveries") && r.Method == http.MethodGet {
+			h.HandleListEvaluationDeliveries(ctx, w)
+			return
+		}
+		if strings.Contains(path, "/benchmarks/") && strings.HasSuffix(path, "/logs") && r.Method == http.MethodGet {
+			// a followed log is streamed until the benchmark has finished
+			cancel := s.withStreamContext(ctx, r)
+			defer cancel()
+			h.HandleGetBenchmarkLogs(ctx, w)
+			return
+		}
+		if strings.HasSuffix(path, "/artifacts") && r.Method == http.MethodGet {
+			h.HandleListArtifacts(ctx, w)
+			return
+		}
+		if strings.Contains(path, "/artifacts/") && r.Method == http.MethodGet {
+			h.HandleGetArtifact(ctx, w)
+			return
+		}
+		if strings.HasSuffix(path, "/events") && r.Method == http.MethodGet {
+			cancel := s.withStreamContext(ctx, r)
+			defer cancel()
+			h.HandleEvaluationEvents(ctx, w)
+			return
+		}
 		// Handle individual job endpoints
 		switch r.Method {
 		case http.MethodGet:
@@ -196,6 +334,17 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 		}
 	})
 
+	// Events of all the jobs of the tenant
+	router.HandleFunc("/api/v1/evaluations/events", func(w http.ResponseWriter, r *http.Request) {
+		ctx, ok := s.newTenantExecutionContext(w, r)
+		if !ok {
+			return
+		}
+		cancel := s.withStreamContext(ctx, r)
+		defer cancel()
+		h.HandleListEvents(ctx, w)
+	})
+
 	// Benchmarks endpoint
 	router.HandleFunc("/api/v1/evaluations/benchmarks", func(w http.ResponseWriter, r *http.Request) {
 		ctx := s.newExecutionContext(r)
@@ -204,7 +353,10 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 
 	// Collections endpoints
 	router.HandleFunc("/api/v1/evaluations/collections", func(w http.ResponseWriter, r *http.Request) {
-		ctx := s.newExecutionContext(r)
+		ctx, ok := s.newTenantExecutionContext(w, r)
+		if !ok {
+			return
+		}
 		switch r.Method {
 		case http.MethodPost:
 			h.HandleCreateCollection(ctx, w)
@@ -215,7 +367,10 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 		}
 	})
 	router.HandleFunc("/api/v1/evaluations/collections/", func(w http.ResponseWriter, r *http.Request) {
-		ctx := s.newExecutionContext(r)
+		ctx, ok := s.newTenantExecutionContext(w, r)
+		if !ok {
+			return
+		}
 		switch r.Method {
 		case http.MethodGet:
 			h.HandleGetCollection(ctx, w)
@@ -259,11 +414,24 @@ func (s *Server) setupRoutes() (http.Handler, error) {
 	// Prometheus metrics endpoint
 	router.Handle("/metrics", promhttp.Handler())
 
-	// Wrap router with metrics middleware
-	return Middleware(router), nil
+	// Wrap router with the authentication and the metrics middleware
+	return Middleware(s.authenticate(router)), nil
 }
 
 // SetupRoutes exposes the route setup for testing
+// withStreamContext sets the context of an event stream, unlike the other requests the context
+// is done when the client disconnects or the server shuts down. The returned function releases
+// the context once the stream has ended.
+func (s *Server) withStreamContext(ctx *executioncontext.ExecutionContext, r *http.Request) context.CancelFunc {
+	streamCtx, cancel := context.WithCancel(r.Context())
+	stop := context.AfterFunc(s.streams, cancel)
+	ctx.Ctx = streamCtx
+	return func() {
+		stop()
+		cancel()
+	}
+}
+
 func (s *Server) SetupRoutes() (http.Handler, error) {
 	return s.setupRoutes()
 }
@@ -287,6 +455,17 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// the client certificates are only requested when the mTLS authentication is enabled
+	if mtls := s.mtlsConfig(); mtls != nil {
+		tlsConfig, err := auth.ServerTLSConfig(mtls)
+		if err != nil {
+			return err
+		}
+		s.httpServer.TLSConfig = tlsConfig
+		s.logger.Info("Server starting with TLS", "port", s.port)
+		return s.httpServer.ListenAndServeTLS(mtls.CertFile, mtls.KeyFile)
+	}
+
 	s.logger.Info("Server starting", "port", s.port)
 	return s.httpServer.ListenAndServe()
 }
@@ -299,5 +478,8 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down server gracefully...")
 	// do we need to flush the logs?
 
+	// the event streams do not end on their own so they would hold up the shutdown
+	s.closeStreams()
+
 	return s.httpServer.Shutdown(ctx)
 }
@@ -6,12 +6,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/julpayne/eval-hub-backend-svc/cmd/eval_hub/server"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
 	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
 	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore"
 	"github.com/julpayne/eval-hub-backend-svc/internal/storage"
 	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
 )
@@ -66,41 +70,50 @@ func TestServerSetupRoutes(t *testing.T) {
 	testCases := []struct {
 		method string
 		path   string
+		body   string
 		status int
 	}{
-		{http.MethodGet, "/api/v1/health", http.StatusOK},
-		{http.MethodGet, "/api/v1/status", http.StatusOK},
-		{http.MethodGet, "/metrics", http.StatusOK},
-		{http.MethodGet, "/openapi.yaml", http.StatusOK},
-		{http.MethodGet, "/docs", http.StatusOK},
+		{http.MethodGet, "/api/v1/health", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/status", "", http.StatusOK},
+		{http.MethodGet, "/metrics", "", http.StatusOK},
+		{http.MethodGet, "/openapi.yaml", "", http.StatusOK},
+		{http.MethodGet, "/docs", "", http.StatusOK},
 		// Evaluation endpoints
-		{http.MethodPost, "/api/v1/evaluations/jobs", http.StatusAccepted},
-		{http.MethodGet, "/api/v1/evaluations/jobs", http.StatusOK},
-		{http.MethodGet, "/api/v1/evaluations/jobs/test-id", http.StatusOK},
-		{http.MethodDelete, "/api/v1/evaluations/jobs/test-id", http.StatusOK},
-		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/summary", http.StatusOK},
+		{http.MethodPost, "/api/v1/evaluations/jobs", `{"model":{"url":"http://localhost:8000","name":"test-model"}}`, http.StatusAccepted},
+		{http.MethodGet, "/api/v1/evaluations/jobs", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/evaluations/jobs/test-id", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/summary", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/benchmarks/mmlu/logs", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/events", "", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/evaluations/jobs/test-id/results", `{"benchmarks":[]}`, http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/artifacts", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/evaluations/jobs/test-id/artifacts/sha256:0000", "", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/evaluations/jobs/test-id/artifacts", "content", http.StatusUnauthorized},
 		// Benchmarks
-		{http.MethodGet, "/api/v1/evaluations/benchmarks", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/benchmarks", "", http.StatusOK},
 		// Collections
-		{http.MethodGet, "/api/v1/evaluations/collections", http.StatusOK},
-		{http.MethodPost, "/api/v1/evaluations/collections", http.StatusCreated},
-		{http.MethodGet, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
-		{http.MethodPut, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
-		{http.MethodPatch, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
-		{http.MethodDelete, "/api/v1/evaluations/collections/test-collection", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/collections", "", http.StatusOK},
+		{http.MethodPost, "/api/v1/evaluations/collections", `{"name":"test-collection","benchmarks":[{"id":"mmlu"}]}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/evaluations/collections", "", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/evaluations/collections/test-collection", "", http.StatusNotFound},
+		{http.MethodPut, "/api/v1/evaluations/collections/test-collection", `{"name":"test-collection","benchmarks":[{"id":"mmlu"}]}`, http.StatusNotFound},
+		{http.MethodPatch, "/api/v1/evaluations/collections/test-collection", `[{"op":"replace","path":"/name","value":"renamed"}]`, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/evaluations/collections/test-collection", "", http.StatusNotFound},
 		// Providers
-		{http.MethodGet, "/api/v1/evaluations/providers", http.StatusOK},
-		{http.MethodGet, "/api/v1/evaluations/providers/test-provider", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/providers", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/providers/lm_evaluation_harness", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/evaluations/providers/test-provider", "", http.StatusNotFound},
 		// System metrics
-		{http.MethodGet, "/api/v1/metrics/system", http.StatusOK},
+		{http.MethodGet, "/api/v1/metrics/system", "", http.StatusOK},
 		// Error cases
-		{http.MethodPost, "/api/v1/health", http.StatusMethodNotAllowed},
-		{http.MethodGet, "/nonexistent", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/health", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/nonexistent", "", http.StatusNotFound},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
-			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
 			w := httptest.NewRecorder()
 
 			handler.ServeHTTP(w, req)
@@ -164,6 +177,11 @@ func TestServerShutdown(t *testing.T) {
 }
 
 func createServer(port int) (*server.Server, error) {
+	return createServerWithConfig(port, nil)
+}
+
+// createServerWithConfig creates a server with the configuration changed by the configure function
+func createServerWithConfig(port int, configure func(serviceConfig *config.Config)) (*server.Server, error) {
 	logger, _, err := logging.NewLogger()
 	if err != nil {
 		return nil, err
@@ -177,9 +195,21 @@ func createServer(port int) (*server.Server, error) {
 		return nil, fmt.Errorf("failed to load service config: %w", err)
 	}
 	serviceConfig.Service.Port = port
-	storage, err := storage.NewStorage(serviceConfig, logger)
+	if configure != nil {
+		configure(serviceConfig)
+	}
+	bus := events.NewBus(events.DefaultHistorySize)
+	storage, err := storage.NewStorage(serviceConfig, bus, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage: %w", err)
 	}
-	return server.NewServer(logger, serviceConfig, storage, validate)
+	logStore, err := logstore.NewLogStore(serviceConfig, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log store: %w", err)
+	}
+	providerCatalog, err := catalog.NewCatalog(serviceConfig.Catalog, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+	return server.NewServer(logger, serviceConfig, storage, validate, server.Dependencies{Catalog: providerCatalog, Bus: bus, LogStore: logStore})
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// maxTenantLength is the size of the tenant columns of the tables
+const maxTenantLength = 255
+
+// newTenantExecutionContext creates the execution context of a request that is made on behalf
+// of a tenant, the tenant is resolved with resolveTenant. It returns false when the request has
+// no tenant and the response has already been written.
+func (s *Server) newTenantExecutionContext(w http.ResponseWriter, r *http.Request) (*executioncontext.ExecutionContext, bool) {
+	ctx := s.newExecutionContext(r)
+	tenant, err := s.resolveTenant(r)
+	if err != nil {
+		ctx.Logger.Info("Rejected the request without a valid tenant", "error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	ctx.Tenant = tenant
+	ctx.Logger = ctx.Logger.With("tenant", string(tenant))
+	return ctx, true
+}
+
+// resolveTenant returns the tenant of the request from the claim of the bearer token when a claim
+// is configured, otherwise from the tenant header. The default tenant is returned for a request
+// without a tenant. When the authentication is enabled the tenant is only read from the verified
+// principal, from the claim of its token or from the tenant of its subject, and the header is
+// ignored. Otherwise the signature of the token is not checked and the token is only used to
+// find the tenant.
+func (s *Server) resolveTenant(r *http.Request) (api.Tenant, error) {
+	tenancy := s.serviceConfig.Tenancy
+	if tenancy == nil {
+		return "", fmt.Errorf("the tenancy is not configured")
+	}
+
+	tenant := ""
+	switch {
+	case s.authentication != nil:
+		if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+			tenant = principalTenant(tenancy, principal)
+		}
+	case tenancy.Claim != "":
+		claim, err := tokenClaim(r, tenancy.Claim)
+		if err != nil {
+			return "", err
+		}
+		tenant = claim
+	case tenancy.Header != "":
+		tenant = strings.TrimSpace(r.Header.Get(tenancy.Header))
+	}
+	if tenant == "" {
+		tenant = tenancy.DefaultTenant
+	}
+
+	if tenant == "" {
+		return "", fmt.Errorf("the tenant of the request is required")
+	}
+	if len(tenant) > maxTenantLength {
+		return "", fmt.Errorf("the tenant must not be longer than %d characters", maxTenantLength)
+	}
+	return api.Tenant(tenant), nil
+}
+
+// principalTenant returns the tenant of the claim of the verified token of the principal, or
+// the tenant of its subject
+func principalTenant(tenancy *config.TenancyConfig, principal *auth.Principal) string {
+	if tenancy.Claim != "" {
+		if claim, _ := principal.Claims[tenancy.Claim].(string); strings.TrimSpace(claim) != "" {
+			return strings.TrimSpace(claim)
+		}
+	}
+	for _, subject := range tenancy.Subjects {
+		if subject.Method == string(principal.Method) && subject.Subject == principal.Subject {
+			return subject.Tenant
+		}
+	}
+	return ""
+}
+
+// tokenClaim returns the string claim of the JWT bearer token of the request, an empty string is
+// returned when the request has no bearer token or the token does not have the claim
+func tokenClaim(r *http.Request, claim string) (string, error) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return "", nil
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("the bearer token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("the payload of the bearer token is not valid: %w", err)
+	}
+	claims := map[string]any{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("the claims of the bearer token are not valid: %w", err)
+	}
+	switch value := claims[claim].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimSpace(value), nil
+	default:
+		return "", fmt.Errorf("the %s claim of the bearer token is not a string", claim)
+	}
+}
@@ -0,0 +1,183 @@
+package server_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+func TestTenantResolution(t *testing.T) {
+	serve := func(t *testing.T, tenancy *config.TenancyConfig) func(method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+		srv, err := createServerWithConfig(8080, func(serviceConfig *config.Config) {
+			serviceConfig.Tenancy = tenancy
+		})
+		if err != nil {
+			t.Fatalf("NewServer() returned error: %v", err)
+		}
+		handler, err := srv.SetupRoutes()
+		if err != nil {
+			t.Fatalf("SetupRoutes() returned error: %v", err)
+		}
+		return func(method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+	}
+	createJob := func(t *testing.T, request func(string, string, string, map[string]string) *httptest.ResponseRecorder, headers map[string]string) string {
+		w := request(http.MethodPost, "/api/v1/evaluations/jobs", `{"model":{"url":"http://localhost:8000","name":"test-model"}}`, headers)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		job := struct {
+			ID     string `json:"id"`
+			Tenant string `json:"tenant"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		return job.ID
+	}
+
+	t.Run("the tenant is read from the header", func(t *testing.T) {
+		request := serve(t, &config.TenancyConfig{Header: "X-Tenant", DefaultTenant: "default"})
+		id := createJob(t, request, map[string]string{"X-Tenant": "team-a"})
+
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", map[string]string{"X-Tenant": "team-a"}); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d for the same tenant, got %d", http.StatusOK, w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", map[string]string{"X-Tenant": "team-b"}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for another tenant, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for the default tenant, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id+"/summary", "", map[string]string{"X-Tenant": "team-b"}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for the summary of another tenant, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("requests without a tenant are rejected without a default tenant", func(t *testing.T) {
+		request := serve(t, &config.TenancyConfig{Header: "X-Tenant"})
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs", "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/collections", "", map[string]string{"X-Tenant": strings.Repeat("a", 256)}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for a tenant that is too long, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/benchmarks", "", nil); w.Code != http.StatusOK {
+			t.Errorf("Expected the catalog to not require a tenant, got %d", w.Code)
+		}
+	})
+
+	t.Run("the tenant is read from the token claim", func(t *testing.T) {
+		request := serve(t, &config.TenancyConfig{Header: "X-Tenant", Claim: "tenant"})
+		teamA := map[string]string{"Authorization": "Bearer " + token(t, map[string]any{"sub": "user", "tenant": "team-a"})}
+		id := createJob(t, request, teamA)
+
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", teamA); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d for the same tenant, got %d", http.StatusOK, w.Code)
+		}
+		teamB := map[string]string{
+			"Authorization": "Bearer " + token(t, map[string]any{"tenant": "team-b"}),
+			"X-Tenant":      "team-a",
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", teamB); w.Code != http.StatusNotFound {
+			t.Errorf("Expected the header to be ignored when the claim is configured, got %d", w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", map[string]string{"Authorization": "Bearer not-a-token"}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for a token that is not a JWT, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+id, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d without a token, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// token returns an unsigned JWT with the claims
+func token(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Failed to marshal the claims: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func TestTenantOfPrincipal(t *testing.T) {
+	keys := ""
+	for _, name := range []string{"ci", "other"} {
+		hash := sha256.Sum256([]byte(name + "-key"))
+		keys += name + ":" + hex.EncodeToString(hash[:]) + "\n"
+	}
+	authConfig := &config.AuthConfig{
+		Enabled: true,
+		APIKeys: &config.APIKeyConfig{Enabled: true, Keys: keys},
+	}
+
+	t.Run("the tenant header is not trusted", func(t *testing.T) {
+		_, err := createServerWithConfig(8080, func(serviceConfig *config.Config) {
+			serviceConfig.Tenancy = &config.TenancyConfig{Header: "X-Tenant", DefaultTenant: "default"}
+			serviceConfig.Auth = authConfig
+		})
+		if err == nil {
+			t.Errorf("Expected an error for the tenant header with the authentication enabled")
+		}
+	})
+
+	t.Run("the tenant is read from the subject of the principal", func(t *testing.T) {
+		srv, err := createServerWithConfig(8080, func(serviceConfig *config.Config) {
+			serviceConfig.Tenancy = &config.TenancyConfig{
+				Header:        "X-Tenant",
+				DefaultTenant: "default",
+				Subjects:      []config.TenantSubject{{Method: "api_key", Subject: "ci", Tenant: "team-a"}},
+			}
+			serviceConfig.Auth = authConfig
+		})
+		if err != nil {
+			t.Fatalf("NewServer() returned error: %v", err)
+		}
+		handler, err := srv.SetupRoutes()
+		if err != nil {
+			t.Fatalf("SetupRoutes() returned error: %v", err)
+		}
+		request := func(method string, path string, body string, headers map[string]string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		w := request(http.MethodPost, "/api/v1/evaluations/jobs", `{"model":{"url":"http://localhost:8000","name":"test-model"}}`, map[string]string{"X-API-Key": "ci-key", "X-Tenant": "team-b"})
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		job := struct {
+			ID     string `json:"id"`
+			Tenant string `json:"tenant"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if job.Tenant != "team-a" {
+			t.Errorf("Expected the tenant of the subject, got %s", job.Tenant)
+		}
+		if w := request(http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID, "", map[string]string{"X-API-Key": "other-key", "X-Tenant": "team-a"}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for a principal of the default tenant, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package abstractions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// ArtifactStore stores the artifacts of the evaluation jobs by the SHA-256 digest of their
+// content, the same file uploaded for several jobs or benchmarks is stored once. The digests
+// are "sha256:" followed by the lowercase hex encoding of the hash.
+type ArtifactStore interface {
+	// Put stores the content of the given size and digest, the digest has been computed by the
+	// caller. Storing content that is already stored is not an error.
+	Put(ctx context.Context, digest string, size int64, content io.Reader) error
+	// Get returns the content of the digest and its size, ErrNotFound is returned when the
+	// digest is not stored
+	Get(ctx context.Context, digest string) (io.ReadCloser, int64, error)
+}
+
+// DigestHex returns the hex encoded hash of the digest of an artifact, an error wrapping
+// ErrInvalidArgument is returned when the digest is not a SHA-256 digest
+func DigestHex(digest string) (string, error) {
+	hash, found := strings.CutPrefix(digest, api.ArtifactDigestPrefix)
+	if !found || len(hash) != sha256.Size*2 || strings.ToLower(hash) != hash {
+		return "", fmt.Errorf("%q is not a SHA-256 digest %w", digest, ErrInvalidArgument)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", fmt.Errorf("%q is not a SHA-256 digest %w", digest, ErrInvalidArgument)
+	}
+	return hash, nil
+}
+
+// ArtifactDigest returns the digest of the SHA-256 sum of the content of an artifact
+func ArtifactDigest(sum []byte) string {
+	return api.ArtifactDigestPrefix + hex.EncodeToString(sum)
+}
@@ -0,0 +1,22 @@
+package abstractions
+
+import "errors"
+
+// These errors are returned (wrapped) by the Storage implementations so that the
+// handlers can map them onto the correct HTTP status codes without knowing which
+// storage implementation is in use.
+var (
+	// ErrNotFound is returned when the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrLeaseLost is returned when the lease of an evaluation job is held by another owner
+	ErrLeaseLost = errors.New("lease lost")
+	// ErrInvalidTransition is returned when a state change is not allowed by the state machine
+	ErrInvalidTransition = errors.New("invalid state transition")
+	// ErrConflict is returned when a resource was changed concurrently and the change could not be applied
+	ErrConflict = errors.New("conflict")
+	// ErrInvalidArgument is returned when the request does not match the stored resource
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrReplicaStopped is the cause of the cancellation of the runtime context when the replica
+	// running the job stops, the job is recovered by another replica once its lease has expired
+	ErrReplicaStopped = errors.New("replica stopped")
+)
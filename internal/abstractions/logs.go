@@ -0,0 +1,15 @@
+package abstractions
+
+import "io"
+
+// LogStore stores the logs of the benchmarks of the evaluation jobs. The runtimes write the
+// output of the benchmarks to the store and the handlers serve it, a log can be read while it
+// is still being written.
+type LogStore interface {
+	// Create returns a writer that replaces the log of the benchmark of the job and the
+	// location of the log that is reported in the status of the benchmark
+	Create(jobID string, benchmark string) (io.WriteCloser, string, error)
+	// Open returns the log of the benchmark of the job, ErrNotFound is returned when the
+	// benchmark has no log
+	Open(jobID string, benchmark string) (io.ReadSeekCloser, error)
+}
@@ -0,0 +1,21 @@
+package abstractions
+
+import "time"
+
+// ResultsRecording is the MLflow recording of the results of a job. The storage writes a
+// recording when results are reported without a run id and the recording is claimed by a
+// replica that records the stored results in MLflow.
+type ResultsRecording struct {
+	JobID string `json:"job_id"`
+	// Benchmarks maps the benchmarks to record onto the number of times their results have
+	// been reported, a benchmark reported again while it is being recorded is recorded again
+	Benchmarks map[string]int64 `json:"benchmarks"`
+	// Attempts is the number of failed attempts since the last recording that succeeded
+	Attempts int `json:"attempts"`
+	// RunIDs and ExperimentURL are set by the recorder, they are stored with the results when
+	// the recording is completed
+	RunIDs        map[string]string `json:"-"`
+	ExperimentURL string            `json:"-"`
+	// NextAttemptAt is set by the recorder when some benchmarks could not be recorded
+	NextAttemptAt *time.Time `json:"-"`
+}
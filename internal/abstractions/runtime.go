@@ -1,10 +1,22 @@
 package abstractions
 
-import "github.com/julpayne/eval-hub-backend-svc/pkg/api"
+import (
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
 
 // Runtime interface defines the methods for running evaluation jobs. Concrete implemementation
 // hold the specific aspects of various runtimes (i.e. K8s, local, etc.). No other places in the code should
 // be pointing directly to K8s or other runtime specific details.
 type Runtime interface {
-	RunEvaluationJob(evaluation *api.EvaluationJobResource, storage *Storage) error
+	// This is used to identify the runtime implementation in the logs and error messages
+	GetRuntimeName() string
+
+	// RunEvaluationJob runs all the benchmarks of the evaluation job and blocks until the job
+	// has finished. The progress is reported through the storage. When ctx.Ctx is cancelled the
+	// job has been cancelled or is owned by another replica, the runtime must then stop the
+	// benchmarks and return the context error without updating the job. When the cause of the
+	// cancellation is ErrReplicaStopped the benchmarks that do not run in the replica can be
+	// left running for the replica that recovers the job.
+	RunEvaluationJob(ctx *executioncontext.ExecutionContext, evaluation *api.EvaluationJobResource, storage Storage) error
 }
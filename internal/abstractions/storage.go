@@ -20,12 +20,43 @@ type Storage interface {
 	DeleteEvaluationJob(ctx *executioncontext.ExecutionContext, id string, hardDelete bool) error
 	UpdateBenchmarkStatusForJob(ctx *executioncontext.ExecutionContext, id string, status api.BenchmarkStatus) error
 	UpdateEvaluationJobStatus(ctx *executioncontext.ExecutionContext, id string, state api.EvaluationJobState) error
+	// UpsertEvaluationJobResults replaces the results of the reported benchmarks and keeps the others,
+	// reporting the same results again leaves the job unchanged. The MLflow run id of a benchmark is
+	// kept when its results are reported again without a run id.
+	UpsertEvaluationJobResults(ctx *executioncontext.ExecutionContext, id string, results *api.EvaluationJobResultsConfig) error
+	// AddEvaluationJobArtifact records an artifact stored in the ArtifactStore in the results of the
+	// job, an artifact with the same name and benchmark is replaced
+	AddEvaluationJobArtifact(ctx *executioncontext.ExecutionContext, id string, artifact *api.ArtifactResource) error
 
-	// Collection operations
+	// Evaluation job queue operations, a job is run by the owner of its lease. ClaimEvaluationJob
+	// returns nil when there is no job to claim and the lease operations return ErrLeaseLost
+	// when the lease is held by another owner.
+	ClaimEvaluationJob(ctx *executioncontext.ExecutionContext, owner string, leaseDuration time.Duration) (*api.EvaluationJobResource, error)
+	RenewEvaluationJobLease(ctx *executioncontext.ExecutionContext, id string, owner string, leaseDuration time.Duration) error
+	ReleaseEvaluationJobLease(ctx *executioncontext.ExecutionContext, id string, owner string) error
+
+	// Webhook delivery operations, the deliveries are written to the outbox with the update of a
+	// job that has a callback URL. ClaimWebhookDelivery returns nil when no delivery is due and
+	// RecordWebhookDeliveryAttempt returns ErrLeaseLost when the delivery is claimed by another owner.
+	ClaimWebhookDelivery(ctx *executioncontext.ExecutionContext, owner string, leaseDuration time.Duration) (*api.WebhookDeliveryResource, []byte, error)
+	RecordWebhookDeliveryAttempt(ctx *executioncontext.ExecutionContext, delivery *api.WebhookDeliveryResource, owner string) error
+	GetWebhookDeliveries(ctx *executioncontext.ExecutionContext, jobID string) (*api.WebhookDeliveryResourceList, error)
+
+	// MLflow recording operations, the recordings are written to the outbox with the results when
+	// the MLflow tracking is enabled. ClaimResultsRecording returns nil when no recording is due and
+	// CompleteResultsRecording returns ErrLeaseLost when the recording is claimed by another owner.
+	ClaimResultsRecording(ctx *executioncontext.ExecutionContext, owner string, leaseDuration time.Duration) (*ResultsRecording, error)
+	CompleteResultsRecording(ctx *executioncontext.ExecutionContext, recording *ResultsRecording, owner string) error
+
+	// Collection operations, the name of a collection is unique per tenant and creating or
+	// renaming a collection with a name that is already used returns ErrConflict
 	CreateCollection(ctx *executioncontext.ExecutionContext, collection *api.CollectionResource) error
 	GetCollection(ctx *executioncontext.ExecutionContext, id string, summary bool) (*api.CollectionResource, error)
 	GetCollections(ctx *executioncontext.ExecutionContext, limit int, offset int) (*api.CollectionResourceList, error)
 	UpdateCollection(ctx *executioncontext.ExecutionContext, collection *api.CollectionResource) error
+	// PatchCollection applies the JSON patch to the collection and stores it if the check of the
+	// patched collection succeeds, the collection is not changed when the patch or the check fails
+	PatchCollection(ctx *executioncontext.ExecutionContext, id string, patch api.Patch, check func(collection *api.CollectionResource) error) (*api.CollectionResource, error)
 	DeleteCollection(ctx *executioncontext.ExecutionContext, id string) error
 
 	// Close the storage connection
@@ -0,0 +1,113 @@
+package aggregation
+
+import (
+	"fmt"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The names of the aggregated metrics that are not named after a benchmark metric
+const (
+	ScoreMetric    = "score"
+	PassedMetric   = "passed"
+	categoryPrefix = "category/"
+	passedSuffix   = "/passed"
+)
+
+// BenchmarkResult is the input of the aggregation functions, it holds the result of a
+// benchmark together with the weight and the category of the benchmark in the job
+type BenchmarkResult struct {
+	Name     string
+	Category string
+	Weight   float64
+	Metrics  map[string]any
+	Failed   bool
+}
+
+// Function computes aggregated metrics from the benchmark results and adds them to the
+// aggregated metrics, it can read the metrics added by the functions run before it
+type Function func(results []BenchmarkResult, aggregated map[string]any)
+
+// Aggregator runs the configured aggregation functions
+type Aggregator struct {
+	functions    []Function
+	scoreMetrics []string
+}
+
+// NewAggregator creates the aggregation functions listed in the configuration, the default
+// configuration is used when aggregationConfig is nil
+func NewAggregator(aggregationConfig *config.AggregationConfig) (*Aggregator, error) {
+	if aggregationConfig == nil {
+		aggregationConfig = &config.AggregationConfig{}
+	}
+	if err := aggregationConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	functions := []Function{}
+	for _, name := range aggregationConfig.Functions {
+		switch name {
+		case config.AggregationWeightedMean:
+			functions = append(functions, WeightedMean(aggregationConfig.ScoreMetrics))
+		case config.AggregationCategoryRollup:
+			functions = append(functions, CategoryRollup(aggregationConfig.ScoreMetrics))
+		case config.AggregationThresholds:
+			functions = append(functions, Thresholds(aggregationConfig.Thresholds))
+		default:
+			return nil, fmt.Errorf("unknown aggregation function %s", name)
+		}
+	}
+	return &Aggregator{functions: functions, scoreMetrics: aggregationConfig.ScoreMetrics}, nil
+}
+
+// Aggregate runs the aggregation functions in order and returns the aggregated metrics
+func (a *Aggregator) Aggregate(results []BenchmarkResult) map[string]any {
+	aggregated := map[string]any{}
+	for _, function := range a.functions {
+		function(results, aggregated)
+	}
+	return aggregated
+}
+
+// AggregateJob computes the aggregated metrics of the job from its benchmark results, the
+// computed metrics replace the reported metrics with the same name and the others are kept
+func (a *Aggregator) AggregateJob(job *api.EvaluationJobResource) {
+	if job.Results == nil || len(job.Results.Benchmarks) == 0 {
+		return
+	}
+	results := make([]BenchmarkResult, 0, len(job.Results.Benchmarks))
+	for _, benchmarkResult := range job.Results.Benchmarks {
+		result := BenchmarkResult{
+			Name:    benchmarkResult.Name,
+			Weight:  1,
+			Metrics: benchmarkResult.Metrics,
+			Failed:  benchmarkResult.State == api.StateFailed,
+		}
+		for _, benchmark := range job.Benchmarks {
+			if benchmark.ID != benchmarkResult.Name {
+				continue
+			}
+			result.Category = benchmark.Category
+			if benchmark.Weight != nil {
+				result.Weight = *benchmark.Weight
+			}
+		}
+		results = append(results, result)
+	}
+	if job.Results.AggregatedMetrics == nil {
+		job.Results.AggregatedMetrics = map[string]any{}
+	}
+	for name, value := range a.Aggregate(results) {
+		job.Results.AggregatedMetrics[name] = value
+	}
+}
+
+// CategoryMetric returns the name of the aggregated score of a category
+func CategoryMetric(category string) string {
+	return categoryPrefix + category
+}
+
+// PassedMetricFor returns the name of the aggregated metric telling if the threshold of the metric is met
+func PassedMetricFor(metric string) string {
+	return metric + passedSuffix
+}
@@ -0,0 +1,132 @@
+package aggregation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+var results = []aggregation.BenchmarkResult{
+	{Name: "mmlu", Category: "knowledge", Weight: 2, Metrics: map[string]any{"acc": 0.6, "acc_norm": 0.7}},
+	{Name: "arc", Category: "reasoning", Weight: 1, Metrics: map[string]any{"acc": 0.3}},
+	{Name: "gsm8k", Category: "reasoning", Weight: 1, Metrics: map[string]any{"exact_match": "0.5", "model": "test-model"}},
+}
+
+func TestWeightedMean(t *testing.T) {
+	aggregated := map[string]any{}
+	aggregation.WeightedMean(config.DefaultScoreMetrics)(results, aggregated)
+
+	expected := map[string]float64{
+		"acc":         (0.6*2 + 0.3) / 3,
+		"acc_norm":    0.7,
+		"exact_match": 0.5,
+		"score":       (0.7*2 + 0.3 + 0.5) / 4,
+	}
+	for name, value := range expected {
+		assertFloat(t, aggregated, name, value)
+	}
+	if _, found := aggregated["model"]; found {
+		t.Errorf("Expected the non numeric metrics to be ignored, got %v", aggregated["model"])
+	}
+}
+
+func TestCategoryRollup(t *testing.T) {
+	aggregated := map[string]any{}
+	uncategorized := append(results, aggregation.BenchmarkResult{Name: "custom", Weight: 1, Metrics: map[string]any{"score": 1.0}})
+	aggregation.CategoryRollup(config.DefaultScoreMetrics)(uncategorized, aggregated)
+
+	assertFloat(t, aggregated, "category/knowledge", 0.7)
+	assertFloat(t, aggregated, "category/reasoning", 0.4)
+	if len(aggregated) != 2 {
+		t.Errorf("Expected 2 categories, got %v", aggregated)
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	thresholds := []config.ThresholdConfig{
+		{Metric: "score", Min: 0.5},
+		{Metric: "category/reasoning", Min: 0.5},
+	}
+	testCases := []struct {
+		name       string
+		aggregated map[string]any
+		failed     bool
+		passed     bool
+	}{
+		{"all thresholds met", map[string]any{"score": 0.6, "category/reasoning": 0.5}, false, true},
+		{"a threshold is not met", map[string]any{"score": 0.6, "category/reasoning": 0.4}, false, false},
+		{"a metric is missing", map[string]any{"score": 0.6}, false, false},
+		{"a benchmark failed", map[string]any{"score": 0.6, "category/reasoning": 0.5}, true, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results := []aggregation.BenchmarkResult{{Name: "mmlu", Failed: tc.failed}}
+			aggregation.Thresholds(thresholds)(results, tc.aggregated)
+			if tc.aggregated["passed"] != tc.passed {
+				t.Errorf("Expected passed to be %v, got %v", tc.passed, tc.aggregated["passed"])
+			}
+			if tc.aggregated["score/passed"] != true {
+				t.Errorf("Expected the score threshold to be met, got %v", tc.aggregated["score/passed"])
+			}
+		})
+	}
+}
+
+func TestNewAggregator(t *testing.T) {
+	if _, err := aggregation.NewAggregator(&config.AggregationConfig{Functions: []string{"median"}}); err == nil {
+		t.Error("Expected an error for an unknown aggregation function")
+	}
+
+	aggregator, err := aggregation.NewAggregator(&config.AggregationConfig{
+		Thresholds: []config.ThresholdConfig{{Metric: "category/reasoning", Min: 0.5}},
+	})
+	if err != nil {
+		t.Fatalf("NewAggregator() returned error: %v", err)
+	}
+	aggregated := aggregator.Aggregate(results)
+	assertFloat(t, aggregated, "category/reasoning", 0.4)
+	if aggregated["passed"] != false {
+		t.Errorf("Expected the evaluation to fail the reasoning threshold, got %v", aggregated["passed"])
+	}
+}
+
+func TestAggregateJob(t *testing.T) {
+	aggregator, err := aggregation.NewAggregator(nil)
+	if err != nil {
+		t.Fatalf("NewAggregator() returned error: %v", err)
+	}
+	weight := 3.0
+	job := &api.EvaluationJobResource{
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Benchmarks: []api.BenchmarkConfig{
+				{Ref: api.Ref{ID: "mmlu"}, Weight: &weight, Category: "knowledge"},
+				{Ref: api.Ref{ID: "hellaswag"}},
+			},
+		},
+		Results: &api.EvaluationJobResults{
+			Benchmarks: []api.EvaluationJobBenchmarkResult{
+				{Name: "mmlu", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.5}},
+				{Name: "hellaswag", State: api.StateCompleted, Metrics: map[string]any{"acc": 0.9}},
+			},
+			AggregatedMetrics: map[string]any{"reported": "kept", "score": 0.0},
+		},
+	}
+	aggregator.AggregateJob(job)
+
+	assertFloat(t, job.Results.AggregatedMetrics, "score", 0.6)
+	assertFloat(t, job.Results.AggregatedMetrics, "category/knowledge", 0.5)
+	if job.Results.AggregatedMetrics["reported"] != "kept" {
+		t.Errorf("Expected the reported metrics to be kept, got %v", job.Results.AggregatedMetrics)
+	}
+}
+
+func assertFloat(t *testing.T, aggregated map[string]any, name string, expected float64) {
+	t.Helper()
+	value, ok := aggregated[name].(float64)
+	if !ok || math.Abs(value-expected) > 1e-9 {
+		t.Errorf("Expected %s to be %v, got %v", name, expected, aggregated[name])
+	}
+}
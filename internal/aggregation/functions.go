@@ -0,0 +1,134 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// WeightedMean adds the weighted mean of each numeric metric over the benchmarks that report
+// it, and the weighted mean of the benchmark scores as the score metric
+func WeightedMean(scoreMetrics []string) Function {
+	return func(results []BenchmarkResult, aggregated map[string]any) {
+		means := map[string]*mean{}
+		score := &mean{}
+		for _, result := range results {
+			for name, value := range result.Metrics {
+				if v, ok := toFloat(value); ok {
+					if means[name] == nil {
+						means[name] = &mean{}
+					}
+					means[name].add(v, result.Weight)
+				}
+			}
+			if v, ok := benchmarkScore(result, scoreMetrics); ok {
+				score.add(v, result.Weight)
+			}
+		}
+		for name, m := range means {
+			if v, ok := m.value(); ok {
+				aggregated[name] = v
+			}
+		}
+		if v, ok := score.value(); ok {
+			aggregated[ScoreMetric] = v
+		}
+	}
+}
+
+// CategoryRollup adds the weighted mean of the benchmark scores of each category, the
+// benchmarks without a category are not rolled up
+func CategoryRollup(scoreMetrics []string) Function {
+	return func(results []BenchmarkResult, aggregated map[string]any) {
+		categories := map[string]*mean{}
+		for _, result := range results {
+			if result.Category == "" {
+				continue
+			}
+			if v, ok := benchmarkScore(result, scoreMetrics); ok {
+				if categories[result.Category] == nil {
+					categories[result.Category] = &mean{}
+				}
+				categories[result.Category].add(v, result.Weight)
+			}
+		}
+		for category, m := range categories {
+			if v, ok := m.value(); ok {
+				aggregated[CategoryMetric(category)] = v
+			}
+		}
+	}
+}
+
+// Thresholds adds whether each aggregated metric meets its minimum and whether the evaluation
+// passed, the evaluation fails if a benchmark failed or a threshold is not met. A metric that
+// has not been computed does not meet its threshold.
+func Thresholds(thresholds []config.ThresholdConfig) Function {
+	return func(results []BenchmarkResult, aggregated map[string]any) {
+		passed := true
+		for _, result := range results {
+			if result.Failed {
+				passed = false
+			}
+		}
+		for _, threshold := range thresholds {
+			v, ok := toFloat(aggregated[threshold.Metric])
+			met := ok && v >= threshold.Min
+			aggregated[PassedMetricFor(threshold.Metric)] = met
+			passed = passed && met
+		}
+		aggregated[PassedMetric] = passed
+	}
+}
+
+// benchmarkScore returns the first score metric reported by the benchmark
+func benchmarkScore(result BenchmarkResult, scoreMetrics []string) (float64, bool) {
+	for _, name := range scoreMetrics {
+		if v, ok := toFloat(result.Metrics[name]); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// toFloat converts the numeric metrics, the metrics are decoded from JSON so the numbers
+// are usually float64 but the metrics can also be reported as strings
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// mean accumulates a weighted mean, the benchmarks with a zero weight are ignored
+type mean struct {
+	sum    float64
+	weight float64
+}
+
+func (m *mean) add(value float64, weight float64) {
+	m.sum += value * weight
+	m.weight += weight
+}
+
+func (m *mean) value() (float64, bool) {
+	if m.weight <= 0 {
+		return 0, false
+	}
+	return m.sum / m.weight, true
+}
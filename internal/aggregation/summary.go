@@ -0,0 +1,118 @@
+package aggregation
+
+import (
+	"slices"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Summarize returns the compact report of the job, the headline metric of a benchmark is its
+// score metric or its only numeric metric. The duration of the work that has not finished is
+// measured until now.
+func (a *Aggregator) Summarize(job *api.EvaluationJobResource, now time.Time) *api.EvaluationJobSummary {
+	summary := &api.EvaluationJobSummary{
+		ID:              job.ID,
+		State:           job.Status.State,
+		Message:         job.Status.Message,
+		StartedAt:       job.Status.StartedAt,
+		CompletedAt:     job.Status.CompletedAt,
+		DurationSeconds: duration(job.Status.StartedAt, job.Status.CompletedAt, now),
+		Benchmarks:      []api.BenchmarkSummary{},
+	}
+
+	// the requested benchmarks come first in the order of the request
+	names := []string{}
+	for _, benchmark := range job.Benchmarks {
+		names = appendName(names, benchmark.ID)
+	}
+	for _, status := range job.Status.Benchmarks {
+		names = appendName(names, status.Name)
+	}
+	if job.Results != nil {
+		for _, result := range job.Results.Benchmarks {
+			names = appendName(names, result.Name)
+		}
+	}
+
+	for _, name := range names {
+		benchmark := api.BenchmarkSummary{Name: name, State: api.StatePending}
+		for _, status := range job.Status.Benchmarks {
+			if status.Name == name {
+				benchmark.State = status.State
+				benchmark.DurationSeconds = duration(status.StartedAt, status.CompletedAt, now)
+				if status.State == api.StateFailed || status.State == api.StateCancelled {
+					benchmark.Message = status.Message
+				}
+			}
+		}
+		if job.Results != nil {
+			for _, result := range job.Results.Benchmarks {
+				if result.Name != name {
+					continue
+				}
+				benchmark.Metric, benchmark.Value = a.headlineMetric(result.Metrics)
+				if result.Error != nil {
+					benchmark.Message = *result.Error
+				}
+			}
+		}
+		switch benchmark.State {
+		case api.StateCompleted:
+			summary.CompletedEvaluations++
+		case api.StateFailed:
+			summary.FailedEvaluations++
+		}
+		summary.Benchmarks = append(summary.Benchmarks, benchmark)
+	}
+	summary.TotalEvaluations = len(summary.Benchmarks)
+
+	if job.Results != nil {
+		if score, ok := toFloat(job.Results.AggregatedMetrics[ScoreMetric]); ok {
+			summary.Score = &score
+		}
+		if passed, ok := job.Results.AggregatedMetrics[PassedMetric].(bool); ok {
+			summary.Passed = &passed
+		}
+	}
+	return summary
+}
+
+func (a *Aggregator) headlineMetric(metrics map[string]any) (string, *float64) {
+	for _, name := range a.scoreMetrics {
+		if v, ok := toFloat(metrics[name]); ok {
+			return name, &v
+		}
+	}
+	// a benchmark that reports a single numeric metric has an obvious headline
+	headline := ""
+	var value *float64
+	for name, metric := range metrics {
+		if v, ok := toFloat(metric); ok {
+			if value != nil {
+				return "", nil
+			}
+			headline, value = name, &v
+		}
+	}
+	return headline, value
+}
+
+func duration(startedAt *time.Time, completedAt *time.Time, now time.Time) *float64 {
+	if startedAt == nil {
+		return nil
+	}
+	end := now
+	if completedAt != nil {
+		end = *completedAt
+	}
+	seconds := end.Sub(*startedAt).Seconds()
+	return &seconds
+}
+
+func appendName(names []string, name string) []string {
+	if slices.Contains(names, name) {
+		return names
+	}
+	return append(names, name)
+}
@@ -0,0 +1,72 @@
+package aggregation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestSummarize(t *testing.T) {
+	aggregator, err := aggregation.NewAggregator(nil)
+	if err != nil {
+		t.Fatalf("NewAggregator() returned error: %v", err)
+	}
+	startedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	completedAt := startedAt.Add(90 * time.Second)
+	now := startedAt.Add(time.Hour)
+	failure := "out of memory"
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: "job-1"},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "gsm8k"}}, {Ref: api.Ref{ID: "arc"}}},
+		},
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: api.StateRunning, Message: "Evaluation job running"},
+			StartedAt:          &startedAt,
+			Benchmarks: []api.BenchmarkStatus{
+				{Name: "mmlu", State: api.StateCompleted, StartedAt: &startedAt, CompletedAt: &completedAt},
+				{Name: "gsm8k", State: api.StateFailed, StartedAt: &startedAt, CompletedAt: &completedAt, Message: "Benchmark failed"},
+			},
+		},
+		Results: &api.EvaluationJobResults{
+			Benchmarks: []api.EvaluationJobBenchmarkResult{
+				{Name: "mmlu", Metrics: map[string]any{"acc": 0.5, "acc_stderr": 0.01}},
+				{Name: "gsm8k", Metrics: map[string]any{"flexible_match": 0.25}, Error: &failure},
+			},
+			AggregatedMetrics: map[string]any{"score": 0.5, "passed": false},
+		},
+	}
+
+	summary := aggregator.Summarize(job, now)
+	if summary.ID != "job-1" || summary.State != api.StateRunning || *summary.DurationSeconds != 3600 {
+		t.Errorf("Unexpected job summary: %+v", summary)
+	}
+	if summary.TotalEvaluations != 3 || summary.CompletedEvaluations != 1 || summary.FailedEvaluations != 1 {
+		t.Errorf("Expected 1 completed and 1 failed of 3, got %d and %d of %d", summary.CompletedEvaluations, summary.FailedEvaluations, summary.TotalEvaluations)
+	}
+	if summary.Score == nil || *summary.Score != 0.5 || summary.Passed == nil || *summary.Passed {
+		t.Errorf("Expected the aggregated score and passed, got %v and %v", summary.Score, summary.Passed)
+	}
+
+	expected := []struct {
+		name    string
+		state   api.State
+		metric  string
+		message string
+	}{
+		{"mmlu", api.StateCompleted, "acc", ""},
+		{"gsm8k", api.StateFailed, "flexible_match", "out of memory"},
+		{"arc", api.StatePending, "", ""},
+	}
+	for i, e := range expected {
+		benchmark := summary.Benchmarks[i]
+		if benchmark.Name != e.name || benchmark.State != e.state || benchmark.Metric != e.metric || benchmark.Message != e.message {
+			t.Errorf("Expected %+v, got %+v", e, benchmark)
+		}
+	}
+	if *summary.Benchmarks[0].DurationSeconds != 90 || summary.Benchmarks[2].DurationSeconds != nil {
+		t.Errorf("Unexpected benchmark durations: %v and %v", summary.Benchmarks[0].DurationSeconds, summary.Benchmarks[2].DurationSeconds)
+	}
+}
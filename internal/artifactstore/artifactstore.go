@@ -0,0 +1,28 @@
+package artifactstore
+
+import (
+	"log/slog"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/artifactstore/artifactstore_fs"
+	"github.com/julpayne/eval-hub-backend-svc/internal/artifactstore/artifactstore_s3"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// NewArtifactStore creates the store of the job artifacts, the artifacts are kept in the S3
+// bucket when it is enabled and on the local filesystem of the replica otherwise
+func NewArtifactStore(serviceConfig *config.Config, logger *slog.Logger) (abstractions.ArtifactStore, error) {
+	artifactsConfig := serviceConfig.Artifacts
+	if artifactsConfig == nil {
+		artifactsConfig = &config.ArtifactsConfig{}
+	}
+	if err := artifactsConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	if s3Config := artifactsConfig.S3; s3Config != nil && s3Config.Enabled {
+		logger.Info("Using S3 artifact store", "endpoint", s3Config.Endpoint, "bucket", s3Config.Bucket, "prefix", s3Config.Prefix)
+		return artifactstore_s3.NewS3ArtifactStore(s3Config)
+	}
+	logger.Info("Using filesystem artifact store", "dir", artifactsConfig.Dir)
+	return artifactstore_fs.NewFSArtifactStore(artifactsConfig.Dir)
+}
@@ -0,0 +1,97 @@
+package artifactstore_fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+)
+
+// FSArtifactStore keeps each artifact in a file of the local filesystem, the artifact with the
+// digest sha256:<hash> is <dir>/sha256/<first two characters of the hash>/<hash>
+type FSArtifactStore struct {
+	dir string
+}
+
+// NewFSArtifactStore creates the directory of the artifacts if it does not exist
+func NewFSArtifactStore(dir string) (abstractions.ArtifactStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the filesystem artifact store requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the artifacts directory: %w", err)
+	}
+	return &FSArtifactStore{dir: dir}, nil
+}
+
+// Put writes the content to a temporary file that is renamed when the content matches the
+// digest, a reader never sees a partially written artifact
+func (s *FSArtifactStore) Put(ctx context.Context, digest string, size int64, content io.Reader) error {
+	path, err := s.path(digest)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create the artifacts directory: %w", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the artifact file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(content, size+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the artifact file: %w", err)
+	}
+	if written != size || abstractions.ArtifactDigest(hash.Sum(nil)) != digest {
+		return fmt.Errorf("the content does not match the size %d and the digest %s %w", size, digest, abstractions.ErrInvalidArgument)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to store the artifact file: %w", err)
+	}
+	return nil
+}
+
+func (s *FSArtifactStore) Get(ctx context.Context, digest string) (io.ReadCloser, int64, error) {
+	path, err := s.path(digest)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, 0, fmt.Errorf("artifact %s %w", digest, abstractions.ErrNotFound)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// path returns the file of the digest, the digest is checked so that it can not escape the
+// artifacts directory
+func (s *FSArtifactStore) path(digest string) (string, error) {
+	hash, err := abstractions.DigestHex(digest)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, "sha256", hash[:2], hash), nil
+}
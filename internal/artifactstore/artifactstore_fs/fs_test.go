@@ -0,0 +1,70 @@
+package artifactstore_fs_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/artifactstore/artifactstore_fs"
+)
+
+func TestFSArtifactStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := artifactstore_fs.NewFSArtifactStore(dir)
+	if err != nil {
+		t.Fatalf("NewFSArtifactStore() returned error: %v", err)
+	}
+	ctx := context.Background()
+	content := "accuracy,0.5\n"
+	sum := sha256.Sum256([]byte(content))
+	digest := abstractions.ArtifactDigest(sum[:])
+
+	t.Run("an artifact is stored by its digest", func(t *testing.T) {
+		if err := store.Put(ctx, digest, int64(len(content)), strings.NewReader(content)); err != nil {
+			t.Fatalf("Put() returned error: %v", err)
+		}
+		hash, _ := abstractions.DigestHex(digest)
+		if _, err := os.Stat(filepath.Join(dir, "sha256", hash[:2], hash)); err != nil {
+			t.Errorf("Expected the artifact file, got %v", err)
+		}
+		reader, size, err := store.Get(ctx, digest)
+		if err != nil {
+			t.Fatalf("Get() returned error: %v", err)
+		}
+		defer reader.Close()
+		if got, _ := io.ReadAll(reader); string(got) != content || size != int64(len(content)) {
+			t.Errorf("Expected %q of %d bytes, got %q of %d bytes", content, len(content), got, size)
+		}
+	})
+
+	t.Run("storing the same content again is not an error", func(t *testing.T) {
+		if err := store.Put(ctx, digest, int64(len(content)), strings.NewReader(content)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("content that does not match the digest is rejected", func(t *testing.T) {
+		other := sha256.Sum256([]byte("other"))
+		otherDigest := abstractions.ArtifactDigest(other[:])
+		if err := store.Put(ctx, otherDigest, int64(len(content)), strings.NewReader(content)); !errors.Is(err, abstractions.ErrInvalidArgument) {
+			t.Errorf("Expected ErrInvalidArgument, got %v", err)
+		}
+		if _, _, err := store.Get(ctx, otherDigest); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("digests that are not SHA-256 digests are rejected", func(t *testing.T) {
+		for _, digest := range []string{"", "md5:abc", "sha256:../../etc/passwd", "sha256:" + strings.Repeat("G", 64)} {
+			if _, _, err := store.Get(ctx, digest); !errors.Is(err, abstractions.ErrInvalidArgument) {
+				t.Errorf("Expected ErrInvalidArgument for %q, got %v", digest, err)
+			}
+		}
+	})
+}
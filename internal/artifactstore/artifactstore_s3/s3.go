@@ -0,0 +1,153 @@
+package artifactstore_s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// maxErrorBody is the number of bytes of an error response that are read
+const maxErrorBody = 4096
+
+// S3Error is an error response of the S3 API
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("S3 returned %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// S3ArtifactStore keeps each artifact in an object of an S3-compatible bucket, the artifact with
+// the digest sha256:<hash> is the object <prefix>sha256/<hash>. The objects are addressed with
+// path-style URLs and the requests are signed with AWS Signature Version 4, the payload hash of
+// an upload is the hash of the artifact so the content is checked by the object storage.
+type S3ArtifactStore struct {
+	endpoint    string
+	bucket      string
+	prefix      string
+	region      string
+	credentials Credentials
+	client      *http.Client
+}
+
+// NewS3ArtifactStore returns a store of the bucket of the configuration, the bucket must exist
+func NewS3ArtifactStore(s3Config *config.S3ArtifactsConfig) (abstractions.ArtifactStore, error) {
+	if err := s3Config.CheckConfig(); err != nil {
+		return nil, err
+	}
+	endpoint, err := url.Parse(s3Config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", s3Config.Endpoint)
+	}
+	return &S3ArtifactStore{
+		endpoint: strings.TrimSuffix(s3Config.Endpoint, "/"),
+		bucket:   s3Config.Bucket,
+		prefix:   s3Config.Prefix,
+		region:   s3Config.Region,
+		credentials: Credentials{
+			AccessKeyID:     s3Config.AccessKeyID,
+			SecretAccessKey: s3Config.SecretAccessKey,
+		},
+		client: &http.Client{Timeout: s3Config.Timeout},
+	}, nil
+}
+
+// Put uploads the content unless the object of the digest already exists
+func (s *S3ArtifactStore) Put(ctx context.Context, digest string, size int64, content io.Reader) error {
+	hash, err := abstractions.DigestHex(digest)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodHead, hash, UnsignedPayload, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+	default:
+		return &S3Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+	}
+
+	resp, err = s.do(ctx, http.MethodPut, hash, hash, io.LimitReader(content, size), size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errorResponse(resp)
+	}
+	return nil
+}
+
+func (s *S3ArtifactStore) Get(ctx context.Context, digest string) (io.ReadCloser, int64, error) {
+	hash, err := abstractions.DigestHex(digest)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, hash, UnsignedPayload, nil, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		err := errorResponse(resp)
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, 0, fmt.Errorf("artifact %s %w: %w", digest, abstractions.ErrNotFound, err)
+		}
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// do sends a signed request for the object of the hash
+func (s *S3ArtifactStore) do(ctx context.Context, method string, hash string, payloadHash string, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(hash), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	SignV4(req, payloadHash, s.credentials, s.region, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s the artifact object: %w", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+func (s *S3ArtifactStore) objectURL(hash string) string {
+	key := s.prefix + "sha256/" + hash
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.endpoint + "/" + url.PathEscape(s.bucket) + "/" + strings.Join(segments, "/")
+}
+
+// errorResponse returns the error of a response that is not successful, the body of a HEAD
+// response is empty so only the status is known
+func errorResponse(resp *http.Response) error {
+	s3Error := &S3Error{StatusCode: resp.StatusCode}
+	// the body is only used for the error code and the message
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(s3Error); err != nil && !errors.Is(err, io.EOF) {
+		s3Error.Message = http.StatusText(resp.StatusCode)
+	}
+	return s3Error
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+type apiKey struct {
+	name string
+	hash []byte
+}
+
+// APIKeyAuthenticator authenticates the requests with the static API keys of the header,
+// only the hashes of the keys are kept
+type APIKeyAuthenticator struct {
+	header string
+	keys   []apiKey
+}
+
+// NewAPIKeyAuthenticator parses the hashed keys, one key per line as <name>:<hex encoded
+// SHA-256 hash>. The empty lines and the lines starting with # are ignored.
+func NewAPIKeyAuthenticator(apiKeyConfig *config.APIKeyConfig) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{header: apiKeyConfig.Header}
+	scanner := bufio.NewScanner(strings.NewReader(apiKeyConfig.Keys))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, hash, found := strings.Cut(text, ":")
+		name = strings.TrimSpace(name)
+		decoded, err := hex.DecodeString(strings.TrimSpace(hash))
+		if !found || name == "" || err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("the API key on line %d must be <name>:<hex encoded SHA-256 hash>", line)
+		}
+		a.keys = append(a.keys, apiKey{name: name, hash: decoded})
+	}
+	if len(a.keys) == 0 {
+		return nil, fmt.Errorf("the API keys are enabled without any key")
+	}
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := strings.TrimSpace(r.Header.Get(a.header))
+	if key == "" {
+		return nil, nil
+	}
+	hash := sha256.Sum256([]byte(key))
+	// all the keys are compared so that the time does not depend on the matching key
+	name := ""
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash) == 1 {
+			name = k.name
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the API key is not valid")
+	}
+	return &Principal{Subject: name, Method: MethodAPIKey}, nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// ErrUnauthenticated is returned when a request does not have credentials for any of the
+// enabled authentication methods
+var ErrUnauthenticated = errors.New("the request is not authenticated")
+
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+	MethodMTLS   Method = "mtls"
+	// MethodResultsToken is the method of the results token of a job, the subject of the
+	// principal is the id of the job
+	MethodResultsToken Method = "results_token"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller, this is the subject claim of the token, the name of
+	// the API key or the subject of the client certificate
+	Subject string `json:"subject"`
+	Method  Method `json:"method"`
+	// Claims are the claims of the verified token, they are only set for the JWT method
+	Claims map[string]any `json:"claims,omitempty"`
+}
+
+// Authenticator authenticates the requests with one method. It returns nil without an error
+// when the request has no credentials for the method, and an error when the credentials are
+// not valid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// Authentication authenticates the requests with the enabled methods, in the order client
+// certificate, API key and bearer token. The first method with credentials decides.
+type Authentication struct {
+	authenticators []Authenticator
+	publicRoutes   []string
+}
+
+// NewAuthentication creates the authenticators of the enabled methods, it returns nil when
+// the authentication is not enabled
+func NewAuthentication(authConfig *config.AuthConfig, logger *slog.Logger) (*Authentication, error) {
+	if authConfig == nil || !authConfig.Enabled {
+		logger.Warn("The authentication is not enabled, all the requests are anonymous")
+		return nil, nil
+	}
+	if err := authConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	for _, route := range authConfig.PublicRoutes {
+		if _, err := path.Match(route, "/"); err != nil {
+			return nil, fmt.Errorf("the public route %q is not a valid pattern: %w", route, err)
+		}
+	}
+
+	a := &Authentication{publicRoutes: authConfig.PublicRoutes}
+	methods := []Method{}
+	if authConfig.MTLS != nil && authConfig.MTLS.Enabled {
+		a.authenticators = append(a.authenticators, NewMTLSAuthenticator(authConfig.MTLS))
+		methods = append(methods, MethodMTLS)
+	}
+	if authConfig.APIKeys != nil && authConfig.APIKeys.Enabled {
+		authenticator, err := NewAPIKeyAuthenticator(authConfig.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, authenticator)
+		methods = append(methods, MethodAPIKey)
+	}
+	if authConfig.JWT != nil && authConfig.JWT.Enabled {
+		authenticator, err := NewJWTAuthenticator(authConfig.JWT, logger)
+		if err != nil {
+			return nil, err
+		}
+		a.authenticators = append(a.authenticators, authenticator)
+		methods = append(methods, MethodJWT)
+	}
+	logger.Info("Enabled the authentication", "methods", fmt.Sprintf("%v", methods), "public_routes", fmt.Sprintf("%v", a.publicRoutes))
+	return a, nil
+}
+
+// IsPublic returns true when the path matches one of the public routes
+func (a *Authentication) IsPublic(urlPath string) bool {
+	return slices.ContainsFunc(a.publicRoutes, func(route string) bool {
+		matched, _ := path.Match(route, urlPath)
+		return matched
+	})
+}
+
+// Authenticate returns the principal of the request, ErrUnauthenticated is returned when
+// the request has no credentials
+func (a *Authentication) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range a.authenticators {
+		principal, err := authenticator.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if principal != nil {
+			return principal, nil
+		}
+	}
+	return nil, ErrUnauthenticated
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of the context with the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the context, nil for an anonymous request
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,148 @@
+package auth_test
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+func withCertificate(r *http.Request, commonName string) *http.Request {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{certificate}}}
+	return r
+}
+
+func TestAuthentication(t *testing.T) {
+	authentication, err := auth.NewAuthentication(&config.AuthConfig{
+		Enabled:      true,
+		PublicRoutes: []string{"/api/v1/health", "/api/v1/evaluations/providers/*"},
+		APIKeys: &config.APIKeyConfig{
+			Enabled: true,
+			Keys:    "# the CI pipeline\nci:" + hashKey("ci-key") + "\n\nops:" + hashKey("ops-key") + "\n",
+		},
+		MTLS: &config.MTLSConfig{Enabled: true, CAFile: "ca.pem", CertFile: "tls.crt", KeyFile: "tls.key", Subjects: []string{"runner"}},
+	}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewAuthentication() returned error: %v", err)
+	}
+
+	t.Run("public routes", func(t *testing.T) {
+		for path, expected := range map[string]bool{
+			"/api/v1/health":                         true,
+			"/api/v1/evaluations/providers/lm_eval":  true,
+			"/api/v1/evaluations/providers":          false,
+			"/api/v1/evaluations/providers/a/b":      false,
+			"/api/v1/evaluations/jobs":               false,
+			"/api/v1/health/../evaluations/jobs/abc": false,
+		} {
+			if authentication.IsPublic(path) != expected {
+				t.Errorf("Expected IsPublic(%s) to be %v", path, expected)
+			}
+		}
+	})
+
+	t.Run("API keys", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-API-Key", "ops-key")
+		principal, err := authentication.Authenticate(r)
+		if err != nil {
+			t.Fatalf("Authenticate() returned error: %v", err)
+		}
+		if principal.Subject != "ops" || principal.Method != auth.MethodAPIKey {
+			t.Errorf("Unexpected principal %+v", principal)
+		}
+		r.Header.Set("X-API-Key", hashKey("ops-key"))
+		if _, err := authentication.Authenticate(r); err == nil || errors.Is(err, auth.ErrUnauthenticated) {
+			t.Errorf("Expected the hash to not be accepted as a key, got %v", err)
+		}
+	})
+
+	t.Run("client certificates", func(t *testing.T) {
+		principal, err := authentication.Authenticate(withCertificate(httptest.NewRequest(http.MethodGet, "/", nil), "runner"))
+		if err != nil {
+			t.Fatalf("Authenticate() returned error: %v", err)
+		}
+		if principal.Subject != "runner" || principal.Method != auth.MethodMTLS {
+			t.Errorf("Unexpected principal %+v", principal)
+		}
+		if _, err := authentication.Authenticate(withCertificate(httptest.NewRequest(http.MethodGet, "/", nil), "intruder")); err == nil {
+			t.Errorf("Expected an error for a subject that is not allowed")
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		if _, err := authentication.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, auth.ErrUnauthenticated) {
+			t.Errorf("Expected ErrUnauthenticated, got %v", err)
+		}
+	})
+}
+
+func TestNewAuthenticationErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		authConfig *config.AuthConfig
+	}{
+		{"no method", &config.AuthConfig{Enabled: true}},
+		{"no JWKS", &config.AuthConfig{Enabled: true, JWT: &config.JWTConfig{Enabled: true}}},
+		{"file and URL", &config.AuthConfig{Enabled: true, JWT: &config.JWTConfig{Enabled: true, JWKSFile: "jwks.json", JWKSURL: "http://localhost/jwks"}}},
+		{"no API keys", &config.AuthConfig{Enabled: true, APIKeys: &config.APIKeyConfig{Enabled: true}}},
+		{"key that is not hashed", &config.AuthConfig{Enabled: true, APIKeys: &config.APIKeyConfig{Enabled: true, Keys: "ci:secret"}}},
+		{"no client CA", &config.AuthConfig{Enabled: true, MTLS: &config.MTLSConfig{Enabled: true}}},
+		{"invalid public route", &config.AuthConfig{Enabled: true, PublicRoutes: []string{"/api/["}, APIKeys: &config.APIKeyConfig{Enabled: true, Keys: "ci:" + hashKey("ci")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.NewAuthentication(tt.authConfig, logging.FallbackLogger()); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+
+	authentication, err := auth.NewAuthentication(&config.AuthConfig{Enabled: false}, logging.FallbackLogger())
+	if err != nil || authentication != nil {
+		t.Errorf("Expected no authentication when it is not enabled, got %v %v", authentication, err)
+	}
+}
+
+func TestJobToken(t *testing.T) {
+	token := auth.NewJobToken("secret", "job-1", "tenant-a")
+	if !auth.VerifyJobToken("secret", token, "job-1", "tenant-a") {
+		t.Errorf("Expected the token of the job to be valid")
+	}
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		jobID  string
+		tenant string
+	}{
+		{"another job", "secret", token, "job-2", "tenant-a"},
+		{"another tenant", "secret", token, "job-1", "tenant-b"},
+		{"another secret", "other", token, "job-1", "tenant-a"},
+		{"no secret", "", auth.NewJobToken("", "job-1", "tenant-a"), "job-1", "tenant-a"},
+		{"no token", "secret", "", "job-1", "tenant-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if auth.VerifyJobToken(tt.secret, tt.token, tt.jobID, api.Tenant(tt.tenant)) {
+				t.Errorf("Expected the token to be rejected")
+			}
+		})
+	}
+}
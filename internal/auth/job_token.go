@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// NewJobToken returns the results token of an evaluation job, the token is derived from the
+// results secret of the service, the id and the tenant of the job so that it can only be used
+// to report the results and artifacts of this job. The token is not stored, it is derived
+// again to check the requests.
+func NewJobToken(secret string, jobID string, tenant api.Tenant) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	// the id can not contain a new line so the id and the tenant can not be mixed up
+	mac.Write([]byte(jobID + "\n" + string(tenant)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyJobToken returns true when the token is the results token of the job
+func VerifyJobToken(secret string, token string, jobID string, tenant api.Tenant) bool {
+	if secret == "" || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(NewJobToken(secret, jobID, tenant)))
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"golang.org/x/sync/singleflight"
+)
+
+// maxJWKSSize limits the size of the JWKS read from the URL
+const maxJWKSSize = 1 << 20
+
+// jwksRefreshKey is the key of the reads of the JWKS in the single flight group
+const jwksRefreshKey = "jwks"
+
+// jsonWebKey is the subset of the members of a JSON Web Key (RFC 7517) used for the RSA and
+// EC signature keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet holds the keys of the JWKS, the keys are read again when they are older than the
+// refresh interval or when a token is signed with an unknown key, but not more often than
+// every config.DefaultAuthJWKSFetchInterval. The JWKS is read by one request at a time and
+// without holding the lock so that the other requests are not blocked by a slow JWKS URL.
+type keySet struct {
+	file            string
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	logger          *slog.Logger
+
+	group     singleflight.Group
+	mu        sync.Mutex
+	keys      map[string]publicKey
+	fetchedAt time.Time
+}
+
+func newKeySet(jwtConfig *config.JWTConfig, logger *slog.Logger) *keySet {
+	return &keySet{
+		file:            jwtConfig.JWKSFile,
+		url:             jwtConfig.JWKSURL,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: jwtConfig.RefreshInterval,
+		logger:          logger,
+	}
+}
+
+// find returns the key with the id, the only key of the set is returned when the token
+// does not have a key id. A known key is returned while the keys that are older than the
+// refresh interval are read again in the background, a request with an unknown key waits for
+// the keys to be read.
+func (s *keySet) find(kid string) (publicKey, error) {
+	key, found, sinceFetch := s.get(kid)
+	switch {
+	case found && sinceFetch > s.refreshInterval:
+		s.group.DoChan(jwksRefreshKey, s.refreshOlderThan(s.refreshInterval))
+	case !found && sinceFetch > config.DefaultAuthJWKSFetchInterval:
+		s.group.Do(jwksRefreshKey, s.refreshOlderThan(config.DefaultAuthJWKSFetchInterval))
+		key, found, _ = s.get(kid)
+	}
+	if !found {
+		return publicKey{}, fmt.Errorf("the token is signed with the unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// get returns the key with the id and the time since the keys were read
+func (s *keySet) get(kid string) (publicKey, bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, found := s.lookup(kid)
+	return key, found, time.Since(s.fetchedAt)
+}
+
+// refreshOlderThan returns the function of the single flight group that reads the keys when
+// they have not been read again since the request found them older than the age
+func (s *keySet) refreshOlderThan(age time.Duration) func() (any, error) {
+	return func() (any, error) {
+		s.mu.Lock()
+		sinceFetch := time.Since(s.fetchedAt)
+		s.mu.Unlock()
+		if sinceFetch <= age {
+			return nil, nil
+		}
+		if err := s.refresh(); err != nil {
+			// the keys already read are used until the JWKS can be read again
+			s.logger.Warn("Failed to read the JWKS", "error", err.Error())
+		}
+		return nil, nil
+	}
+}
+
+// lookup must be called with the lock held
+func (s *keySet) lookup(kid string) (publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, found := s.keys[kid]
+	return key, found
+}
+
+// refresh reads the keys of the JWKS, it must be called without the lock held
+func (s *keySet) refresh() error {
+	// the time is recorded first so that a JWKS that can not be read is not read on each request
+	s.mu.Lock()
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	jsonBytes, err := s.read()
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(jsonBytes)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	s.logger.Info("Read the JWKS", "keys", len(keys))
+	return nil
+}
+
+func (s *keySet) read() ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the JWKS request returned the status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// parseJWKS returns the signature keys of the JWKS by key id, the keys of other types or
+// uses are ignored
+func parseJWKS(jsonBytes []byte) (map[string]publicKey, error) {
+	jwks := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(jsonBytes, &jwks); err != nil {
+		return nil, fmt.Errorf("the JWKS is not valid: %w", err)
+	}
+	keys := map[string]publicKey{}
+	for i, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = rsaKey(jwk)
+		case "EC":
+			key, err = ecKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the key %d (%q) of the JWKS is not valid: %w", i, jwk.Kid, err)
+		}
+		keys[jwk.Kid] = publicKey{alg: jwk.Alg, key: key}
+	}
+	return keys, nil
+}
+
+func rsaKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(n) == 0 {
+		return nil, fmt.Errorf("the modulus is not valid")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, fmt.Errorf("the exponent is not valid")
+	}
+	exponent := 0
+	for _, b := range e {
+		exponent = exponent<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
+
+func ecKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var checkCurve ecdh.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve, checkCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, checkCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, checkCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("the curve %q is not supported", jwk.Crv)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+	y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("the coordinates are not valid")
+	}
+	// the point is checked by parsing its uncompressed form
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := checkCurve.NewPublicKey(point); err != nil {
+		return nil, fmt.Errorf("the point is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// algorithm is a JWS signature algorithm (RFC 7518), the symmetric algorithms and none are
+// not supported
+type algorithm struct {
+	hash      crypto.Hash
+	kty       string
+	pss       bool
+	curveBits int
+}
+
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256, kty: "RSA"},
+	"RS384": {hash: crypto.SHA384, kty: "RSA"},
+	"RS512": {hash: crypto.SHA512, kty: "RSA"},
+	"PS256": {hash: crypto.SHA256, kty: "RSA", pss: true},
+	"PS384": {hash: crypto.SHA384, kty: "RSA", pss: true},
+	"PS512": {hash: crypto.SHA512, kty: "RSA", pss: true},
+	"ES256": {hash: crypto.SHA256, kty: "EC", curveBits: 256},
+	"ES384": {hash: crypto.SHA384, kty: "EC", curveBits: 384},
+	"ES512": {hash: crypto.SHA512, kty: "EC", curveBits: 521},
+}
+
+// JWTAuthenticator authenticates the requests with the bearer JWT, the token must be signed
+// by one of the keys of the JWKS and must not be expired
+type JWTAuthenticator struct {
+	config *config.JWTConfig
+	keys   *keySet
+	now    func() time.Time
+}
+
+// NewJWTAuthenticator creates the authenticator and reads the JWKS, a JWKS file that can not
+// be read is an error while a JWKS URL is read again on the next request
+func NewJWTAuthenticator(jwtConfig *config.JWTConfig, logger *slog.Logger) (*JWTAuthenticator, error) {
+	keys := newKeySet(jwtConfig, logger.With("jwks", jwtConfig.JWKSFile+jwtConfig.JWKSURL))
+	if err := keys.refresh(); err != nil {
+		if keys.file != "" {
+			return nil, err
+		}
+		keys.logger.Warn("Failed to read the JWKS", "error", err.Error())
+	}
+	return &JWTAuthenticator{config: jwtConfig, keys: keys, now: time.Now}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return nil, nil
+	}
+	claims, err := a.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims[a.config.SubjectClaim].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("the token does not have the %s claim", a.config.SubjectClaim)
+	}
+	return &Principal{Subject: subject, Method: MethodJWT, Claims: claims}, nil
+}
+
+// verify checks the signature and the registered claims of the token and returns its claims
+func (a *JWTAuthenticator) verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("the bearer token is not a JWT")
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("the header of the token is not valid: %w", err)
+	}
+	alg, supported := algorithms[header.Alg]
+	if !supported {
+		return nil, fmt.Errorf("the token algorithm %q is not supported", header.Alg)
+	}
+	key, err := a.keys.find(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("the key %q can not be used with the algorithm %s", header.Kid, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("the signature of the token is not valid: %w", err)
+	}
+	if err := verifySignature(alg, key.key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]any{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("the claims of the token are not valid: %w", err)
+	}
+	if err := a.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func verifySignature(alg algorithm, key crypto.PublicKey, signingInput string, signature []byte) error {
+	hasher := alg.hash.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg.kty != "RSA" {
+			break
+		}
+		var err error
+		if alg.pss {
+			err = rsa.VerifyPSS(key, alg.hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			err = rsa.VerifyPKCS1v15(key, alg.hash, digest, signature)
+		}
+		if err != nil {
+			return fmt.Errorf("the signature of the token is not valid")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg.kty != "EC" || key.Curve.Params().BitSize != alg.curveBits {
+			break
+		}
+		// the signature is the concatenation of r and s, each the size of the curve
+		size := (alg.curveBits + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("the signature of the token is not valid")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("the signature of the token is not valid")
+		}
+		return nil
+	}
+	return fmt.Errorf("the key of the token does not match its algorithm")
+}
+
+// checkClaims checks the expiry, the start of validity, the issuer and the audience
+func (a *JWTAuthenticator) checkClaims(claims map[string]any) error {
+	now := a.now()
+	skew := a.config.ClockSkew
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("the token does not have an expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(skew)) {
+		return fmt.Errorf("the token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-skew)) {
+		return fmt.Errorf("the token is not valid yet")
+	}
+	if a.config.Issuer != "" && claims["iss"] != a.config.Issuer {
+		return fmt.Errorf("the token issuer is not valid")
+	}
+	if a.config.Audience != "" {
+		audience := []string{}
+		switch aud := claims["aud"].(type) {
+		case string:
+			audience = append(audience, aud)
+		case []any:
+			for _, value := range aud {
+				if value, ok := value.(string); ok {
+					audience = append(audience, value)
+				}
+			}
+		}
+		if !slices.Contains(audience, a.config.Audience) {
+			return fmt.Errorf("the token audience is not valid")
+		}
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	jsonBytes, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonBytes, v)
+}
@@ -0,0 +1,269 @@
+package auth_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+)
+
+type signer struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func (s signer) jwk() map[string]any {
+	switch key := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]any{
+			"kty": "RSA", "kid": s.kid, "alg": s.alg, "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return map[string]any{
+			"kty": "EC", "kid": s.kid, "crv": key.Curve.Params().Name,
+			"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}
+	}
+	return nil
+}
+
+// sign returns a JWT with the claims signed with RS256 or ES256
+func (s signer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]any{"alg": s.alg, "kid": s.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch key := s.key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("Failed to sign the token: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign the token: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newSigners(t *testing.T) (signer, signer) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate the RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate the EC key: %v", err)
+	}
+	return signer{kid: "rsa", alg: "RS256", key: rsaKey}, signer{kid: "ec", alg: "ES256", key: ecKey}
+}
+
+func jwks(signers ...signer) []byte {
+	keys := []any{map[string]any{"kty": "oct", "kid": "ignored", "k": "c2VjcmV0"}}
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	jsonBytes, _ := json.Marshal(map[string]any{"keys": keys})
+	return jsonBytes
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/evaluations/jobs", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	rsaSigner, ecSigner := newSigners(t)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks(rsaSigner, ecSigner), 0o600); err != nil {
+		t.Fatalf("Failed to write the JWKS: %v", err)
+	}
+	jwtConfig := &config.AuthConfig{Enabled: true, JWT: &config.JWTConfig{
+		Enabled: true, JWKSFile: jwksFile, Issuer: "https://issuer", Audience: "eval-hub",
+	}}
+	if err := jwtConfig.CheckConfig(); err != nil {
+		t.Fatalf("CheckConfig() returned error: %v", err)
+	}
+	authenticator, err := auth.NewJWTAuthenticator(jwtConfig.JWT, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator() returned error: %v", err)
+	}
+
+	now := time.Now().Unix()
+	valid := map[string]any{"sub": "alice", "iss": "https://issuer", "aud": []any{"other", "eval-hub"}, "exp": now + 60, "tenant": "team-a"}
+	with := func(changes map[string]any) map[string]any {
+		claims := map[string]any{}
+		for name, value := range valid {
+			claims[name] = value
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	for _, s := range []signer{rsaSigner, ecSigner} {
+		principal, err := authenticator.Authenticate(bearer(s.sign(t, valid)))
+		if err != nil {
+			t.Fatalf("Expected the %s token to be valid, got %v", s.alg, err)
+		}
+		if principal.Subject != "alice" || principal.Method != auth.MethodJWT || principal.Claims["tenant"] != "team-a" {
+			t.Errorf("Unexpected principal %+v", principal)
+		}
+	}
+
+	if principal, err := authenticator.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); principal != nil || err != nil {
+		t.Errorf("Expected no principal and no error without a token, got %v %v", principal, err)
+	}
+
+	otherSigner, _ := newSigners(t)
+	unsigned := strings.Join(strings.Split(rsaSigner.sign(t, valid), ".")[:2], ".") + "."
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", rsaSigner.sign(t, with(map[string]any{"exp": now - 3600}))},
+		{"no expiry", rsaSigner.sign(t, with(map[string]any{"exp": nil}))},
+		{"not valid yet", rsaSigner.sign(t, with(map[string]any{"nbf": now + 3600}))},
+		{"wrong issuer", rsaSigner.sign(t, with(map[string]any{"iss": "https://other"}))},
+		{"wrong audience", rsaSigner.sign(t, with(map[string]any{"aud": "other"}))},
+		{"no subject", rsaSigner.sign(t, with(map[string]any{"sub": nil}))},
+		{"signed by another key", otherSigner.sign(t, valid)},
+		{"unknown key", signer{kid: "unknown", alg: "RS256", key: otherSigner.key}.sign(t, valid)},
+		{"key of another algorithm", signer{kid: "rsa", alg: "ES256", key: ecSigner.key}.sign(t, valid)},
+		{"no signature", unsigned},
+		{"not a JWT", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if principal, err := authenticator.Authenticate(bearer(tt.token)); err == nil {
+				t.Errorf("Expected an error, got the principal %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWKSURL(t *testing.T) {
+	first, second := newSigners(t)
+	served := atomic.Value{}
+	served.Store(jwks(first))
+	requests := atomic.Int32{}
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(served.Load().([]byte))
+	}))
+	defer jwksServer.Close()
+
+	authenticator, err := auth.NewJWTAuthenticator(&config.JWTConfig{
+		Enabled: true, JWKSURL: jwksServer.URL, SubjectClaim: "sub", RefreshInterval: time.Hour,
+	}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator() returned error: %v", err)
+	}
+	claims := map[string]any{"sub": "bob", "exp": time.Now().Unix() + 60}
+	if _, err := authenticator.Authenticate(bearer(first.sign(t, claims))); err != nil {
+		t.Errorf("Expected the token to be valid, got %v", err)
+	}
+
+	// a key added to the JWKS is not read again before the minimum fetch interval
+	served.Store(jwks(first, second))
+	if _, err := authenticator.Authenticate(bearer(second.sign(t, claims))); err == nil {
+		t.Errorf("Expected the new key to not be read yet")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected the JWKS to be read once, got %d", requests.Load())
+	}
+}
+
+func TestJWKSRefreshDoesNotBlock(t *testing.T) {
+	first, _ := newSigners(t)
+	requests := atomic.Int32{}
+	release := make(chan struct{})
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the JWKS is slow to read after the first request
+		if requests.Add(1) > 1 {
+			<-release
+		}
+		w.Write(jwks(first))
+	}))
+	defer jwksServer.Close()
+	defer close(release)
+
+	authenticator, err := auth.NewJWTAuthenticator(&config.JWTConfig{
+		Enabled: true, JWKSURL: jwksServer.URL, SubjectClaim: "sub", RefreshInterval: time.Millisecond,
+	}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator() returned error: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// the known key is used while the keys are read again in the background
+	claims := map[string]any{"sub": "bob", "exp": time.Now().Unix() + 60}
+	done := make(chan error, 1)
+	go func() {
+		for range 3 {
+			if _, err := authenticator.Authenticate(bearer(first.sign(t, claims))); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected the token to be valid, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the requests to not wait for the JWKS")
+	}
+	// the reads of the JWKS in the background are shared while the first one is running
+	for deadline := time.Now().Add(time.Second); requests.Load() < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected the JWKS to be read again once, got %d", requests.Load()-1)
+	}
+}
+
+func TestJWKSFileIsRequired(t *testing.T) {
+	_, err := auth.NewJWTAuthenticator(&config.JWTConfig{
+		Enabled: true, JWKSFile: filepath.Join(t.TempDir(), "missing.json"), SubjectClaim: "sub", RefreshInterval: time.Hour,
+	}, logging.FallbackLogger())
+	if err == nil {
+		t.Errorf("Expected an error for a missing JWKS file")
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+// MTLSAuthenticator authenticates the requests with the client certificate verified by the
+// TLS handshake
+type MTLSAuthenticator struct {
+	subjects []string
+}
+
+func NewMTLSAuthenticator(mtlsConfig *config.MTLSConfig) *MTLSAuthenticator {
+	return &MTLSAuthenticator{subjects: mtlsConfig.Subjects}
+}
+
+func (a *MTLSAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	certificate := r.TLS.VerifiedChains[0][0]
+	subject := certificate.Subject.CommonName
+	if subject == "" {
+		subject = certificate.Subject.String()
+	}
+	if len(a.subjects) > 0 && !slices.Contains(a.subjects, subject) {
+		return nil, fmt.Errorf("the client certificate subject %q is not allowed", subject)
+	}
+	return &Principal{Subject: subject, Method: MethodMTLS}, nil
+}
+
+// ServerTLSConfig returns the TLS configuration of the server that verifies the client
+// certificates with the CA. The certificates are optional during the handshake so that the
+// public routes and the other methods can be used without a certificate.
+func ServerTLSConfig(mtlsConfig *config.MTLSConfig) (*tls.Config, error) {
+	caBytes, err := os.ReadFile(mtlsConfig.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caBytes) {
+		return nil, fmt.Errorf("the client CA %s has no PEM certificate", mtlsConfig.CAFile)
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  clientCAs,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+	}, nil
+}
@@ -0,0 +1,180 @@
+package authz
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"sigs.k8s.io/yaml"
+)
+
+type Permission string
+
+const (
+	CatalogRead       Permission = "catalog:read"
+	EvaluationsRead   Permission = "evaluations:read"
+	EvaluationsCreate Permission = "evaluations:create"
+	EvaluationsCancel Permission = "evaluations:cancel"
+	CollectionsRead   Permission = "collections:read"
+	CollectionsCreate Permission = "collections:create"
+	CollectionsUpdate Permission = "collections:update"
+	CollectionsDelete Permission = "collections:delete"
+	SystemRead        Permission = "system:read"
+
+	// allPermissions grants all the permissions
+	allPermissions Permission = "*"
+	// anyOwner is the suffix of the owned permissions that also apply to the resources
+	// owned by other principals
+	anyOwner = ":any"
+)
+
+// ownedPermissions are the permissions that only apply to the resources owned by the principal
+var ownedPermissions = []Permission{EvaluationsCancel, CollectionsUpdate, CollectionsDelete}
+
+var permissions = []Permission{
+	CatalogRead, EvaluationsRead, EvaluationsCreate, EvaluationsCancel, CollectionsRead,
+	CollectionsCreate, CollectionsUpdate, CollectionsDelete, SystemRead,
+}
+
+//go:embed policy.yaml
+var defaultPolicy []byte
+
+// Policy maps the principals onto roles and the roles onto permissions
+type Policy struct {
+	RolesClaim   string                  `json:"roles_claim,omitempty"`
+	GroupsClaim  string                  `json:"groups_claim,omitempty"`
+	DefaultRoles []string                `json:"default_roles,omitempty"`
+	Groups       map[string][]string     `json:"groups,omitempty"`
+	Subjects     map[string][]string     `json:"subjects,omitempty"`
+	Roles        map[string][]Permission `json:"roles"`
+}
+
+// Authorizer checks the permissions of the principals with the policy
+type Authorizer struct {
+	policy *Policy
+}
+
+// NewAuthorizer loads the policy, it returns nil when the authorization is not enabled
+func NewAuthorizer(authorizationConfig *config.AuthorizationConfig, logger *slog.Logger) (*Authorizer, error) {
+	if authorizationConfig == nil || !authorizationConfig.Enabled {
+		return nil, nil
+	}
+	data := defaultPolicy
+	if authorizationConfig.PolicyFile != "" {
+		var err error
+		if data, err = os.ReadFile(authorizationConfig.PolicyFile); err != nil {
+			return nil, fmt.Errorf("failed to read the authorization policy: %w", err)
+		}
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Loaded the authorization policy", "file", authorizationConfig.PolicyFile, "roles", len(policy.Roles))
+	return &Authorizer{policy: policy}, nil
+}
+
+// ParsePolicy parses and checks a policy, the roles mapped to the principals must be
+// defined and the permissions must be known
+func ParsePolicy(data []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("the authorization policy is not valid: %w", err)
+	}
+	for role, rolePermissions := range policy.Roles {
+		for _, permission := range rolePermissions {
+			if !isPermission(permission) {
+				return nil, fmt.Errorf("the role %s has the unknown permission %s", role, permission)
+			}
+		}
+	}
+	mappings := map[string][]string{"default_roles": policy.DefaultRoles}
+	for group, roles := range policy.Groups {
+		mappings["group "+group] = roles
+	}
+	for subject, roles := range policy.Subjects {
+		mappings["subject "+subject] = roles
+	}
+	for name, roles := range mappings {
+		for _, role := range roles {
+			if _, found := policy.Roles[role]; !found {
+				return nil, fmt.Errorf("the role %s of %s is not defined", role, name)
+			}
+		}
+	}
+	return policy, nil
+}
+
+func isPermission(permission Permission) bool {
+	if permission == allPermissions || slices.Contains(permissions, permission) {
+		return true
+	}
+	owned, found := strings.CutSuffix(string(permission), anyOwner)
+	return found && slices.Contains(ownedPermissions, Permission(owned))
+}
+
+// Roles returns the defined roles of the principal
+func (a *Authorizer) Roles(principal *auth.Principal) []string {
+	policy := a.policy
+	roles := slices.Clone(policy.DefaultRoles)
+	roles = append(roles, policy.Subjects[principal.Subject]...)
+	if policy.RolesClaim != "" {
+		roles = append(roles, claimValues(principal.Claims[policy.RolesClaim])...)
+	}
+	if policy.GroupsClaim != "" {
+		for _, group := range claimValues(principal.Claims[policy.GroupsClaim]) {
+			roles = append(roles, policy.Groups[group]...)
+		}
+	}
+	// the roles of the claims that are not defined by the policy are ignored
+	roles = slices.DeleteFunc(roles, func(role string) bool {
+		_, found := policy.Roles[role]
+		return !found
+	})
+	slices.Sort(roles)
+	return slices.Compact(roles)
+}
+
+// Allowed returns true when one of the roles of the principal has the permission. The owner
+// is the subject of the principal that owns the resource, it is only used for the owned
+// permissions: the resources of other principals, or without an owner, require the
+// permission with the :any suffix.
+func (a *Authorizer) Allowed(principal *auth.Principal, permission Permission, owner string) bool {
+	required := []Permission{allPermissions, permission}
+	if slices.Contains(ownedPermissions, permission) {
+		required = []Permission{allPermissions, permission + anyOwner}
+		if owner != "" && owner == principal.Subject {
+			required = append(required, permission)
+		}
+	}
+	for _, role := range a.Roles(principal) {
+		for _, granted := range a.policy.Roles[role] {
+			if slices.Contains(required, granted) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimValues returns the strings of a claim, a claim can be a string or an array of strings
+func claimValues(claim any) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		values := []string{}
+		for _, item := range value {
+			if item, ok := item.(string); ok {
+				values = append(values, item)
+			}
+		}
+		return values
+	}
+	return nil
+}
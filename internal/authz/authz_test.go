@@ -0,0 +1,113 @@
+package authz_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	authorizer, err := authz.NewAuthorizer(&config.AuthorizationConfig{Enabled: true}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewAuthorizer() returned error: %v", err)
+	}
+
+	viewer := &auth.Principal{Subject: "alice", Method: auth.MethodJWT}
+	submitter := &auth.Principal{Subject: "bob", Method: auth.MethodJWT, Claims: map[string]any{"roles": []any{"submitter", "unknown"}}}
+	collectionAdmin := &auth.Principal{Subject: "carol", Method: auth.MethodJWT, Claims: map[string]any{"roles": "collection-admin"}}
+	systemAdmin := &auth.Principal{Subject: "dave", Method: auth.MethodJWT, Claims: map[string]any{"roles": []any{"system-admin"}}}
+
+	if roles := authorizer.Roles(submitter); !reflect.DeepEqual(roles, []string{"submitter", "viewer"}) {
+		t.Errorf("Expected the roles of the claim and the default roles, got %v", roles)
+	}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		permission authz.Permission
+		owner      string
+		allowed    bool
+	}{
+		{"viewer reads", viewer, authz.EvaluationsRead, "", true},
+		{"viewer submits", viewer, authz.EvaluationsCreate, "", false},
+		{"viewer reads the system metrics", viewer, authz.SystemRead, "", false},
+		{"submitter submits", submitter, authz.EvaluationsCreate, "", true},
+		{"submitter cancels its job", submitter, authz.EvaluationsCancel, "bob", true},
+		{"submitter cancels another job", submitter, authz.EvaluationsCancel, "alice", false},
+		{"submitter cancels a job without owner", submitter, authz.EvaluationsCancel, "", false},
+		{"submitter deletes its collection", submitter, authz.CollectionsDelete, "bob", true},
+		{"submitter deletes a shared collection", submitter, authz.CollectionsDelete, "carol", false},
+		{"collection admin deletes a shared collection", collectionAdmin, authz.CollectionsDelete, "bob", true},
+		{"collection admin cancels a job", collectionAdmin, authz.EvaluationsCancel, "carol", false},
+		{"system admin cancels another job", systemAdmin, authz.EvaluationsCancel, "bob", true},
+		{"system admin reads the system metrics", systemAdmin, authz.SystemRead, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := authorizer.Allowed(tt.principal, tt.permission, tt.owner); allowed != tt.allowed {
+				t.Errorf("Expected Allowed(%s, %s) to be %v", tt.principal.Subject, tt.permission, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestPolicyFile(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	policy := `
+groups_claim: groups
+groups:
+  ml-platform: [operator]
+subjects:
+  ci: [operator]
+roles:
+  operator: [evaluations:create, "evaluations:cancel:any"]
+`
+	if err := os.WriteFile(policyFile, []byte(policy), 0o600); err != nil {
+		t.Fatalf("Failed to write the policy: %v", err)
+	}
+	authorizer, err := authz.NewAuthorizer(&config.AuthorizationConfig{Enabled: true, PolicyFile: policyFile}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewAuthorizer() returned error: %v", err)
+	}
+
+	ci := &auth.Principal{Subject: "ci", Method: auth.MethodAPIKey}
+	member := &auth.Principal{Subject: "erin", Method: auth.MethodJWT, Claims: map[string]any{"groups": []any{"ml-platform"}}}
+	other := &auth.Principal{Subject: "frank", Method: auth.MethodJWT, Claims: map[string]any{"groups": []any{"other"}}}
+	if !authorizer.Allowed(ci, authz.EvaluationsCancel, "someone") || !authorizer.Allowed(member, authz.EvaluationsCancel, "someone") {
+		t.Errorf("Expected the roles of the subject and of the group to be granted")
+	}
+	if authorizer.Allowed(other, authz.EvaluationsCreate, "") || authorizer.Allowed(ci, authz.EvaluationsRead, "") {
+		t.Errorf("Expected the permissions that are not granted to be denied")
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{"unknown permission", `roles: {viewer: [evaluations:delete]}`},
+		{"any on a permission that is not owned", `roles: {viewer: ["evaluations:read:any"]}`},
+		{"undefined default role", `{default_roles: [viewer], roles: {}}`},
+		{"undefined group role", `{groups: {team: [admin]}, roles: {viewer: [catalog:read]}}`},
+		{"unknown field", `{role: {}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := authz.ParsePolicy([]byte(tt.policy)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+
+	authorizer, err := authz.NewAuthorizer(&config.AuthorizationConfig{Enabled: false}, logging.FallbackLogger())
+	if err != nil || authorizer != nil {
+		t.Errorf("Expected no authorizer when it is not enabled, got %v %v", authorizer, err)
+	}
+}
@@ -0,0 +1,153 @@
+package catalog
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// the catalog used when no catalog directory is configured
+//
+//go:embed providers/*.yaml
+var builtinProviders embed.FS
+
+// Catalog holds the providers and the benchmarks loaded from the catalog files, the
+// catalog can be read while it is reloaded
+type Catalog struct {
+	logger  *slog.Logger
+	dir     string
+	mu      sync.RWMutex
+	content *content
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// BenchmarkFilter selects the benchmarks returned by GetBenchmarks, the empty fields
+// match all the benchmarks and a benchmark must have all the tags
+type BenchmarkFilter struct {
+	ProviderID string
+	Category   string
+	Tags       []string
+}
+
+// NewCatalog loads the catalog and starts watching the catalog directory when configured,
+// the catalog fails to load if any file is invalid
+func NewCatalog(catalogConfig *config.CatalogConfig, logger *slog.Logger) (*Catalog, error) {
+	if catalogConfig == nil {
+		catalogConfig = &config.CatalogConfig{}
+	}
+	c := &Catalog{
+		logger: logger.With("catalog", catalogConfig.Dir),
+		dir:    catalogConfig.Dir,
+	}
+
+	var fsys fs.FS
+	if c.dir == "" {
+		fsys, _ = fs.Sub(builtinProviders, "providers")
+	} else {
+		fsys = os.DirFS(c.dir)
+	}
+	content, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	c.content = content
+	c.logger.Info("Loaded the catalog", "providers", len(content.providers), "benchmarks", len(content.benchmarks))
+
+	if c.dir != "" && catalogConfig.Watch {
+		if err := c.watch(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// GetProviders returns all the providers
+func (c *Catalog) GetProviders() *api.ProviderResourceList {
+	content := c.get()
+	return &api.ProviderResourceList{
+		TotalCount: len(content.providers),
+		Items:      slices.Clone(content.providers),
+	}
+}
+
+// GetProvider returns the provider or an error wrapping abstractions.ErrNotFound
+func (c *Catalog) GetProvider(id string) (*api.ProviderResource, error) {
+	for _, provider := range c.get().providers {
+		if provider.ID == id {
+			return &provider, nil
+		}
+	}
+	return nil, fmt.Errorf("provider %s %w", id, abstractions.ErrNotFound)
+}
+
+// GetBenchmark returns the benchmark of the provider or an error wrapping abstractions.ErrNotFound
+func (c *Catalog) GetBenchmark(providerID string, id string) (*api.BenchmarkResource, error) {
+	benchmark := c.get().find(providerID, id)
+	if benchmark == nil {
+		return nil, fmt.Errorf("benchmark %s of provider %s %w", id, providerID, abstractions.ErrNotFound)
+	}
+	result := *benchmark
+	return &result, nil
+}
+
+// GetBenchmarks returns the benchmarks selected by the filter in the order of the catalog
+func (c *Catalog) GetBenchmarks(filter BenchmarkFilter) *api.BenchmarkResourceList {
+	items := []api.BenchmarkResource{}
+	for _, benchmark := range c.get().benchmarks {
+		if filter.matches(benchmark) {
+			items = append(items, benchmark)
+		}
+	}
+	return &api.BenchmarkResourceList{
+		TotalCount: len(items),
+		Items:      items,
+	}
+}
+
+// Close stops watching the catalog directory
+func (c *Catalog) Close() error {
+	if c.watcher == nil {
+		return nil
+	}
+	err := c.watcher.Close()
+	<-c.done
+	return err
+}
+
+func (c *Catalog) get() *content {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.content
+}
+
+func (c *Catalog) set(content *content) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.content = content
+}
+
+func (f BenchmarkFilter) matches(benchmark api.BenchmarkResource) bool {
+	if f.ProviderID != "" && f.ProviderID != benchmark.ProviderID {
+		return false
+	}
+	if f.Category != "" && !strings.EqualFold(f.Category, benchmark.Category) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(benchmark.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package catalog_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+)
+
+const testProvider = `
+id: test_provider
+label: Test Provider
+benchmarks:
+  - id: bench_a
+    label: Bench A
+    category: reasoning
+    tags: [english, multiple_choice]
+  - id: bench_b
+    label: Bench B
+    category: math
+    tags: [english]
+`
+
+const otherProvider = `
+id: other_provider
+label: Other Provider
+benchmarks:
+  - id: bench_c
+    label: Bench C
+    category: Reasoning
+    tags: [german]
+`
+
+func writeFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+func newCatalog(t *testing.T, dir string, watch bool) *catalog.Catalog {
+	t.Helper()
+	c, err := catalog.NewCatalog(&config.CatalogConfig{Dir: dir, Watch: watch}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewCatalog() returned error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestBuiltinCatalog(t *testing.T) {
+	c := newCatalog(t, "", false)
+
+	provider, err := c.GetProvider("lm_evaluation_harness")
+	if err != nil {
+		t.Fatalf("GetProvider() returned error: %v", err)
+	}
+	benchmarks := c.GetBenchmarks(catalog.BenchmarkFilter{ProviderID: provider.ID})
+	if benchmarks.TotalCount == 0 || benchmarks.TotalCount != len(provider.SupportedBenchmarks) {
+		t.Errorf("Expected %d benchmarks, got %d", len(provider.SupportedBenchmarks), benchmarks.TotalCount)
+	}
+}
+
+func TestGetProviders(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "test.yaml", testProvider)
+	writeFile(t, dir, "other.yml", otherProvider)
+	writeFile(t, dir, "README.md", "not a catalog file")
+	c := newCatalog(t, dir, false)
+
+	providers := c.GetProviders()
+	if providers.TotalCount != 2 {
+		t.Fatalf("Expected 2 providers, got %d", providers.TotalCount)
+	}
+	// the files are loaded in name order
+	if providers.Items[0].ID != "other_provider" || providers.Items[1].ID != "test_provider" {
+		t.Errorf("Expected the providers in file name order, got %s and %s", providers.Items[0].ID, providers.Items[1].ID)
+	}
+
+	provider, err := c.GetProvider("test_provider")
+	if err != nil {
+		t.Fatalf("GetProvider() returned error: %v", err)
+	}
+	if len(provider.SupportedBenchmarks) != 2 || provider.SupportedBenchmarks[0].ID != "bench_a" {
+		t.Errorf("Expected the supported benchmarks bench_a and bench_b, got %v", provider.SupportedBenchmarks)
+	}
+
+	if _, err := c.GetProvider("unknown"); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown provider, got %v", err)
+	}
+}
+
+func TestGetBenchmarks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "test.yaml", testProvider)
+	writeFile(t, dir, "other.yaml", otherProvider)
+	c := newCatalog(t, dir, false)
+
+	tests := []struct {
+		name     string
+		filter   catalog.BenchmarkFilter
+		expected []string
+	}{
+		{"no filter", catalog.BenchmarkFilter{}, []string{"bench_c", "bench_a", "bench_b"}},
+		{"provider", catalog.BenchmarkFilter{ProviderID: "test_provider"}, []string{"bench_a", "bench_b"}},
+		{"category ignores the case", catalog.BenchmarkFilter{Category: "reasoning"}, []string{"bench_c", "bench_a"}},
+		{"all the tags", catalog.BenchmarkFilter{Tags: []string{"english", "multiple_choice"}}, []string{"bench_a"}},
+		{"combined", catalog.BenchmarkFilter{ProviderID: "test_provider", Category: "math", Tags: []string{"english"}}, []string{"bench_b"}},
+		{"no match", catalog.BenchmarkFilter{ProviderID: "unknown"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			benchmarks := c.GetBenchmarks(tt.filter)
+			if benchmarks.TotalCount != len(tt.expected) {
+				t.Fatalf("Expected %d benchmarks, got %d", len(tt.expected), benchmarks.TotalCount)
+			}
+			for i, id := range tt.expected {
+				if benchmarks.Items[i].ID != id {
+					t.Errorf("Expected benchmark %s at %d, got %s", id, i, benchmarks.Items[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown field", testProvider + "version: 2\n"},
+		{"missing label", "id: p\nbenchmarks:\n  - id: b\n    label: B\n"},
+		{"no benchmarks", "id: p\nlabel: P\n"},
+		{"benchmark without id", "id: p\nlabel: P\nbenchmarks:\n  - label: B\n"},
+		{"duplicate benchmark", "id: p\nlabel: P\nbenchmarks:\n  - id: b\n    label: B\n  - id: b\n    label: B\n"},
+		{"not yaml", "id: [p"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "invalid.yaml", tt.content)
+			if _, err := catalog.NewCatalog(&config.CatalogConfig{Dir: dir}, logging.FallbackLogger()); err == nil {
+				t.Error("Expected an error for an invalid catalog file")
+			}
+		})
+	}
+
+	t.Run("duplicate provider", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "a.yaml", testProvider)
+		writeFile(t, dir, "b.yaml", testProvider)
+		if _, err := catalog.NewCatalog(&config.CatalogConfig{Dir: dir}, logging.FallbackLogger()); err == nil {
+			t.Error("Expected an error for a duplicate provider")
+		}
+	})
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "test.yaml", testProvider)
+	c := newCatalog(t, dir, true)
+
+	waitFor := func(description string, condition func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", description)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	writeFile(t, dir, "other.yaml", otherProvider)
+	waitFor("the new provider", func() bool { return c.GetProviders().TotalCount == 2 })
+
+	// an invalid file keeps the current catalog
+	writeFile(t, dir, "other.yaml", "id: [other")
+	time.Sleep(500 * time.Millisecond)
+	if c.GetProviders().TotalCount != 2 {
+		t.Errorf("Expected the catalog to be kept after an invalid change, got %d providers", c.GetProviders().TotalCount)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "other.yaml")); err != nil {
+		t.Fatalf("Failed to remove other.yaml: %v", err)
+	}
+	waitFor("the provider to be removed", func() bool { return c.GetProviders().TotalCount == 1 })
+}
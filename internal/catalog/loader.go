@@ -0,0 +1,139 @@
+package catalog
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"sigs.k8s.io/yaml"
+)
+
+// providerFile is the schema of a catalog file, unknown fields are rejected
+type providerFile struct {
+	ID          string          `json:"id" validate:"required"`
+	Label       string          `json:"label" validate:"required"`
+	Description string          `json:"description,omitempty"`
+	Benchmarks  []benchmarkFile `json:"benchmarks" validate:"required,min=1,dive"`
+}
+
+type benchmarkFile struct {
+	ID          string   `json:"id" validate:"required"`
+	Label       string   `json:"label" validate:"required"`
+	Description string   `json:"description,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// ParametersSchema is the JSON Schema of the parameters of the evaluation requests
+	ParametersSchema map[string]any `json:"parameters_schema,omitempty"`
+}
+
+// content is an immutable snapshot of the catalog
+type content struct {
+	providers  []api.ProviderResource
+	benchmarks []api.BenchmarkResource
+	// the compiled parameter schemas by benchmark key
+	schemas map[string]*jsonschema.Schema
+}
+
+// load reads the YAML files of the directory in name order
+func load(fsys fs.FS) (*content, error) {
+	validate, err := validation.NewValidator()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the catalog: %w", err)
+	}
+
+	c := &content{
+		providers:  []api.ProviderResource{},
+		benchmarks: []api.BenchmarkResource{},
+		schemas:    map[string]*jsonschema.Schema{},
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (path.Ext(name) != ".yaml" && path.Ext(name) != ".yml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the catalog file %s: %w", name, err)
+		}
+		file := &providerFile{}
+		if err := yaml.UnmarshalStrict(data, file); err != nil {
+			return nil, fmt.Errorf("invalid catalog file %s: %w", name, err)
+		}
+		if err := validate.Struct(file); err != nil {
+			return nil, fmt.Errorf("invalid catalog file %s: %w", name, err)
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if err := c.add(file, info); err != nil {
+			return nil, fmt.Errorf("invalid catalog file %s: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *content) add(file *providerFile, info fs.FileInfo) error {
+	if slices.ContainsFunc(c.providers, func(p api.ProviderResource) bool { return p.ID == file.ID }) {
+		return fmt.Errorf("duplicate provider %s", file.ID)
+	}
+	provider := api.ProviderResource{
+		ID:          file.ID,
+		Label:       file.Label,
+		Description: file.Description,
+	}
+	for _, benchmark := range file.Benchmarks {
+		if slices.ContainsFunc(provider.SupportedBenchmarks, func(b api.SupportedBenchmark) bool { return b.ID == benchmark.ID }) {
+			return fmt.Errorf("duplicate benchmark %s", benchmark.ID)
+		}
+		if benchmark.ParametersSchema != nil {
+			schema, err := compileSchema(file.ID, benchmark.ID, benchmark.ParametersSchema)
+			if err != nil {
+				return err
+			}
+			c.schemas[benchmarkKey(file.ID, benchmark.ID)] = schema
+		}
+		provider.SupportedBenchmarks = append(provider.SupportedBenchmarks, api.SupportedBenchmark{ID: benchmark.ID})
+		c.benchmarks = append(c.benchmarks, api.BenchmarkResource{
+			Resource: api.Resource{
+				ID:        benchmark.ID,
+				CreatedAt: info.ModTime().UTC(),
+				UpdatedAt: info.ModTime().UTC(),
+			},
+			Label:       benchmark.Label,
+			Description: benchmark.Description,
+			Category:    benchmark.Category,
+			ProviderID:  file.ID,
+			Tags:        benchmark.Tags,
+
+			ParametersSchema: benchmark.ParametersSchema,
+		})
+	}
+	c.providers = append(c.providers, provider)
+	return nil
+}
+
+func compileSchema(providerID string, benchmarkID string, schema map[string]any) (*jsonschema.Schema, error) {
+	url := fmt.Sprintf("catalog:///%s/%s/parameters.json", providerID, benchmarkID)
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, schema); err != nil {
+		return nil, fmt.Errorf("invalid parameters schema of benchmark %s: %w", benchmarkID, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters schema of benchmark %s: %w", benchmarkID, err)
+	}
+	return compiled, nil
+}
+
+func benchmarkKey(providerID string, benchmarkID string) string {
+	return providerID + "/" + benchmarkID
+}
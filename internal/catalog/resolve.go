@@ -0,0 +1,149 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var printer = message.NewPrinter(language.English)
+
+// ResolveBenchmarks checks that the benchmarks of an evaluation request are in the catalog and
+// that their parameters match the schema of the benchmark. The provider is set when the
+// benchmark id is unique in the catalog and the category is set from the catalog when it is
+// not given. The locations of the errors start at the benchmarks field of the request body.
+func (c *Catalog) ResolveBenchmarks(benchmarks []api.BenchmarkConfig) []api.ValidationError {
+	content := c.get()
+	details := []api.ValidationError{}
+	for i := range benchmarks {
+		details = append(details, content.resolve(&benchmarks[i], []any{validation.BodyLoc, "benchmarks", i})...)
+	}
+	return details
+}
+
+func (c *content) resolve(benchmark *api.BenchmarkConfig, loc []any) []api.ValidationError {
+	if benchmark.ID == "" {
+		return []api.ValidationError{{Loc: appendLoc(loc, "id"), Msg: "field required", Type: "value_error.missing"}}
+	}
+
+	var resolved *api.BenchmarkResource
+	if benchmark.ProviderID != "" {
+		if !c.hasProvider(benchmark.ProviderID) {
+			return []api.ValidationError{{
+				Loc:  appendLoc(loc, "provider_id"),
+				Msg:  fmt.Sprintf("unknown provider %s", benchmark.ProviderID),
+				Type: "value_error.unknown_provider",
+			}}
+		}
+		resolved = c.find(benchmark.ProviderID, benchmark.ID)
+		if resolved == nil {
+			return []api.ValidationError{{
+				Loc:  appendLoc(loc, "id"),
+				Msg:  fmt.Sprintf("unknown benchmark %s of provider %s", benchmark.ID, benchmark.ProviderID),
+				Type: "value_error.unknown_benchmark",
+			}}
+		}
+	} else {
+		matches := []*api.BenchmarkResource{}
+		for i := range c.benchmarks {
+			if c.benchmarks[i].ID == benchmark.ID {
+				matches = append(matches, &c.benchmarks[i])
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return []api.ValidationError{{
+				Loc:  appendLoc(loc, "id"),
+				Msg:  fmt.Sprintf("unknown benchmark %s", benchmark.ID),
+				Type: "value_error.unknown_benchmark",
+			}}
+		case 1:
+			resolved = matches[0]
+		default:
+			return []api.ValidationError{{
+				Loc:  appendLoc(loc, "provider_id"),
+				Msg:  fmt.Sprintf("benchmark %s is provided by more than one provider, the provider is required", benchmark.ID),
+				Type: "value_error.ambiguous_benchmark",
+			}}
+		}
+	}
+
+	benchmark.ProviderID = resolved.ProviderID
+	if benchmark.Category == "" {
+		benchmark.Category = resolved.Category
+	}
+
+	schema := c.schemas[benchmarkKey(resolved.ProviderID, resolved.ID)]
+	if schema == nil {
+		return nil
+	}
+	parameters := benchmark.Parameters
+	if parameters == nil {
+		parameters = map[string]any{}
+	}
+	err := schema.Validate(parameters)
+	if err == nil {
+		return nil
+	}
+	var schemaError *jsonschema.ValidationError
+	if !errors.As(err, &schemaError) {
+		return []api.ValidationError{{Loc: appendLoc(loc, "parameters"), Msg: err.Error(), Type: "value_error.jsonschema"}}
+	}
+	return schemaErrors(schemaError, appendLoc(loc, "parameters"))
+}
+
+func (c *content) hasProvider(id string) bool {
+	for _, provider := range c.providers {
+		if provider.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *content) find(providerID string, id string) *api.BenchmarkResource {
+	for i := range c.benchmarks {
+		if c.benchmarks[i].ProviderID == providerID && c.benchmarks[i].ID == id {
+			return &c.benchmarks[i]
+		}
+	}
+	return nil
+}
+
+// schemaErrors reports the leaves of the schema validation error, these are the keywords
+// that failed, at the location of the parameter
+func schemaErrors(schemaError *jsonschema.ValidationError, loc []any) []api.ValidationError {
+	if len(schemaError.Causes) > 0 {
+		details := []api.ValidationError{}
+		for _, cause := range schemaError.Causes {
+			details = append(details, schemaErrors(cause, loc)...)
+		}
+		return details
+	}
+	errorLoc := loc
+	for _, name := range schemaError.InstanceLocation {
+		// the items of the arrays are located by their index
+		if index, err := strconv.Atoi(name); err == nil {
+			errorLoc = appendLoc(errorLoc, index)
+		} else {
+			errorLoc = appendLoc(errorLoc, name)
+		}
+	}
+	keyword := strings.Join(schemaError.ErrorKind.KeywordPath(), ".")
+	return []api.ValidationError{{
+		Loc:  errorLoc,
+		Msg:  schemaError.ErrorKind.LocalizedString(printer),
+		Type: "value_error.jsonschema." + keyword,
+	}}
+}
+
+func appendLoc(loc []any, elements ...any) []any {
+	return append(append([]any{}, loc...), elements...)
+}
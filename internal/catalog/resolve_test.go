@@ -0,0 +1,144 @@
+package catalog_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const schemaProvider = `
+id: schema_provider
+label: Schema Provider
+benchmarks:
+  - id: bench_a
+    label: Bench A
+    category: knowledge
+    parameters_schema:
+      type: object
+      required: [num_fewshot]
+      additionalProperties: false
+      properties:
+        num_fewshot:
+          type: integer
+          minimum: 0
+        stop:
+          type: array
+          items:
+            type: string
+  - id: bench_d
+    label: Bench D
+`
+
+func TestResolveBenchmarks(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "test.yaml", testProvider)
+	writeFile(t, dir, "schema.yaml", schemaProvider)
+	c := newCatalog(t, dir, false)
+
+	t.Run("resolves the provider and the category", func(t *testing.T) {
+		benchmarks := []api.BenchmarkConfig{
+			{Ref: api.Ref{ID: "bench_b"}},
+			{Ref: api.Ref{ID: "bench_a"}, ProviderID: "schema_provider", Category: "custom", Parameters: map[string]any{"num_fewshot": float64(5), "stop": []any{"\n"}}},
+			{Ref: api.Ref{ID: "bench_d"}, Parameters: map[string]any{"anything": true}},
+		}
+		if details := c.ResolveBenchmarks(benchmarks); len(details) != 0 {
+			t.Fatalf("Expected no validation errors, got %+v", details)
+		}
+		if benchmarks[0].ProviderID != "test_provider" || benchmarks[0].Category != "math" {
+			t.Errorf("Expected the provider and the category from the catalog, got %s and %s", benchmarks[0].ProviderID, benchmarks[0].Category)
+		}
+		if benchmarks[1].Category != "custom" {
+			t.Errorf("Expected the category of the request to be kept, got %s", benchmarks[1].Category)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		benchmark api.BenchmarkConfig
+		expected  []api.ValidationError
+	}{
+		{
+			name:      "missing id",
+			benchmark: api.BenchmarkConfig{},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "id"}, Type: "value_error.missing"}},
+		},
+		{
+			name:      "unknown benchmark",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "unknown"}},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "id"}, Type: "value_error.unknown_benchmark"}},
+		},
+		{
+			name:      "unknown provider",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "bench_b"}, ProviderID: "unknown"},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "provider_id"}, Type: "value_error.unknown_provider"}},
+		},
+		{
+			name:      "benchmark of another provider",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "bench_b"}, ProviderID: "schema_provider"},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "id"}, Type: "value_error.unknown_benchmark"}},
+		},
+		{
+			name:      "ambiguous benchmark",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "bench_a"}},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "provider_id"}, Type: "value_error.ambiguous_benchmark"}},
+		},
+		{
+			name:      "missing parameter",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "bench_a"}, ProviderID: "schema_provider"},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "parameters"}, Type: "value_error.jsonschema.required"}},
+		},
+		{
+			name: "invalid parameters",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "bench_a"}, ProviderID: "schema_provider", Parameters: map[string]any{
+				"num_fewshot": float64(-1),
+				"stop":        []any{"\n", float64(1)},
+			}},
+			expected: []api.ValidationError{
+				{Loc: []any{"body", "benchmarks", 0, "parameters", "num_fewshot"}, Type: "value_error.jsonschema.minimum"},
+				{Loc: []any{"body", "benchmarks", 0, "parameters", "stop", 1}, Type: "value_error.jsonschema.type"},
+			},
+		},
+		{
+			name:      "unknown parameter",
+			benchmark: api.BenchmarkConfig{Ref: api.Ref{ID: "bench_a"}, ProviderID: "schema_provider", Parameters: map[string]any{"num_fewshot": float64(0), "seed": float64(1)}},
+			expected:  []api.ValidationError{{Loc: []any{"body", "benchmarks", 0, "parameters"}, Type: "value_error.jsonschema.additionalProperties"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := c.ResolveBenchmarks([]api.BenchmarkConfig{tt.benchmark})
+			if len(details) != len(tt.expected) {
+				t.Fatalf("Expected %d validation errors, got %+v", len(tt.expected), details)
+			}
+			for i, expected := range tt.expected {
+				if !hasValidationError(details, expected) {
+					t.Errorf("Expected the validation error %v at %v, got %+v", expected.Type, expected.Loc, details)
+				}
+				if details[i].Msg == "" {
+					t.Errorf("Expected a message for the validation error %+v", details[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidParametersSchema(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "invalid.yaml", "id: p\nlabel: P\nbenchmarks:\n  - id: b\n    label: B\n    parameters_schema:\n      type: unknown\n")
+	if _, err := catalog.NewCatalog(&config.CatalogConfig{Dir: dir}, logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error for an invalid parameters schema")
+	}
+}
+
+func hasValidationError(details []api.ValidationError, expected api.ValidationError) bool {
+	for _, detail := range details {
+		if detail.Type == expected.Type && reflect.DeepEqual(detail.Loc, expected.Loc) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"os"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay lets the editors and the config map updates finish writing all the files
+// before the catalog is reloaded
+const reloadDelay = 200 * time.Millisecond
+
+// watch reloads the catalog when the files of the catalog directory change, the current
+// catalog is kept when the new files are invalid
+func (c *Catalog) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(c.dir); err != nil {
+		watcher.Close()
+		return err
+	}
+	c.watcher = watcher
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		reload := time.NewTimer(reloadDelay)
+		reload.Stop()
+		defer reload.Stop()
+		for {
+			select {
+			case _, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				reload.Reset(reloadDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				c.logger.Error("Failed to watch the catalog", "error", err.Error())
+			case <-reload.C:
+				c.reload()
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *Catalog) reload() {
+	content, err := load(os.DirFS(c.dir))
+	if err != nil {
+		c.logger.Error("Failed to reload the catalog, keeping the current catalog", "error", err.Error())
+		return
+	}
+	c.set(content)
+	c.logger.Info("Reloaded the catalog", "providers", len(content.providers), "benchmarks", len(content.benchmarks))
+}
@@ -0,0 +1,72 @@
+package collections
+
+import (
+	"maps"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Expand returns the benchmarks of an evaluation job that references the collection. The
+// benchmarks of the collection are copied with their configuration so that later changes
+// to the collection do not change the job. A benchmark of the request that is in the
+// collection overrides the configuration of the collection, the other benchmarks of the
+// request are added after the benchmarks of the collection.
+func Expand(collection *api.CollectionResource, overrides []api.BenchmarkConfig) []api.BenchmarkConfig {
+	benchmarks := make([]api.BenchmarkConfig, 0, len(collection.Benchmarks)+len(overrides))
+	for _, benchmark := range collection.Benchmarks {
+		benchmarks = append(benchmarks, Merge(benchmark, api.BenchmarkConfig{}))
+	}
+	for _, override := range overrides {
+		if i := indexOf(benchmarks, override); i >= 0 {
+			benchmarks[i] = Merge(benchmarks[i], override)
+		} else {
+			benchmarks = append(benchmarks, Merge(override, api.BenchmarkConfig{}))
+		}
+	}
+	return benchmarks
+}
+
+// Merge returns a copy of the benchmark with the fields that are set in the override, the
+// parameters are merged and the parameters of the override win
+func Merge(benchmark api.BenchmarkConfig, override api.BenchmarkConfig) api.BenchmarkConfig {
+	merged := benchmark
+	if override.ProviderID != "" {
+		merged.ProviderID = override.ProviderID
+	}
+	if override.Limit != nil {
+		merged.Limit = override.Limit
+	}
+	if override.Weight != nil {
+		merged.Weight = override.Weight
+	}
+	if override.Category != "" {
+		merged.Category = override.Category
+	}
+	if benchmark.Parameters != nil || override.Parameters != nil {
+		merged.Parameters = make(map[string]any, len(benchmark.Parameters)+len(override.Parameters))
+		maps.Copy(merged.Parameters, benchmark.Parameters)
+		maps.Copy(merged.Parameters, override.Parameters)
+	}
+	if merged.Limit != nil {
+		limit := *merged.Limit
+		merged.Limit = &limit
+	}
+	if merged.Weight != nil {
+		weight := *merged.Weight
+		merged.Weight = &weight
+	}
+	return merged
+}
+
+// indexOf matches the benchmarks by id, and by provider when the override has a provider
+func indexOf(benchmarks []api.BenchmarkConfig, override api.BenchmarkConfig) int {
+	for i, benchmark := range benchmarks {
+		if benchmark.ID != override.ID {
+			continue
+		}
+		if override.ProviderID == "" || benchmark.ProviderID == "" || benchmark.ProviderID == override.ProviderID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,75 @@
+package collections_test
+
+import (
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/collections"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestExpand(t *testing.T) {
+	collection := &api.CollectionResource{
+		CollectionConfig: api.CollectionConfig{
+			Name: "reasoning",
+			Benchmarks: []api.BenchmarkConfig{
+				{Ref: api.Ref{ID: "arc_challenge"}, Limit: intPtr(100), Weight: floatPtr(2), Parameters: map[string]any{"num_fewshot": 25.0, "batch_size": 8.0}},
+				{Ref: api.Ref{ID: "hellaswag"}, ProviderID: "lm_evaluation_harness", Category: "commonsense"},
+			},
+		},
+	}
+	overrides := []api.BenchmarkConfig{
+		{Ref: api.Ref{ID: "arc_challenge"}, Limit: intPtr(10), Parameters: map[string]any{"num_fewshot": 0.0}},
+		{Ref: api.Ref{ID: "hellaswag"}, ProviderID: "other_provider"},
+		{Ref: api.Ref{ID: "gsm8k"}, Weight: floatPtr(3)},
+	}
+
+	benchmarks := collections.Expand(collection, overrides)
+	if len(benchmarks) != 4 {
+		t.Fatalf("Expected 4 benchmarks, got %d", len(benchmarks))
+	}
+
+	arc := benchmarks[0]
+	if arc.ID != "arc_challenge" || *arc.Limit != 10 || *arc.Weight != 2 {
+		t.Errorf("Expected the limit of the request and the weight of the collection, got %+v", arc)
+	}
+	if arc.Parameters["num_fewshot"] != 0.0 || arc.Parameters["batch_size"] != 8.0 {
+		t.Errorf("Expected the parameters to be merged, got %v", arc.Parameters)
+	}
+	if benchmarks[1].ID != "hellaswag" || benchmarks[1].ProviderID != "lm_evaluation_harness" || benchmarks[1].Category != "commonsense" {
+		t.Errorf("Expected the hellaswag of the collection to be unchanged, got %+v", benchmarks[1])
+	}
+	if benchmarks[2].ProviderID != "other_provider" || benchmarks[3].ID != "gsm8k" || *benchmarks[3].Weight != 3 {
+		t.Errorf("Expected the other benchmarks of the request after the collection, got %+v and %+v", benchmarks[2], benchmarks[3])
+	}
+
+	// the job does not share anything with the collection
+	arc.Parameters["batch_size"] = 1.0
+	*arc.Weight = 5
+	if collection.Benchmarks[0].Parameters["batch_size"] != 8.0 || *collection.Benchmarks[0].Weight != 2 {
+		t.Errorf("Expected the collection to be unchanged, got %+v", collection.Benchmarks[0])
+	}
+	if collection.Benchmarks[0].Parameters["num_fewshot"] != 25.0 || *collection.Benchmarks[0].Limit != 100 {
+		t.Errorf("Expected the overrides to leave the collection unchanged, got %+v", collection.Benchmarks[0])
+	}
+}
+
+func TestExpandWithoutOverrides(t *testing.T) {
+	collection := &api.CollectionResource{
+		CollectionConfig: api.CollectionConfig{
+			Name:       "empty",
+			Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}},
+		},
+	}
+	benchmarks := collections.Expand(collection, nil)
+	if len(benchmarks) != 1 || benchmarks[0].ID != "mmlu" || benchmarks[0].Parameters != nil {
+		t.Errorf("Expected the benchmark of the collection, got %+v", benchmarks)
+	}
+}
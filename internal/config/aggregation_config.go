@@ -0,0 +1,38 @@
+package config
+
+// The aggregation functions that can be configured
+const (
+	AggregationWeightedMean   = "weighted_mean"
+	AggregationCategoryRollup = "category_rollup"
+	AggregationThresholds     = "thresholds"
+)
+
+// DefaultScoreMetrics are the metrics used as the score of a benchmark, the first one
+// reported by the benchmark is used
+var DefaultScoreMetrics = []string{"score", "acc_norm", "acc", "exact_match", "f1"}
+
+// AggregationConfig configures how the aggregated metrics of an evaluation job are computed
+// from the benchmark results once the last benchmark has finished. The functions are run in
+// order and a function can use the metrics computed by the previous ones.
+type AggregationConfig struct {
+	Functions    []string          `mapstructure:"functions,omitempty"`
+	ScoreMetrics []string          `mapstructure:"score_metrics,omitempty"`
+	Thresholds   []ThresholdConfig `mapstructure:"thresholds,omitempty"`
+}
+
+// ThresholdConfig is the minimum value of an aggregated metric for the evaluation to pass
+type ThresholdConfig struct {
+	Metric string  `mapstructure:"metric"`
+	Min    float64 `mapstructure:"min"`
+}
+
+// CheckConfig sets the defaults of the values that are not set
+func (c *AggregationConfig) CheckConfig() error {
+	if len(c.Functions) == 0 {
+		c.Functions = []string{AggregationWeightedMean, AggregationCategoryRollup, AggregationThresholds}
+	}
+	if len(c.ScoreMetrics) == 0 {
+		c.ScoreMetrics = DefaultScoreMetrics
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// DefaultArtifactMaxSize is the largest artifact that can be uploaded, 100 MiB
+	DefaultArtifactMaxSize int64 = 100 << 20
+	DefaultS3Timeout             = 5 * time.Minute
+	DefaultS3Region              = "us-east-1"
+)
+
+// ArtifactsConfig configures the store of the files that the runtimes and the adapters upload
+// for the jobs. The files are stored by the SHA-256 digest of their content, in S3-compatible
+// object storage when it is enabled and on the local filesystem otherwise.
+type ArtifactsConfig struct {
+	// MaxSize is the largest artifact in bytes that is accepted
+	MaxSize int64 `mapstructure:"max_size"`
+	// Dir is the directory of the filesystem store
+	Dir string             `mapstructure:"dir"`
+	S3  *S3ArtifactsConfig `mapstructure:"s3,omitempty"`
+}
+
+// S3ArtifactsConfig configures an S3-compatible store such as AWS S3 or MinIO, the objects are
+// addressed with the path-style URL <endpoint>/<bucket>/<prefix><key>
+type S3ArtifactsConfig struct {
+	Enabled         bool          `mapstructure:"enabled,omitempty"`
+	Endpoint        string        `mapstructure:"endpoint"`
+	Region          string        `mapstructure:"region"`
+	Bucket          string        `mapstructure:"bucket"`
+	Prefix          string        `mapstructure:"prefix,omitempty"`
+	AccessKeyID     string        `mapstructure:"access_key_id,omitempty"`
+	SecretAccessKey string        `mapstructure:"secret_access_key,omitempty"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+// CheckConfig sets the defaults of the values that are not set
+func (c *ArtifactsConfig) CheckConfig() error {
+	if c.MaxSize <= 0 {
+		c.MaxSize = DefaultArtifactMaxSize
+	}
+	if c.Dir == "" {
+		c.Dir = filepath.Join(os.TempDir(), "eval-hub", "artifacts")
+	}
+	if c.S3 != nil && c.S3.Enabled {
+		return c.S3.CheckConfig()
+	}
+	return nil
+}
+
+// CheckConfig sets the defaults of the values that are not set
+func (c *S3ArtifactsConfig) CheckConfig() error {
+	if c.Endpoint == "" || c.Bucket == "" {
+		return fmt.Errorf("the S3 artifact store requires an endpoint and a bucket")
+	}
+	if (c.AccessKeyID == "") != (c.SecretAccessKey == "") {
+		return fmt.Errorf("the S3 artifact store requires both an access key id and a secret access key")
+	}
+	if c.Region == "" {
+		c.Region = DefaultS3Region
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultS3Timeout
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultAuthAPIKeyHeader      = "X-API-Key"
+	DefaultAuthSubjectClaim      = "sub"
+	DefaultAuthJWKSRefresh       = 10 * time.Minute
+	DefaultAuthClockSkew         = 30 * time.Second
+	DefaultAuthJWKSFetchInterval = 30 * time.Second
+)
+
+// AuthConfig configures the authentication of the requests. When it is enabled a request
+// must be authenticated by one of the enabled methods, except for the public routes. The
+// public routes are path patterns as matched by path.Match, for example /api/v1/health.
+type AuthConfig struct {
+	Enabled      bool          `mapstructure:"enabled,omitempty"`
+	PublicRoutes []string      `mapstructure:"public_routes,omitempty"`
+	JWT          *JWTConfig    `mapstructure:"jwt,omitempty"`
+	APIKeys      *APIKeyConfig `mapstructure:"api_keys,omitempty"`
+	MTLS         *MTLSConfig   `mapstructure:"mtls,omitempty"`
+}
+
+// JWTConfig configures the bearer JWTs, the signature is checked with the keys of the JWKS
+// read from a local file or a URL. The keys are read again after RefreshInterval, or when a
+// token is signed with an unknown key. The issuer and the audience are only checked when set.
+type JWTConfig struct {
+	Enabled         bool          `mapstructure:"enabled,omitempty"`
+	JWKSFile        string        `mapstructure:"jwks_file,omitempty"`
+	JWKSURL         string        `mapstructure:"jwks_url,omitempty"`
+	Issuer          string        `mapstructure:"issuer,omitempty"`
+	Audience        string        `mapstructure:"audience,omitempty"`
+	SubjectClaim    string        `mapstructure:"subject_claim,omitempty"`
+	RefreshInterval time.Duration `mapstructure:"refresh_interval,omitempty"`
+	ClockSkew       time.Duration `mapstructure:"clock_skew,omitempty"`
+}
+
+// APIKeyConfig configures the static API keys that are sent in the header. Only the SHA-256
+// hashes of the keys are configured, one key per line as <name>:<hex encoded hash>, they are
+// usually loaded from the api_keys secret. The name of the key is the principal.
+type APIKeyConfig struct {
+	Enabled bool   `mapstructure:"enabled,omitempty"`
+	Header  string `mapstructure:"header,omitempty"`
+	Keys    string `mapstructure:"keys,omitempty"`
+}
+
+// MTLSConfig configures the client certificates. The server is started with TLS and requests
+// the certificates signed by the CA, the subject (common name) of the certificate is the
+// principal. When subjects is set only these subjects are accepted.
+type MTLSConfig struct {
+	Enabled  bool     `mapstructure:"enabled,omitempty"`
+	CAFile   string   `mapstructure:"ca_file,omitempty"`
+	CertFile string   `mapstructure:"cert_file,omitempty"`
+	KeyFile  string   `mapstructure:"key_file,omitempty"`
+	Subjects []string `mapstructure:"subjects,omitempty"`
+}
+
+// CheckConfig sets the defaults of the values that are not set, at least one method must be
+// enabled when the authentication is enabled
+func (c *AuthConfig) CheckConfig() error {
+	if !c.Enabled {
+		return nil
+	}
+	enabled := false
+	if c.JWT != nil && c.JWT.Enabled {
+		enabled = true
+		if (c.JWT.JWKSFile == "") == (c.JWT.JWKSURL == "") {
+			return fmt.Errorf("exactly one of auth.jwt.jwks_file and auth.jwt.jwks_url must be set")
+		}
+		if c.JWT.SubjectClaim == "" {
+			c.JWT.SubjectClaim = DefaultAuthSubjectClaim
+		}
+		if c.JWT.RefreshInterval <= 0 {
+			c.JWT.RefreshInterval = DefaultAuthJWKSRefresh
+		}
+		if c.JWT.ClockSkew <= 0 {
+			c.JWT.ClockSkew = DefaultAuthClockSkew
+		}
+	}
+	if c.APIKeys != nil && c.APIKeys.Enabled {
+		enabled = true
+		if c.APIKeys.Header == "" {
+			c.APIKeys.Header = DefaultAuthAPIKeyHeader
+		}
+	}
+	if c.MTLS != nil && c.MTLS.Enabled {
+		enabled = true
+		if c.MTLS.CAFile == "" || c.MTLS.CertFile == "" || c.MTLS.KeyFile == "" {
+			return fmt.Errorf("auth.mtls.ca_file, auth.mtls.cert_file and auth.mtls.key_file must be set")
+		}
+	}
+	if !enabled {
+		return fmt.Errorf("the authentication is enabled without an authentication method")
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package config
+
+// AuthorizationConfig configures the role-based authorization of the authenticated requests.
+// The roles and their permissions are read from the policy file, the policy built into the
+// service is used when the file is not set. The authorization requires the authentication.
+type AuthorizationConfig struct {
+	Enabled    bool   `mapstructure:"enabled,omitempty"`
+	PolicyFile string `mapstructure:"policy_file,omitempty"`
+}
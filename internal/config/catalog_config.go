@@ -0,0 +1,10 @@
+package config
+
+// CatalogConfig configures where the providers and benchmarks are loaded from. Each YAML
+// file of the directory describes a provider and its benchmarks, the catalog built into the
+// service is used when the directory is not set. When watch is true the catalog is reloaded
+// when the files of the directory change.
+type CatalogConfig struct {
+	Dir   string `mapstructure:"dir,omitempty"`
+	Watch bool   `mapstructure:"watch,omitempty"`
+}
@@ -1,6 +1,17 @@
 package config
 
 type Config struct {
-	Service  *ServiceConfig  `mapstructure:"service"`
-	Database *DatabaseConfig `mapstructure:"database"`
+	Service       *ServiceConfig       `mapstructure:"service"`
+	Database      *DatabaseConfig      `mapstructure:"database"`
+	Runtime       *RuntimeConfig       `mapstructure:"runtime,omitempty"`
+	Dispatcher    *DispatcherConfig    `mapstructure:"dispatcher,omitempty"`
+	Aggregation   *AggregationConfig   `mapstructure:"aggregation,omitempty"`
+	Catalog       *CatalogConfig       `mapstructure:"catalog,omitempty"`
+	Tenancy       *TenancyConfig       `mapstructure:"tenancy,omitempty"`
+	Auth          *AuthConfig          `mapstructure:"auth,omitempty"`
+	Authorization *AuthorizationConfig `mapstructure:"authorization,omitempty"`
+	Webhooks      *WebhookConfig       `mapstructure:"webhooks,omitempty"`
+	Logs          *LogsConfig          `mapstructure:"logs,omitempty"`
+	MLflow        *MLflowConfig        `mapstructure:"mlflow,omitempty"`
+	Artifacts     *ArtifactsConfig     `mapstructure:"artifacts,omitempty"`
 }
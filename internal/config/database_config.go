@@ -28,16 +28,13 @@ type SQLDatabaseConfig struct {
 
 type SQLTableConfig struct {
 	TableName     string `mapstructure:"table_name"`
-	JSONFieldType string `mapstructure:"json_field_type,omitempy"` // fallback is TEXT
+	JSONFieldType string `mapstructure:"json_field_type,omitempy"` // fallback is the JSON type of the SQL dialect
 }
 
 func (tc *SQLTableConfig) CheckConfig() error {
 	if tc.TableName == "" {
 		return fmt.Errorf("missing table name")
 	}
-	if tc.JSONFieldType == "" {
-		tc.JSONFieldType = "TEXT"
-	}
 	return nil
 }
 
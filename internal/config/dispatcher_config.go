@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultDispatcherWorkers       = 4
+	DefaultDispatcherPollInterval  = 2 * time.Second
+	DefaultDispatcherLeaseDuration = 30 * time.Second
+)
+
+// DispatcherConfig configures how the pending evaluation jobs are claimed from the storage
+// and run. A claimed job is leased for LeaseDuration and the lease is renewed while the job
+// runs, when a replica stops the jobs it was running are claimed again once their lease expires.
+type DispatcherConfig struct {
+	Workers       int           `mapstructure:"workers"`
+	PollInterval  time.Duration `mapstructure:"poll_interval"`
+	LeaseDuration time.Duration `mapstructure:"lease_duration"`
+}
+
+// CheckConfig sets the defaults of the values that are not set, the lease must be
+// long enough to be renewed a few times per lease duration
+func (c *DispatcherConfig) CheckConfig() error {
+	if c.Workers <= 0 {
+		c.Workers = DefaultDispatcherWorkers
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = DefaultDispatcherPollInterval
+	}
+	if c.LeaseDuration <= 0 {
+		c.LeaseDuration = DefaultDispatcherLeaseDuration
+	}
+	if c.LeaseDuration < time.Second {
+		return fmt.Errorf("the dispatcher lease duration must be at least 1s, got %s", c.LeaseDuration)
+	}
+	return nil
+}
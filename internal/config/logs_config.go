@@ -0,0 +1,22 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LogsConfig configures the store of the benchmark logs. The logs are written by the runtime
+// and served by the logs endpoint so that they can be read without access to the runtime.
+type LogsConfig struct {
+	// Dir is the directory of the filesystem store, the log of a benchmark is
+	// <dir>/<job id>/<benchmark>.log
+	Dir string `mapstructure:"dir"`
+}
+
+// CheckConfig sets the defaults of the values that are not set
+func (c *LogsConfig) CheckConfig() error {
+	if c.Dir == "" {
+		c.Dir = filepath.Join(os.TempDir(), "eval-hub", "logs")
+	}
+	return nil
+}
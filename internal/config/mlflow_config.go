@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultMLflowTimeout = 10 * time.Second
+
+// MLflowConfig configures the tracking of the results in MLflow. Each benchmark result is
+// recorded as a run of the experiment of the job, the jobs without an experiment use the
+// default experiment and are not tracked when there is no default experiment.
+type MLflowConfig struct {
+	Enabled           bool          `mapstructure:"enabled,omitempty"`
+	TrackingURI       string        `mapstructure:"tracking_uri"`
+	Token             string        `mapstructure:"token,omitempty"`
+	DefaultExperiment string        `mapstructure:"default_experiment,omitempty"`
+	Timeout           time.Duration `mapstructure:"timeout"`
+}
+
+// CheckConfig sets the defaults of the values that are not set
+func (c *MLflowConfig) CheckConfig() error {
+	if c.TrackingURI == "" {
+		return fmt.Errorf("the MLflow tracking is enabled without a tracking URI")
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultMLflowTimeout
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package config
+
+// RuntimeConfig holds the configuration of the runtimes used to run the evaluation jobs,
+// the first enabled runtime is used.
+type RuntimeConfig struct {
+	Local *LocalRuntimeConfig `mapstructure:"local,omitempty"`
+	K8s   *K8sRuntimeConfig   `mapstructure:"k8s,omitempty"`
+}
+
+// LocalRuntimeConfig configures the runtime that runs each benchmark as a local OS process.
+// The arguments are Go templates that are rendered with the job, model and benchmark.
+// The output of the benchmarks is written to the log store.
+type LocalRuntimeConfig struct {
+	Enabled bool              `mapstructure:"enabled,omitempty"`
+	Command string            `mapstructure:"command"`
+	Args    []string          `mapstructure:"args,omitempty"`
+	Env     map[string]string `mapstructure:"env,omitempty"`
+	WorkDir string            `mapstructure:"work_dir,omitempty"`
+}
+
+// K8sRuntimeConfig configures the runtime that runs each benchmark as a Kubernetes batch/v1 Job.
+// The job template is a Go template of a Job manifest, when it is not set a default
+// template is used. When kubeconfig is not set the in-cluster configuration is used.
+// The logs of the benchmarks are read from the pods.
+type K8sRuntimeConfig struct {
+	Enabled            bool   `mapstructure:"enabled,omitempty"`
+	Kubeconfig         string `mapstructure:"kubeconfig,omitempty"`
+	Namespace          string `mapstructure:"namespace"`
+	Image              string `mapstructure:"image"`
+	ServiceAccountName string `mapstructure:"service_account_name,omitempty"`
+	JobTemplate        string `mapstructure:"job_template,omitempty"`
+}
@@ -7,4 +7,8 @@ type ServiceConfig struct {
 	Port            int    `mapstructure:"port,omitempty"`
 	ReadyFile       string `mapstructure:"ready_file"`
 	TerminationFile string `mapstructure:"termination_file"`
+	// ResultsToken is the secret that the results tokens of the jobs are derived from, the
+	// runtimes and adapters report the results of a job with the token of the job. The results
+	// can not be reported when it is not set.
+	ResultsToken string `mapstructure:"results_token,omitempty"`
 }
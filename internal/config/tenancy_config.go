@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TenancyConfig configures how the tenant of a request is resolved. The tenant is read from
+// the claim of the bearer token when a claim is set, otherwise from the header. The default
+// tenant is used for the requests without a tenant, these requests are rejected when there
+// is no default tenant. A single-tenant deployment only sets the default tenant.
+//
+// When the authentication is enabled the header, that is set by the client, is not trusted:
+// the tenant is read from the claim of the verified token or from the tenant of the subject
+// of the principal, which is how the API keys and the client certificates get a tenant.
+type TenancyConfig struct {
+	Header        string          `mapstructure:"header,omitempty"`
+	Claim         string          `mapstructure:"claim,omitempty"`
+	DefaultTenant string          `mapstructure:"default_tenant,omitempty"`
+	Subjects      []TenantSubject `mapstructure:"subjects,omitempty"`
+}
+
+// TenantSubject is the tenant of an authenticated principal, the method is the authentication
+// method of the principal (jwt, api_key or mtls) and the subject is its subject
+type TenantSubject struct {
+	Method  string `mapstructure:"method"`
+	Subject string `mapstructure:"subject"`
+	Tenant  string `mapstructure:"tenant"`
+}
+
+// CheckConfig checks the subjects, and that the tenant is not read from the header when the
+// requests are authenticated
+func (c *TenancyConfig) CheckConfig(authenticated bool) error {
+	for i, subject := range c.Subjects {
+		if !slices.Contains([]string{"jwt", "api_key", "mtls"}, subject.Method) || subject.Subject == "" || subject.Tenant == "" {
+			return fmt.Errorf("the tenancy subject %d must have a method (jwt, api_key or mtls), a subject and a tenant", i)
+		}
+	}
+	if authenticated && c.Header != "" && c.Claim == "" && len(c.Subjects) == 0 {
+		return fmt.Errorf("the tenant header is set by the client and is not trusted when the authentication is enabled, set tenancy.claim or tenancy.subjects, or an empty tenancy.header for a single tenant")
+	}
+	return nil
+}
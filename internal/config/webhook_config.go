@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultWebhookWorkers        = 4
+	DefaultWebhookPollInterval   = 2 * time.Second
+	DefaultWebhookTimeout        = 10 * time.Second
+	DefaultWebhookMaxAttempts    = 8
+	DefaultWebhookInitialBackoff = 10 * time.Second
+	DefaultWebhookMaxBackoff     = time.Hour
+)
+
+// DefaultWebhookDeniedNetworks are the loopback, link-local, private (RFC 1918) and unique
+// local networks, the callback URLs can not reach the services of the cluster or the host
+var DefaultWebhookDeniedNetworks = []string{
+	"0.0.0.0/8", "127.0.0.0/8", "169.254.0.0/16", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16",
+	"::/128", "::1/128", "fe80::/10", "fc00::/7",
+}
+
+// WebhookConfig configures the delivery of the job events to the callback URL of the jobs.
+// The events are written to an outbox with the job update and are delivered by the replicas
+// with exponential backoff, a delivery that has failed MaxAttempts times is dead-lettered.
+// The events are signed with the secret of the tenant of the job, the secrets are read one
+// per line as <tenant>:<secret> and Secret is used for the tenants without a secret. The
+// connections to the addresses of the denied networks (CIDRs) are refused, the default
+// networks are used when they are not set and an empty list allows all the addresses.
+type WebhookConfig struct {
+	Enabled         bool          `mapstructure:"enabled,omitempty"`
+	BenchmarkEvents bool          `mapstructure:"benchmark_events,omitempty"`
+	Workers         int           `mapstructure:"workers"`
+	PollInterval    time.Duration `mapstructure:"poll_interval"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	MaxAttempts     int           `mapstructure:"max_attempts"`
+	InitialBackoff  time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff      time.Duration `mapstructure:"max_backoff"`
+	Secret          string        `mapstructure:"secret,omitempty"`
+	TenantSecrets   string        `mapstructure:"tenant_secrets,omitempty"`
+	DeniedNetworks  []string      `mapstructure:"denied_networks"`
+}
+
+// CheckConfig sets the defaults of the values that are not set
+func (c *WebhookConfig) CheckConfig() error {
+	if c.Workers <= 0 {
+		c.Workers = DefaultWebhookWorkers
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = DefaultWebhookPollInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultWebhookTimeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = DefaultWebhookInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultWebhookMaxBackoff
+	}
+	if c.DeniedNetworks == nil {
+		c.DeniedNetworks = DefaultWebhookDeniedNetworks
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("the webhook max backoff %s is less than the initial backoff %s", c.MaxBackoff, c.InitialBackoff)
+	}
+	return nil
+}
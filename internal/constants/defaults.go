@@ -0,0 +1,9 @@
+package constants
+
+import "time"
+
+// Defaults of the evaluation jobs that do not set their own values
+const (
+	DefaultJobTimeout       = 60 * time.Minute
+	DefaultJobRetryAttempts = 3
+)
@@ -4,3 +4,16 @@ package constants
 const (
 	EnvVarTerminationFile = "TERMINATION_FILE"
 )
+
+// Environment variables set for the benchmark processes started by the runtimes
+const (
+	EnvVarJobID               = "EVAL_HUB_JOB_ID"
+	EnvVarBenchmarkID         = "EVAL_HUB_BENCHMARK_ID"
+	EnvVarBenchmarkLimit      = "EVAL_HUB_BENCHMARK_LIMIT"
+	EnvVarBenchmarkParameters = "EVAL_HUB_BENCHMARK_PARAMETERS"
+	EnvVarModelURL            = "EVAL_HUB_MODEL_URL"
+	EnvVarModelName           = "EVAL_HUB_MODEL_NAME"
+	// EnvVarResultsToken is the token that the benchmarks report the results and upload the
+	// artifacts of the job with
+	EnvVarResultsToken = "EVAL_HUB_RESULTS_TOKEN"
+)
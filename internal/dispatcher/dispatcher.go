@@ -0,0 +1,293 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/metrics"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// errJobCancelled is the cause of the cancellation of the runtime context when the job is cancelled
+var errJobCancelled = errors.New("evaluation job cancelled")
+
+// Dispatcher claims the pending evaluation jobs from the storage and runs them with the
+// runtime, at most Workers jobs are run at the same time by a replica. The jobs are claimed
+// with a lease that is renewed while the job runs so that the jobs of a replica that has
+// stopped are claimed and run again by another replica once their lease has expired.
+type Dispatcher struct {
+	config  *config.DispatcherConfig
+	storage abstractions.Storage
+	runtime abstractions.Runtime
+	logger  *slog.Logger
+	owner   string
+	// resultsSecret is the secret that the results tokens of the jobs are derived from
+	resultsSecret string
+
+	slots   chan struct{}
+	wake    chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	workers sync.WaitGroup
+	// jobs is the parent of the contexts of the running jobs, it is cancelled when the
+	// dispatcher stops before the jobs have finished
+	jobs     context.Context
+	stopJobs context.CancelCauseFunc
+}
+
+// NewDispatcher creates the dispatcher, the results secret is used to derive the results
+// tokens that are passed to the runtime, no token is passed when it is empty
+func NewDispatcher(dispatcherConfig *config.DispatcherConfig, resultsSecret string, storage abstractions.Storage, runtime abstractions.Runtime, logger *slog.Logger) (*Dispatcher, error) {
+	if dispatcherConfig == nil {
+		dispatcherConfig = &config.DispatcherConfig{}
+	}
+	if err := dispatcherConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	if runtime == nil {
+		return nil, fmt.Errorf("the dispatcher requires a runtime")
+	}
+	// the owner identifies this replica in the leases, the hostname is the pod name on a cluster
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "eval-hub"
+	}
+	owner := fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	logger.Info("Creating dispatcher", "owner", owner, "workers", dispatcherConfig.Workers, "runtime", runtime.GetRuntimeName())
+	return &Dispatcher{
+		config:  dispatcherConfig,
+		storage: storage,
+		runtime: runtime,
+		logger:  logger.With("dispatcher", owner),
+		owner:   owner,
+		slots:   make(chan struct{}, dispatcherConfig.Workers),
+		wake:    make(chan struct{}, 1),
+
+		resultsSecret: resultsSecret,
+	}, nil
+}
+
+// Start starts claiming the pending jobs in the background
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.jobs, d.stopJobs = context.WithCancelCause(context.Background())
+	d.done = make(chan struct{})
+	go d.run(ctx)
+}
+
+// Notify makes the dispatcher look for pending jobs without waiting for the poll interval
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops claiming jobs and waits for the running jobs until the context is done. The
+// jobs that are still running are then stopped with ErrReplicaStopped and Stop waits for
+// them to return, so that the storage is no longer used when Stop returns. Their leases
+// are not released and they are claimed again by another replica once the leases have
+// expired.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+	<-d.done
+
+	finished := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+	}
+	d.stopJobs(abstractions.ErrReplicaStopped)
+	<-finished
+	return fmt.Errorf("evaluation jobs were stopped before they finished: %w", ctx.Err())
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.updateQueueDepth()
+		d.claimJobs()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// claimJobs claims jobs until all the workers are busy or there is no job to claim
+func (d *Dispatcher) claimJobs() {
+	for {
+		select {
+		case d.slots <- struct{}{}:
+		default:
+			return
+		}
+		job, err := d.storage.ClaimEvaluationJob(d.executionContext(context.Background(), ""), d.owner, d.config.LeaseDuration)
+		if err != nil {
+			d.logger.Error("Failed to claim an evaluation job", "error", err.Error())
+		}
+		if job == nil {
+			<-d.slots
+			return
+		}
+		recovered := job.Status.State == api.StateRunning
+		metrics.EvaluationJobsClaimedTotal.WithLabelValues(strconv.FormatBool(recovered)).Inc()
+		d.logger.Info("Claimed evaluation job", "job_id", job.ID, "recovered", recovered)
+		d.workers.Add(1)
+		go d.runJob(job)
+	}
+}
+
+// runJob runs the job with the runtime and monitors the job until it has finished, the
+// runtime is stopped if the job is cancelled or if the lease is lost to another replica
+func (d *Dispatcher) runJob(job *api.EvaluationJobResource) {
+	defer d.workers.Done()
+	defer func() { <-d.slots }()
+	metrics.EvaluationJobsRunning.Inc()
+	defer metrics.EvaluationJobsRunning.Dec()
+
+	jobCtx, cancel := context.WithCancelCause(d.jobs)
+	defer cancel(nil)
+	ctx := d.executionContext(jobCtx, job.ID)
+	if d.resultsSecret != "" {
+		ctx.ResultsToken = auth.NewJobToken(d.resultsSecret, job.ID, job.Tenant)
+	}
+
+	go d.monitor(jobCtx, cancel, ctx, job.ID)
+
+	err := d.runtime.RunEvaluationJob(ctx, job, d.storage)
+	cause := context.Cause(jobCtx)
+	cancel(nil)
+	// the lease is only released once the job is in a terminal state, otherwise it is left
+	// to expire so that the job is claimed again
+	switch {
+	case errors.Is(cause, abstractions.ErrLeaseLost):
+		// another replica owns the job now so the job must not be updated or released
+		ctx.Logger.Warn("Stopped the evaluation job as the lease was lost")
+		return
+	case errors.Is(cause, abstractions.ErrReplicaStopped):
+		ctx.Logger.Info("Stopped the evaluation job as the replica is stopping, the job is recovered once its lease has expired")
+		return
+	case errors.Is(cause, errJobCancelled):
+		ctx.Logger.Info("Stopped the cancelled evaluation job")
+	case err != nil:
+		ctx.Logger.Error("Failed to run the evaluation job", "error", err.Error())
+		if err := d.storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: fmt.Sprintf("Failed to run the evaluation job: %s", err.Error()),
+		}); err != nil {
+			ctx.Logger.Error("Failed to update the evaluation job status, the job is run again once its lease has expired", "error", err.Error())
+			return
+		}
+	default:
+		if job, err := d.storage.GetEvaluationJob(ctx, job.ID); err != nil || !statemachine.IsTerminal(job.Status.State) {
+			ctx.Logger.Error("The evaluation job has not finished, the job is run again once its lease has expired")
+			return
+		}
+	}
+	if err := d.storage.ReleaseEvaluationJobLease(ctx, job.ID, d.owner); err != nil && !errors.Is(cause, errJobCancelled) {
+		ctx.Logger.Error("Failed to release the evaluation job lease", "error", err.Error())
+	}
+	d.Notify()
+}
+
+// monitor checks the job every poll interval to stop the runtime as soon as the job is cancelled
+// or deleted, and renews the lease three times per lease duration
+func (d *Dispatcher) monitor(jobCtx context.Context, cancel context.CancelCauseFunc, ctx *executioncontext.ExecutionContext, id string) {
+	ticker := time.NewTicker(min(d.config.PollInterval, d.config.LeaseDuration/3))
+	defer ticker.Stop()
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-jobCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		job, err := d.storage.GetEvaluationJob(ctx, id)
+		if errors.Is(err, abstractions.ErrNotFound) || ((err == nil) && (job.Status.State == api.StateCancelled)) {
+			cancel(errJobCancelled)
+			return
+		}
+		if err != nil {
+			ctx.Logger.Error("Failed to check the evaluation job", "error", err.Error())
+		}
+
+		if time.Since(renewedAt) < d.config.LeaseDuration/3 {
+			continue
+		}
+		err = d.storage.RenewEvaluationJobLease(ctx, id, d.owner, d.config.LeaseDuration)
+		if errors.Is(err, abstractions.ErrLeaseLost) {
+			cancel(err)
+			return
+		}
+		if err != nil {
+			ctx.Logger.Error("Failed to renew the evaluation job lease", "error", err.Error())
+			continue
+		}
+		renewedAt = time.Now()
+	}
+}
+
+func (d *Dispatcher) updateQueueDepth() {
+	page, err := d.storage.GetEvaluationJobs(d.executionContext(context.Background(), ""), true, 1, 0, string(api.StatePending))
+	if err != nil {
+		d.logger.Error("Failed to count the pending evaluation jobs", "error", err.Error())
+		return
+	}
+	metrics.EvaluationJobsQueueDepth.Set(float64(page.TotalCount))
+}
+
+// executionContext returns the context used for the storage and runtime calls, there is no
+// request so the job id is used as the request id
+func (d *Dispatcher) executionContext(ctx context.Context, jobID string) *executioncontext.ExecutionContext {
+	logger := d.logger
+	if jobID != "" {
+		logger = logger.With("job_id", jobID)
+	}
+	return executioncontext.NewExecutionContext(
+		ctx,
+		jobID,
+		logger,
+		"",
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		jobID,
+		"",
+		"",
+		constants.DefaultJobTimeout,
+		constants.DefaultJobRetryAttempts,
+		make(map[string]interface{}),
+		nil,
+		"",
+	)
+}
@@ -0,0 +1,300 @@
+package dispatcher_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/dispatcher"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// resultsSecret is the secret that the dispatchers of the tests derive the results tokens from
+const resultsSecret = "results-secret"
+
+// blockingRuntime completes the jobs once they are released by the test, the jobs without a
+// valid results token fail
+type blockingRuntime struct {
+	mu      sync.Mutex
+	running int
+	maxSeen int
+	release chan struct{}
+}
+
+func (r *blockingRuntime) GetRuntimeName() string {
+	return "blocking"
+}
+
+func (r *blockingRuntime) RunEvaluationJob(ctx *executioncontext.ExecutionContext, evaluation *api.EvaluationJobResource, storage abstractions.Storage) error {
+	r.mu.Lock()
+	r.running++
+	r.maxSeen = max(r.maxSeen, r.running)
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running--
+		r.mu.Unlock()
+	}()
+
+	if !auth.VerifyJobToken(resultsSecret, ctx.ResultsToken, evaluation.ID, evaluation.Tenant) {
+		return fmt.Errorf("the results token of the job is not valid")
+	}
+	if err := storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{State: api.StateRunning}); err != nil {
+		return err
+	}
+	select {
+	case <-r.release:
+	case <-ctx.Ctx.Done():
+		return ctx.Ctx.Err()
+	}
+	return storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{State: api.StateCompleted})
+}
+
+func (r *blockingRuntime) counts() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running, r.maxSeen
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("runs the pending jobs with a bounded number of workers", func(t *testing.T) {
+		storage := createStorage(t)
+		ctx := createExecutionContext()
+		runtime := &blockingRuntime{release: make(chan struct{})}
+		d := createDispatcher(t, storage, runtime, time.Minute)
+
+		ids := []string{}
+		for range 3 {
+			ids = append(ids, createJob(t, storage, ctx).ID)
+		}
+		d.Start()
+		waitFor(t, "two jobs to run", func() bool {
+			running, _ := runtime.counts()
+			return running == 2
+		})
+		// give the dispatcher a chance to claim a third job if it ignored the limit
+		d.Notify()
+		time.Sleep(50 * time.Millisecond)
+		close(runtime.release)
+
+		for _, id := range ids {
+			waitFor(t, "the job to complete", func() bool {
+				job, err := storage.GetEvaluationJob(ctx, id)
+				return err == nil && job.Status.State == api.StateCompleted
+			})
+		}
+		if _, maxSeen := runtime.counts(); maxSeen != 2 {
+			t.Errorf("Expected at most 2 jobs to run at the same time, got %d", maxSeen)
+		}
+		if err := d.Stop(context.Background()); err != nil {
+			t.Errorf("Stop() returned error: %v", err)
+		}
+	})
+
+	t.Run("recovers the jobs orphaned by another replica", func(t *testing.T) {
+		storage := createStorage(t)
+		ctx := createExecutionContext()
+		runtime := &blockingRuntime{release: make(chan struct{})}
+		close(runtime.release)
+
+		job := createJob(t, storage, ctx)
+		claimed, err := storage.ClaimEvaluationJob(ctx, "crashed-replica", time.Second)
+		if err != nil || claimed == nil {
+			t.Fatalf("ClaimEvaluationJob() returned %v, %v", claimed, err)
+		}
+		if err := storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: api.StateRunning}); err != nil {
+			t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+		}
+
+		d := createDispatcher(t, storage, runtime, time.Minute)
+		d.Start()
+		defer d.Stop(context.Background())
+		waitFor(t, "the orphaned job to complete", func() bool {
+			got, err := storage.GetEvaluationJob(ctx, job.ID)
+			return err == nil && got.Status.State == api.StateCompleted
+		})
+	})
+}
+
+func TestDispatcherCancellation(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+	runtime := &blockingRuntime{release: make(chan struct{})}
+	d := createDispatcher(t, storage, runtime, time.Minute)
+
+	job := createJob(t, storage, ctx)
+	d.Start()
+	defer d.Stop(context.Background())
+	waitFor(t, "the job to run", func() bool {
+		running, _ := runtime.counts()
+		return running == 1
+	})
+
+	if err := storage.DeleteEvaluationJob(ctx, job.ID, false); err != nil {
+		t.Fatalf("DeleteEvaluationJob() returned error: %v", err)
+	}
+	waitFor(t, "the runtime to stop", func() bool {
+		running, _ := runtime.counts()
+		return running == 0
+	})
+	got, err := storage.GetEvaluationJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetEvaluationJob() returned error: %v", err)
+	}
+	if got.Status.State != api.StateCancelled {
+		t.Errorf("Expected the job to stay cancelled, got %s", got.Status.State)
+	}
+}
+
+// failingRuntime fails the jobs once they are running
+type failingRuntime struct {
+	calls atomic.Int32
+}
+
+func (r *failingRuntime) GetRuntimeName() string {
+	return "failing"
+}
+
+func (r *failingRuntime) RunEvaluationJob(ctx *executioncontext.ExecutionContext, evaluation *api.EvaluationJobResource, storage abstractions.Storage) error {
+	r.calls.Add(1)
+	if err := storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{State: api.StateRunning}); err != nil {
+		return err
+	}
+	return errors.New("runtime failure")
+}
+
+// statusFailingStorage fails the updates of the jobs to the failed state
+type statusFailingStorage struct {
+	abstractions.Storage
+}
+
+func (s *statusFailingStorage) UpdateEvaluationJobStatus(ctx *executioncontext.ExecutionContext, id string, state api.EvaluationJobState) error {
+	if state.State == api.StateFailed {
+		return errors.New("database unavailable")
+	}
+	return s.Storage.UpdateEvaluationJobStatus(ctx, id, state)
+}
+
+func TestDispatcherStatusUpdateFailure(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+	runtime := &failingRuntime{}
+	d := createDispatcher(t, &statusFailingStorage{Storage: storage}, runtime, time.Second)
+
+	createJob(t, storage, ctx)
+	d.Start()
+	defer d.Stop(context.Background())
+
+	// the lease is left to expire when the failure can not be recorded, so the job is run again
+	waitFor(t, "the job to be claimed again", func() bool {
+		return runtime.calls.Load() >= 2
+	})
+}
+
+func TestDispatcherStop(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+	runtime := &blockingRuntime{release: make(chan struct{})}
+	d := createDispatcher(t, storage, runtime, time.Minute)
+
+	job := createJob(t, storage, ctx)
+	d.Start()
+	waitFor(t, "the job to run", func() bool {
+		running, _ := runtime.counts()
+		return running == 1
+	})
+
+	stopCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.Stop(stopCtx); err == nil {
+		t.Error("Expected an error when the jobs are stopped before they finished")
+	}
+	if running, _ := runtime.counts(); running != 0 {
+		t.Errorf("Expected the running jobs to be stopped, got %d", running)
+	}
+	got, err := storage.GetEvaluationJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetEvaluationJob() returned error: %v", err)
+	}
+	if got.Status.State != api.StateRunning {
+		t.Errorf("Expected the job to stay running, got %s", got.Status.State)
+	}
+	// the lease is kept until it expires so the job is not claimed by another replica yet
+	if claimed, err := storage.ClaimEvaluationJob(ctx, "other-replica", time.Minute); err != nil || claimed != nil {
+		t.Errorf("Expected the job to keep its lease, got %v, %v", claimed, err)
+	}
+}
+
+func TestNewDispatcher(t *testing.T) {
+	if _, err := dispatcher.NewDispatcher(&config.DispatcherConfig{}, "", nil, nil, logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error when the runtime is missing")
+	}
+	if _, err := dispatcher.NewDispatcher(&config.DispatcherConfig{LeaseDuration: time.Millisecond}, "", nil, &blockingRuntime{}, logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error for a lease shorter than a second")
+	}
+}
+
+func createDispatcher(t *testing.T, storage abstractions.Storage, runtime abstractions.Runtime, leaseDuration time.Duration) *dispatcher.Dispatcher {
+	t.Helper()
+	d, err := dispatcher.NewDispatcher(&config.DispatcherConfig{
+		Workers:       2,
+		PollInterval:  20 * time.Millisecond,
+		LeaseDuration: leaseDuration,
+	}, resultsSecret, storage, runtime, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewDispatcher() returned error: %v", err)
+	}
+	return d
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+func createJob(t *testing.T, storage abstractions.Storage, ctx *executioncontext.ExecutionContext) *api.EvaluationJobResource {
+	t.Helper()
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	return job
+}
+
+func createStorage(t *testing.T) abstractions.Storage {
+	t.Helper()
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, nil, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func createExecutionContext() *executioncontext.ExecutionContext {
+	return executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), "", "", "", "", nil, nil, "", "", "", time.Minute, 0, nil, nil, "")
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	// DefaultHistorySize is the number of events kept to resume the streams
+	DefaultHistorySize = 1024
+	// subscriptionBuffer is the number of events a subscriber can fall behind before it is dropped
+	subscriptionBuffer = 64
+)
+
+// EventJob is the type of the events published when an evaluation job is created or changed
+const EventJob = "job"
+
+// Event is a change of an evaluation job, the data is the JSON of the job after the change
+type Event struct {
+	ID     uint64
+	Type   string
+	Tenant api.Tenant
+	JobID  string
+	Data   []byte
+}
+
+// Subscription receives the events that match its filter on C, C is closed when the
+// subscriber falls too far behind so that it can resume from the history
+type Subscription struct {
+	C <-chan Event
+	// LastEventID is the id of the last event published before the subscription
+	LastEventID uint64
+
+	ch     chan Event
+	filter func(event Event) bool
+}
+
+// Bus publishes the changes of the evaluation jobs to the subscribers of this replica. The
+// events are written to the database by the replica that made the change and are read by the
+// storage of every replica, so the event ids are the same on all the replicas. The last events
+// are kept in the history so that a subscriber can resume after the last event it has received.
+type Bus struct {
+	mu     sync.Mutex
+	lastID uint64
+	// since is the id after which all the events are in the history
+	since         uint64
+	history       []Event
+	historySize   int
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewBus returns a bus that keeps the last historySize events
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		historySize:   historySize,
+		subscriptions: map[*Subscription]struct{}{},
+	}
+}
+
+// HistorySize returns the number of events kept in the history
+func (b *Bus) HistorySize() int {
+	return b.historySize
+}
+
+// Seek clears the history and sets the id of the last event before the events that are
+// published next, the storage calls it before publishing the events it has loaded on start
+func (b *Bus) Seek(lastID uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID = lastID
+	b.since = lastID
+	b.history = nil
+}
+
+// Publish publishes the events read from the storage in the order of their ids, the events
+// that are not newer than the last published event are skipped. Publishing to a nil bus does
+// nothing. A subscriber that is too far behind is dropped instead of blocking the storage.
+func (b *Bus) Publish(events ...Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, event := range events {
+		if event.ID <= b.lastID {
+			continue
+		}
+		b.lastID = event.ID
+		b.history = append(b.history, event)
+		if len(b.history) > b.historySize {
+			b.since = b.history[len(b.history)-b.historySize-1].ID
+			b.history = b.history[len(b.history)-b.historySize:]
+		}
+		for subscription := range b.subscriptions {
+			if !subscription.filter(event) {
+				continue
+			}
+			select {
+			case subscription.ch <- event:
+			default:
+				b.remove(subscription)
+			}
+		}
+	}
+}
+
+// Subscribe subscribes to the events that match the filter and returns the events of the
+// history published after lastEventID. complete is false when some of the events published
+// after lastEventID are no longer in the history or have not been read by this replica yet.
+func (b *Bus) Subscribe(lastEventID uint64, filter func(event Event) bool) (subscription *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan Event, subscriptionBuffer)
+	subscription = &Subscription{C: ch, LastEventID: b.lastID, ch: ch, filter: filter}
+	b.subscriptions[subscription] = struct{}{}
+
+	// the ids start at 1 and are not contiguous as the database can skip ids, so the history
+	// is complete when it has all the events after lastEventID
+	complete = lastEventID != 0 && lastEventID >= b.since && lastEventID <= b.lastID
+	if complete {
+		for _, event := range b.history {
+			if event.ID > lastEventID && filter(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+	return subscription, replay, complete
+}
+
+// Unsubscribe stops the events of the subscription
+func (b *Bus) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, found := b.subscriptions[subscription]; found {
+		b.remove(subscription)
+	}
+}
+
+func (b *Bus) remove(subscription *Subscription) {
+	delete(b.subscriptions, subscription)
+	close(subscription.ch)
+}
@@ -0,0 +1,102 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func jobEvent(id uint64, tenant api.Tenant, jobID string) events.Event {
+	return events.Event{ID: id, Type: events.EventJob, Tenant: tenant, JobID: jobID, Data: []byte(`{"id":"` + jobID + `"}`)}
+}
+
+func TestBus(t *testing.T) {
+	bus := events.NewBus(3)
+	teamA := func(event events.Event) bool { return event.Tenant == "team-a" }
+
+	// the events before 10 were loaded by the storage on start
+	bus.Seek(10)
+	subscription, replay, complete := bus.Subscribe(5, teamA)
+	if complete || len(replay) != 0 {
+		t.Errorf("Expected an event id before the history to be incomplete, got %v %v", replay, complete)
+	}
+	if subscription.LastEventID != 10 {
+		t.Errorf("Expected the last event id to be 10, got %d", subscription.LastEventID)
+	}
+	if _, replay, complete := bus.Subscribe(0, teamA); complete || len(replay) != 0 {
+		t.Errorf("Expected no event id to be incomplete, got %v %v", replay, complete)
+	}
+
+	// the database can skip ids
+	bus.Publish(jobEvent(11, "team-a", "job-1"), jobEvent(12, "team-b", "job-2"), jobEvent(14, "team-a", "job-1"))
+
+	t.Run("the subscribers receive the events of the filter", func(t *testing.T) {
+		for _, expected := range []uint64{11, 14} {
+			event := <-subscription.C
+			if event.ID != expected || event.JobID != "job-1" || event.Type != events.EventJob {
+				t.Errorf("Expected the event %d of job-1, got %+v", expected, event)
+			}
+		}
+		select {
+		case event := <-subscription.C:
+			t.Errorf("Expected no other event, got %+v", event)
+		default:
+		}
+	})
+
+	t.Run("the events that have already been published are skipped", func(t *testing.T) {
+		bus.Publish(jobEvent(11, "team-a", "job-1"), jobEvent(14, "team-a", "job-1"))
+		select {
+		case event := <-subscription.C:
+			t.Errorf("Expected no event, got %+v", event)
+		default:
+		}
+	})
+
+	t.Run("the events after the last event id are replayed", func(t *testing.T) {
+		resumed, replay, complete := bus.Subscribe(11, teamA)
+		defer bus.Unsubscribe(resumed)
+		if !complete || len(replay) != 1 || replay[0].ID != 14 {
+			t.Errorf("Expected the event 14 to be replayed, got %+v %v", replay, complete)
+		}
+		resumed, replay, complete = bus.Subscribe(10, teamA)
+		defer bus.Unsubscribe(resumed)
+		if !complete || len(replay) != 2 {
+			t.Errorf("Expected the events 11 and 14 to be replayed, got %+v %v", replay, complete)
+		}
+	})
+
+	t.Run("the events that are no longer in the history are reported", func(t *testing.T) {
+		bus.Publish(jobEvent(15, "team-a", "job-1"))
+		resumed, replay, complete := bus.Subscribe(10, teamA)
+		defer bus.Unsubscribe(resumed)
+		if complete || len(replay) != 0 {
+			t.Errorf("Expected the history to be incomplete, got %+v %v", replay, complete)
+		}
+		resumed, _, complete = bus.Subscribe(1000, teamA)
+		defer bus.Unsubscribe(resumed)
+		if complete {
+			t.Errorf("Expected the events that have not been read yet to be incomplete")
+		}
+	})
+
+	t.Run("the subscribers that fall behind are dropped", func(t *testing.T) {
+		slow, _, _ := bus.Subscribe(0, teamA)
+		for id := range uint64(100) {
+			bus.Publish(jobEvent(100+id, "team-a", "job-1"))
+		}
+		received := 0
+		for range slow.C {
+			received++
+		}
+		if received == 0 || received >= 100 {
+			t.Errorf("Expected the subscription to be closed once its buffer was full, received %d events", received)
+		}
+		bus.Unsubscribe(slow)
+	})
+
+	bus.Unsubscribe(subscription)
+	var nilBus *events.Bus
+	nilBus.Publish(jobEvent(1, "team-a", "job-1"))
+}
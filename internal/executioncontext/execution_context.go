@@ -6,6 +6,9 @@ import (
 	"io"
 	"log/slog"
 	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
 )
 
 // ExecutionContext contains execution context for API operations. This pattern enables
@@ -16,6 +19,8 @@ import (
 //   - Logger: A request-scoped logger with enriched fields (request_id, method, uri, etc.)
 //   - Config: The service configuration
 //   - Evaluation-specific state: model info, timeouts, retries, metadata
+//   - Tenant: The tenant that the request is made for
+//   - Principal: The authenticated caller of the request
 type ExecutionContext struct {
 	Ctx           context.Context
 	RequestID     string
@@ -38,6 +43,16 @@ type ExecutionContext struct {
 	Metadata       map[string]interface{}
 	MLflowClient   interface{}
 	ExperimentName string
+	// Tenant is the tenant of the request, the storage only returns the resources of the
+	// tenant. It is empty for the operations of the service that are not made on behalf
+	// of a tenant, such as the dispatcher and the results reported by the runtimes.
+	Tenant api.Tenant
+	// Principal is the authenticated caller of the request, it is nil when the authentication
+	// is not enabled or the route is public
+	Principal *auth.Principal
+	// ResultsToken is the token that the runtimes pass to the benchmarks of the job to report
+	// the results, it is only set by the dispatcher when the service has a results secret
+	ResultsToken string
 }
 
 func NewExecutionContext(
@@ -82,6 +97,15 @@ func NewExecutionContext(
 	}
 }
 
+// Owner returns the subject of the principal, this is the owner of the resources created by
+// the request. It is empty when the request is not authenticated.
+func (ctx *ExecutionContext) Owner() string {
+	if ctx.Principal == nil {
+		return ""
+	}
+	return ctx.Principal.Subject
+}
+
 func (ctx *ExecutionContext) GetHeader(key string) string {
 	if (ctx.headers != nil) && (ctx.headers[key] != nil) && len(ctx.headers[key]) > 0 {
 		return ctx.headers[key][0]
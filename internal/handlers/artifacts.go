@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// maxArtifactNameLength is the longest name of an artifact
+const maxArtifactNameLength = 255
+
+// HandleUploadArtifact handles POST /api/v1/evaluations/jobs/{id}/artifacts
+//
+// The body is the content of the artifact, the name query parameter is the name of the file and
+// the optional benchmark query parameter is the benchmark of the job the file belongs to. The
+// artifact is stored by its digest, which the result of a benchmark can reference.
+func (h *Handlers) HandleUploadArtifact(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodPost, w) {
+		return
+	}
+	if h.artifactStore == nil {
+		h.errorResponse(ctx, w, "The artifacts are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	id := getPathParam(ctx, evaluationJobsPath)
+	if !h.authorizeJobReport(ctx, w, id) {
+		return
+	}
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := query.Get("name")
+	if name == "" || len(name) > maxArtifactNameLength || strings.ContainsAny(name, "/\\") || strings.ContainsFunc(name, isControl) {
+		h.errorResponse(ctx, w, fmt.Sprintf("The name of the artifact is required and must be a file name of at most %d characters", maxArtifactNameLength), http.StatusBadRequest)
+		return
+	}
+	benchmark := query.Get("benchmark")
+	if contentLength := ctx.GetHeader("Content-Length"); contentLength != "" {
+		if size, err := strconv.ParseInt(contentLength, 10, 64); err == nil && size > h.artifactMaxSize {
+			h.errorResponse(ctx, w, fmt.Sprintf("The artifact is larger than %d bytes", h.artifactMaxSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	if benchmark != "" && len(job.Benchmarks) > 0 && !slices.ContainsFunc(job.Benchmarks, func(b api.BenchmarkConfig) bool { return b.ID == benchmark }) {
+		h.errorResponse(ctx, w, fmt.Sprintf("The benchmark %s is not part of the evaluation job %s", benchmark, id), http.StatusBadRequest)
+		return
+	}
+
+	// a large artifact can take longer to upload than the read timeout of the server
+	controller := http.NewResponseController(w)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ctx.Logger.Warn("Failed to clear the read deadline of the artifact upload", "error", err.Error())
+	}
+
+	// the content is spooled to a temporary file to compute the digest before it is stored
+	file, err := os.CreateTemp("", "eval-hub-artifact-*")
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(ctx.GetBody(), h.artifactMaxSize+1))
+	if err != nil {
+		h.errorResponse(ctx, w, fmt.Sprintf("Failed to read the artifact: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	if size > h.artifactMaxSize {
+		h.errorResponse(ctx, w, fmt.Sprintf("The artifact is larger than %d bytes", h.artifactMaxSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	artifact := &api.ArtifactResource{
+		Digest:      abstractions.ArtifactDigest(hash.Sum(nil)),
+		Name:        name,
+		Benchmark:   benchmark,
+		Size:        size,
+		ContentType: ctx.GetHeader("Content-Type"),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if artifact.ContentType == "" {
+		artifact.ContentType = "application/octet-stream"
+	}
+	if err := h.artifactStore.Put(ctx.Ctx, artifact.Digest, size, file); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	if err := h.storage.AddEvaluationJobArtifact(ctx, id, artifact); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	w.Header().Set("Location", artifactLocation(id, artifact.Digest))
+	h.successResponse(ctx, w, artifact, http.StatusCreated)
+}
+
+// HandleListArtifacts handles GET /api/v1/evaluations/jobs/{id}/artifacts
+func (h *Handlers) HandleListArtifacts(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
+
+	id := getPathParam(ctx, evaluationJobsPath)
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	benchmark := query.Get("benchmark")
+
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	response := &api.ArtifactResourceList{Items: []api.ArtifactResource{}}
+	if job.Results != nil {
+		for _, artifact := range job.Results.Artifacts {
+			if benchmark == "" || artifact.Benchmark == benchmark {
+				response.Items = append(response.Items, artifact)
+			}
+		}
+	}
+	response.TotalCount = len(response.Items)
+
+	h.successResponse(ctx, w, response, http.StatusOK)
+}
+
+// HandleGetArtifact handles GET /api/v1/evaluations/jobs/{id}/artifacts/{digest}
+//
+// The content of an artifact never changes so the digest is the ETag of the response.
+func (h *Handlers) HandleGetArtifact(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
+	if h.artifactStore == nil {
+		h.errorResponse(ctx, w, "The artifacts are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	id := getPathParam(ctx, evaluationJobsPath)
+	digest := getArtifactDigest(ctx)
+
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	// the artifacts are only served for the jobs they have been uploaded for
+	var artifact *api.ArtifactResource
+	if job.Results != nil {
+		if index := slices.IndexFunc(job.Results.Artifacts, func(a api.ArtifactResource) bool { return a.Digest == digest }); index >= 0 {
+			artifact = &job.Results.Artifacts[index]
+		}
+	}
+	if artifact == nil {
+		h.errorResponse(ctx, w, fmt.Sprintf("The artifact %s has not been uploaded for the evaluation job %s", digest, id), http.StatusNotFound)
+		return
+	}
+
+	header := w.Header()
+	etag := strconv.Quote(artifact.Digest)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "private, max-age=31536000, immutable")
+	if ctx.GetHeader("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	content, size, err := h.artifactStore.Get(ctx.Ctx, artifact.Digest)
+	if err != nil {
+		header.Del("ETag")
+		header.Del("Cache-Control")
+		h.storageError(ctx, w, err)
+		return
+	}
+	defer content.Close()
+
+	// a large artifact can take longer to download than the write timeout of the server
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ctx.Logger.Warn("Failed to clear the write deadline of the artifact download", "error", err.Error())
+	}
+	header.Set("Content-Type", artifact.ContentType)
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Name}))
+	header.Set("Content-Length", strconv.FormatInt(size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.CopyN(w, content, size); err != nil {
+		ctx.Logger.Warn("Failed to write the artifact", "digest", artifact.Digest, "error", err.Error())
+	}
+}
+
+// getArtifactDigest returns the digest in /api/v1/evaluations/jobs/{id}/artifacts/{digest}
+func getArtifactDigest(ctx *executioncontext.ExecutionContext) string {
+	_, digest, _ := strings.Cut(ctx.URI, "/artifacts/")
+	return digest
+}
+
+// artifactLocation returns the path the artifact of the job is downloaded from
+func artifactLocation(id string, digest string) string {
+	return evaluationJobsPath + id + "/artifacts/" + digest
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
@@ -0,0 +1,167 @@
+package handlers_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/artifactstore/artifactstore_fs"
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/handlers"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestArtifacts(t *testing.T) {
+	storage := createStorage(t)
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() returned error: %v", err)
+	}
+	artifactStore, err := artifactstore_fs.NewFSArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSArtifactStore() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(storage, validate, handlers.Dependencies{ArtifactStore: artifactStore, ArtifactMaxSize: 16})
+	job := createJob(t, storage)
+
+	request := func(method string, uri string, rawQuery string, headers map[string][]string, body string) *executioncontext.ExecutionContext {
+		return executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), method,
+			uri, "", rawQuery, headers, io.NopCloser(strings.NewReader(body)), "", "", "", time.Minute, 0, nil, nil, "")
+	}
+	upload := func(id string, rawQuery string, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.HandleUploadArtifact(request(http.MethodPost, "/api/v1/evaluations/jobs/"+id+"/artifacts", rawQuery,
+			map[string][]string{"Content-Type": {"text/csv"}}, body), w)
+		return w
+	}
+	download := func(id string, digest string, headers map[string][]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.HandleGetArtifact(request(http.MethodGet, "/api/v1/evaluations/jobs/"+id+"/artifacts/"+digest, "", headers, ""), w)
+		return w
+	}
+
+	content := "accuracy,0.5\n"
+	sum := sha256.Sum256([]byte(content))
+	digest := abstractions.ArtifactDigest(sum[:])
+
+	t.Run("uploads an artifact", func(t *testing.T) {
+		w := upload(job.ID, "name=scores.csv&benchmark=mmlu", content)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		artifact := &api.ArtifactResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), artifact); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if artifact.Digest != digest || artifact.Size != int64(len(content)) || artifact.ContentType != "text/csv" || artifact.Benchmark != "mmlu" {
+			t.Errorf("Unexpected artifact: %+v", artifact)
+		}
+		if location := w.Header().Get("Location"); location != "/api/v1/evaluations/jobs/"+job.ID+"/artifacts/"+digest {
+			t.Errorf("Unexpected location %s", location)
+		}
+	})
+
+	t.Run("invalid uploads", func(t *testing.T) {
+		testCases := []struct {
+			id       string
+			rawQuery string
+			body     string
+			status   int
+		}{
+			{job.ID, "", content, http.StatusBadRequest},
+			{job.ID, "name=../scores.csv", content, http.StatusBadRequest},
+			{job.ID, "name=scores.csv&benchmark=arc", content, http.StatusBadRequest},
+			{job.ID, "name=large.bin", strings.Repeat("x", 17), http.StatusRequestEntityTooLarge},
+			{"unknown", "name=scores.csv", content, http.StatusNotFound},
+		}
+		for _, tc := range testCases {
+			if w := upload(tc.id, tc.rawQuery, tc.body); w.Code != tc.status {
+				t.Errorf("Expected status %d for %s, got %d", tc.status, tc.rawQuery, w.Code)
+			}
+		}
+	})
+
+	t.Run("the results token of another job is rejected", func(t *testing.T) {
+		ctx := request(http.MethodPost, "/api/v1/evaluations/jobs/"+job.ID+"/artifacts", "name=scores.csv", nil, content)
+		ctx.Principal = &auth.Principal{Subject: "another-job", Method: auth.MethodResultsToken}
+		w := httptest.NewRecorder()
+		h.HandleUploadArtifact(ctx, w)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("lists the artifacts of the job", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.HandleListArtifacts(request(http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID+"/artifacts", "benchmark=mmlu", nil, ""), w)
+		list := &api.ArtifactResourceList{}
+		if err := json.Unmarshal(w.Body.Bytes(), list); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if list.TotalCount != 1 || list.Items[0].Name != "scores.csv" {
+			t.Errorf("Unexpected artifacts: %+v", list)
+		}
+	})
+
+	t.Run("downloads an artifact", func(t *testing.T) {
+		w := download(job.ID, digest, nil)
+		if w.Code != http.StatusOK || w.Body.String() != content {
+			t.Fatalf("Expected status %d and the content, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "text/csv" || w.Header().Get("Content-Disposition") != "attachment; filename=scores.csv" {
+			t.Errorf("Unexpected headers: %v", w.Header())
+		}
+		if w := download(job.ID, digest, map[string][]string{"If-None-Match": {w.Header().Get("ETag")}}); w.Code != http.StatusNotModified {
+			t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+		}
+	})
+
+	t.Run("an artifact is only served for the jobs it was uploaded for", func(t *testing.T) {
+		other := createJob(t, storage)
+		if w := download(other.ID, digest, nil); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("the results reference the uploaded artifacts", func(t *testing.T) {
+		submit := func(body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			h.HandleSubmitEvaluationResults(request(http.MethodPost, "/api/v1/evaluations/jobs/"+job.ID+"/results", "", nil, body), w)
+			return w
+		}
+		unknown := sha256.Sum256([]byte("unknown"))
+		if w := submit(`{"benchmarks":[{"name":"mmlu","artifacts":{"scores":"` + abstractions.ArtifactDigest(unknown[:]) + `"}}]}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for an artifact that was not uploaded, got %d", http.StatusBadRequest, w.Code)
+		}
+		w := submit(`{"benchmarks":[{"name":"mmlu","artifacts":{"scores":"` + digest + `","report":"s3://bucket/report.html"}}]}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.EvaluationJobResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.Results.Benchmarks[0].Artifacts["scores"] != digest || len(got.Results.Artifacts) != 1 {
+			t.Errorf("Unexpected results: %+v", got.Results)
+		}
+	})
+
+	t.Run("the artifacts are not enabled", func(t *testing.T) {
+		h := handlers.New(storage, nil)
+		w := httptest.NewRecorder()
+		h.HandleUploadArtifact(request(http.MethodPost, "/api/v1/evaluations/jobs/"+job.ID+"/artifacts", "name=scores.csv", nil, content), w)
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status %d, got %d", http.StatusNotImplemented, w.Code)
+		}
+	})
+}
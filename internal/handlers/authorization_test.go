@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/handlers"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestAuthorization(t *testing.T) {
+	storage := createStorage(t)
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() returned error: %v", err)
+	}
+	providerCatalog, err := catalog.NewCatalog(nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewCatalog() returned error: %v", err)
+	}
+	authorizer, err := authz.NewAuthorizer(&config.AuthorizationConfig{Enabled: true}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewAuthorizer() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(storage, validate, handlers.Dependencies{Catalog: providerCatalog, Authorizer: authorizer})
+
+	principal := func(subject string, roles ...any) *auth.Principal {
+		return &auth.Principal{Subject: subject, Method: auth.MethodJWT, Claims: map[string]any{"roles": roles}}
+	}
+	alice := principal("alice", "submitter")
+	bob := principal("bob", "submitter")
+	viewer := principal("victor")
+	admin := principal("carol", "collection-admin")
+
+	call := func(principal *auth.Principal, method string, uri string, body string, handle func(*executioncontext.ExecutionContext, http.ResponseWriter)) *httptest.ResponseRecorder {
+		ctx := executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), method,
+			uri, "", "", nil, io.NopCloser(strings.NewReader(body)), "", "", "", time.Minute, 0, nil, nil, "")
+		ctx.Principal = principal
+		w := httptest.NewRecorder()
+		handle(ctx, w)
+		return w
+	}
+	expectForbidden := func(t *testing.T, w *httptest.ResponseRecorder) {
+		t.Helper()
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusForbidden, w.Code, w.Body.String())
+		}
+		body := struct {
+			Error string `json:"error"`
+			Code  int    `json:"code"`
+		}{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != http.StatusForbidden || body.Error == "" {
+			t.Errorf("Expected the JSON error, got %s", w.Body.String())
+		}
+	}
+
+	t.Run("evaluation jobs", func(t *testing.T) {
+		jobs := "/api/v1/evaluations/jobs"
+		body := `{"model":{"url":"http://localhost:8000","name":"test-model"}}`
+		expectForbidden(t, call(viewer, http.MethodPost, jobs, body, h.HandleCreateEvaluation))
+
+		w := call(alice, http.MethodPost, jobs, body, h.HandleCreateEvaluation)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		job := &api.EvaluationJobResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), job); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if job.Owner != "alice" {
+			t.Errorf("Expected the owner to be alice, got %q", job.Owner)
+		}
+
+		uri := jobs + "/" + job.ID
+		if w := call(viewer, http.MethodGet, uri, "", h.HandleGetEvaluation); w.Code != http.StatusOK {
+			t.Errorf("Expected the viewer to read the job, got %d", w.Code)
+		}
+		expectForbidden(t, call(bob, http.MethodDelete, uri, "", h.HandleCancelEvaluation))
+		if w := call(alice, http.MethodDelete, uri, "", h.HandleCancelEvaluation); w.Code != http.StatusOK {
+			t.Errorf("Expected the owner to cancel the job, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("collections", func(t *testing.T) {
+		collections := "/api/v1/evaluations/collections"
+		w := call(bob, http.MethodPost, collections, `{"name":"shared","benchmarks":[{"id":"mmlu"}]}`, h.HandleCreateCollection)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		collection := &api.CollectionResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), collection); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		uri := collections + "/" + collection.ID
+
+		expectForbidden(t, call(alice, http.MethodPut, uri, `{"name":"renamed","benchmarks":[{"id":"mmlu"}]}`, h.HandleUpdateCollection))
+		expectForbidden(t, call(alice, http.MethodPatch, uri, `[{"op":"replace","path":"/name","value":"renamed"}]`, h.HandlePatchCollection))
+		expectForbidden(t, call(alice, http.MethodDelete, uri, "", h.HandleDeleteCollection))
+		if w := call(bob, http.MethodPatch, uri, `[{"op":"replace","path":"/owner","value":"alice"}]`, h.HandlePatchCollection); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected the owner to be immutable, got %d", w.Code)
+		}
+		if w := call(admin, http.MethodDelete, uri, "", h.HandleDeleteCollection); w.Code != http.StatusNoContent {
+			t.Errorf("Expected the collection admin to delete the shared collection, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("system metrics", func(t *testing.T) {
+		expectForbidden(t, call(alice, http.MethodGet, "/api/v1/metrics/system", "", h.HandleGetSystemMetrics))
+		if w := call(principal("root", "system-admin"), http.MethodGet, "/api/v1/metrics/system", "", h.HandleGetSystemMetrics); w.Code != http.StatusOK {
+			t.Errorf("Expected the system admin to read the system metrics, got %d", w.Code)
+		}
+	})
+
+	t.Run("requests without a principal", func(t *testing.T) {
+		if w := call(nil, http.MethodGet, "/api/v1/evaluations/providers", "", h.HandleListProviders); w.Code != http.StatusOK {
+			t.Errorf("Expected the public requests to not be checked, got %d", w.Code)
+		}
+	})
+}
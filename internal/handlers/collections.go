@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/patch"
+	"github.com/julpayne/eval-hub-backend-svc/internal/serialization"
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const collectionsPath = "/api/v1/evaluations/collections/"
+
+// HandleListCollections handles GET /api/v1/evaluations/collections
+func (h *Handlers) HandleListCollections(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.CollectionsRead, "") {
+		return
+	}
+
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := getPageQuery(query)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.storage.GetCollections(ctx, limit, offset)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, response, http.StatusOK)
+}
+
+// HandleCreateCollection handles POST /api/v1/evaluations/collections
+func (h *Handlers) HandleCreateCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodPost, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.CollectionsCreate, "") {
+		return
+	}
+
+	collection := &api.CollectionResource{}
+	if !h.readCollectionConfig(ctx, w, &collection.CollectionConfig) {
+		return
+	}
+	if err := h.storage.CreateCollection(ctx, collection); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, collection, http.StatusCreated)
+}
+
+// HandleGetCollection handles GET /api/v1/evaluations/collections/{collection_id}
+func (h *Handlers) HandleGetCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.CollectionsRead, "") {
+		return
+	}
+
+	id := getPathParam(ctx, collectionsPath)
+	collection, err := h.storage.GetCollection(ctx, id, false)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, collection, http.StatusOK)
+}
+
+// HandleUpdateCollection handles PUT /api/v1/evaluations/collections/{collection_id}
+func (h *Handlers) HandleUpdateCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodPut, w) {
+		return
+	}
+
+	collection := &api.CollectionResource{Resource: api.Resource{ID: getPathParam(ctx, collectionsPath)}}
+	if !h.authorizeCollection(ctx, w, collection.ID, authz.CollectionsUpdate) {
+		return
+	}
+	if !h.readCollectionConfig(ctx, w, &collection.CollectionConfig) {
+		return
+	}
+	if err := h.storage.UpdateCollection(ctx, collection); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, collection, http.StatusOK)
+}
+
+// HandlePatchCollection handles PATCH /api/v1/evaluations/collections/{collection_id}, the body
+// is a JSON patch (RFC 6902) and the patched collection is validated like a new collection
+func (h *Handlers) HandlePatchCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodPatch, w) {
+		return
+	}
+
+	bodyBytes, err := ctx.GetBodyAsBytes()
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	operations := api.Patch{}
+	if err := json.Unmarshal(bodyBytes, &operations); err != nil {
+		h.validationErrorResponse(ctx, w, validation.ValidationErrors(err))
+		return
+	}
+
+	id := getPathParam(ctx, collectionsPath)
+	if !h.authorizeCollection(ctx, w, id, authz.CollectionsUpdate) {
+		return
+	}
+	collection, err := h.storage.PatchCollection(ctx, id, operations, func(collection *api.CollectionResource) error {
+		if err := h.validate.StructCtx(ctx.Ctx, &collection.CollectionConfig); err != nil {
+			return invalidResourceError(validation.ValidationErrors(err))
+		}
+		if details := h.catalog.ResolveBenchmarks(collection.Benchmarks); len(details) > 0 {
+			return invalidResourceError(details)
+		}
+		return nil
+	})
+	if err != nil {
+		h.patchError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, collection, http.StatusOK)
+}
+
+// HandleDeleteCollection handles DELETE /api/v1/evaluations/collections/{collection_id}
+func (h *Handlers) HandleDeleteCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodDelete, w) {
+		return
+	}
+
+	id := getPathParam(ctx, collectionsPath)
+	if !h.authorizeCollection(ctx, w, id, authz.CollectionsDelete) {
+		return
+	}
+	if err := h.storage.DeleteCollection(ctx, id); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logging.LogRequestSuccess(ctx, http.StatusNoContent, nil)
+}
+
+// authorizeCollection checks the permission that depends on the owner of the collection, the
+// owner can not be changed so the collection is read before it is updated or deleted
+func (h *Handlers) authorizeCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, id string, permission authz.Permission) bool {
+	if h.authorizer == nil || ctx.Principal == nil {
+		return true
+	}
+	collection, err := h.storage.GetCollection(ctx, id, true)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return false
+	}
+	return h.authorize(ctx, w, permission, collection.Owner)
+}
+
+// readCollectionConfig reads and validates the collection in the request body, the benchmarks
+// must be in the catalog. The validation errors are written to the response and false is returned.
+func (h *Handlers) readCollectionConfig(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, config *api.CollectionConfig) bool {
+	bodyBytes, err := ctx.GetBodyAsBytes()
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if err := serialization.Unmarshal(h.validate, ctx, bodyBytes, config); err != nil {
+		h.validationErrorResponse(ctx, w, validation.ValidationErrors(err))
+		return false
+	}
+	if details := h.catalog.ResolveBenchmarks(config.Benchmarks); len(details) > 0 {
+		h.validationErrorResponse(ctx, w, details)
+		return false
+	}
+	return true
+}
+
+// invalidResourceError carries the validation errors of a patched resource out of the storage update
+type invalidResourceError []api.ValidationError
+
+func (e invalidResourceError) Error() string {
+	return e[0].Msg
+}
+
+// patchError maps the errors of a patch, a failed test is a conflict with the current state of
+// the resource and the other errors of the patch are validation errors
+func (h *Handlers) patchError(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, err error) {
+	var patchError *patch.Error
+	var invalidResource invalidResourceError
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		h.errorResponse(ctx, w, err.Error(), http.StatusConflict)
+	case errors.As(err, &patchError):
+		h.validationErrorResponse(ctx, w, []api.ValidationError{patchError.ValidationError()})
+	case errors.Is(err, patch.ErrInvalidResult):
+		h.validationErrorResponse(ctx, w, []api.ValidationError{{Loc: []any{validation.BodyLoc}, Msg: err.Error(), Type: "value_error.patch.invalid_result"}})
+	case errors.As(err, &invalidResource):
+		h.validationErrorResponse(ctx, w, invalidResource)
+	default:
+		h.storageError(ctx, w, err)
+	}
+}
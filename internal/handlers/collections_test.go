@@ -0,0 +1,170 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/handlers"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestHandleCollections(t *testing.T) {
+	storage := createStorage(t)
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() returned error: %v", err)
+	}
+	providerCatalog, err := catalog.NewCatalog(nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewCatalog() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(storage, validate, handlers.Dependencies{Catalog: providerCatalog})
+
+	call := func(method string, uri string, body string, handle func(*executioncontext.ExecutionContext, http.ResponseWriter)) *httptest.ResponseRecorder {
+		ctx := executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), method,
+			uri, "", "", nil, io.NopCloser(strings.NewReader(body)), "", "", "", time.Minute, 0, nil, nil, "")
+		w := httptest.NewRecorder()
+		handle(ctx, w)
+		return w
+	}
+	create := func(body string) *httptest.ResponseRecorder {
+		return call(http.MethodPost, "/api/v1/evaluations/collections", body, h.HandleCreateCollection)
+	}
+
+	w := create(`{"name":"reasoning","benchmarks":[{"id":"arc_challenge","parameters":{"num_fewshot":25}},{"id":"hellaswag"}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	created := &api.CollectionResource{}
+	if err := json.Unmarshal(w.Body.Bytes(), created); err != nil {
+		t.Fatalf("Failed to unmarshal the response: %v", err)
+	}
+	if created.ID == "" || created.Benchmarks[0].ProviderID != "lm_evaluation_harness" {
+		t.Errorf("Expected the id and the provider of the benchmarks to be set, got %+v", created)
+	}
+	uri := "/api/v1/evaluations/collections/" + created.ID
+
+	t.Run("duplicate name", func(t *testing.T) {
+		if w := create(`{"name":"reasoning","benchmarks":[{"id":"mmlu"}]}`); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("invalid benchmark", func(t *testing.T) {
+		if w := create(`{"name":"invalid","benchmarks":[{"id":"unknown"}]}`); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := call(http.MethodGet, "/api/v1/evaluations/collections", "", h.HandleListCollections)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.CollectionResourceList{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.TotalCount != 1 || len(got.Items) != 1 || got.First == nil {
+			t.Errorf("Expected a page with the collection, got %+v", got)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		w := call(http.MethodPut, uri, `{"name":"reasoning-v2","benchmarks":[{"id":"winogrande"}]}`, h.HandleUpdateCollection)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		w = call(http.MethodGet, uri, "", h.HandleGetCollection)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.CollectionResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.Name != "reasoning-v2" || len(got.Benchmarks) != 1 || got.Benchmarks[0].ID != "winogrande" {
+			t.Errorf("Expected the updated collection, got %+v", got)
+		}
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		patchCollection := func(body string) *httptest.ResponseRecorder {
+			return call(http.MethodPatch, uri, body, h.HandlePatchCollection)
+		}
+
+		w := patchCollection(`[
+			{"op":"test","path":"/name","value":"reasoning-v2"},
+			{"op":"add","path":"/benchmarks/-","value":{"id":"gsm8k","parameters":{"num_fewshot":5}}},
+			{"op":"add","path":"/description","value":"Reasoning and math"}
+		]`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.CollectionResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if len(got.Benchmarks) != 2 || got.Benchmarks[1].ProviderID != "lm_evaluation_harness" || got.Description == nil {
+			t.Errorf("Expected the patched collection, got %+v", got)
+		}
+
+		tests := []struct {
+			name string
+			body string
+			code int
+		}{
+			{"failed test", `[{"op":"test","path":"/name","value":"reasoning"},{"op":"remove","path":"/description"}]`, http.StatusConflict},
+			{"immutable field", `[{"op":"replace","path":"/id","value":"other"}]`, http.StatusUnprocessableEntity},
+			{"missing path", `[{"op":"remove","path":"/benchmarks/5"}]`, http.StatusUnprocessableEntity},
+			{"invalid result", `[{"op":"remove","path":"/name"}]`, http.StatusUnprocessableEntity},
+			{"unknown field", `[{"op":"add","path":"/owner","value":"me"}]`, http.StatusUnprocessableEntity},
+			{"invalid parameters", `[{"op":"replace","path":"/benchmarks/1/parameters/num_fewshot","value":"five"}]`, http.StatusUnprocessableEntity},
+			{"invalid JSON", `{"op":"remove"}`, http.StatusUnprocessableEntity},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if w := patchCollection(tt.body); w.Code != tt.code {
+					t.Errorf("Expected status %d, got %d: %s", tt.code, w.Code, w.Body.String())
+				}
+			})
+		}
+
+		w = call(http.MethodGet, uri, "", h.HandleGetCollection)
+		unchanged := &api.CollectionResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), unchanged); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if unchanged.ID != created.ID || unchanged.Description == nil || len(unchanged.Benchmarks) != 2 {
+			t.Errorf("Expected the failed patches to leave the collection unchanged, got %+v", unchanged)
+		}
+		if w := call(http.MethodPatch, "/api/v1/evaluations/collections/unknown", `[]`, h.HandlePatchCollection); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if w := call(http.MethodDelete, uri, "", h.HandleDeleteCollection); w.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+		if w := call(http.MethodGet, uri, "", h.HandleGetCollection); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := call(http.MethodPut, uri, `{"name":"reasoning","benchmarks":[{"id":"mmlu"}]}`, h.HandleUpdateCollection); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := call(http.MethodDelete, uri, "", h.HandleDeleteCollection); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
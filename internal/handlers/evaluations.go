@@ -1,15 +1,28 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/collections"
 	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
 	"github.com/julpayne/eval-hub-backend-svc/internal/serialization"
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
 	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
 )
 
+const (
+	evaluationJobsPath = "/api/v1/evaluations/jobs/"
+	providersPath      = "/api/v1/evaluations/providers/"
+)
+
 // BackendSpec represents the backend specification
 type BackendSpec struct {
 	URL  string `json:"url"`
@@ -28,6 +41,9 @@ func (h *Handlers) HandleCreateEvaluation(ctx *executioncontext.ExecutionContext
 	if !h.checkMethod(ctx, http.MethodPost, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.EvaluationsCreate, "") {
+		return
+	}
 	// get the body bytes from the context
 	bodyBytes, err := ctx.GetBodyAsBytes()
 	if err != nil {
@@ -37,16 +53,39 @@ func (h *Handlers) HandleCreateEvaluation(ctx *executioncontext.ExecutionContext
 	evaluation := &api.EvaluationJobConfig{}
 	err = serialization.Unmarshal(h.validate, ctx, bodyBytes, evaluation)
 	if err != nil {
-		h.serializationError(ctx, w, err, http.StatusBadRequest)
+		h.validationErrorResponse(ctx, w, validation.ValidationErrors(err))
+		return
+	}
+	// the benchmarks of the collection are copied into the job with the overrides of the request
+	if evaluation.Collection.ID != "" {
+		collection, err := h.storage.GetCollection(ctx, evaluation.Collection.ID, false)
+		if errors.Is(err, abstractions.ErrNotFound) {
+			h.validationErrorResponse(ctx, w, []api.ValidationError{{
+				Loc:  []any{validation.BodyLoc, "collection", "id"},
+				Msg:  fmt.Sprintf("unknown collection %s", evaluation.Collection.ID),
+				Type: "value_error.unknown_collection",
+			}})
+			return
+		}
+		if err != nil {
+			h.storageError(ctx, w, err)
+			return
+		}
+		evaluation.Benchmarks = collections.Expand(collection, evaluation.Benchmarks)
+	}
+	// the benchmarks must be in the catalog and their parameters must match the catalog schemas
+	if details := h.catalog.ResolveBenchmarks(evaluation.Benchmarks); len(details) > 0 {
+		h.validationErrorResponse(ctx, w, details)
 		return
 	}
 
 	response, err := h.storage.CreateEvaluationJob(ctx, evaluation)
 	if err != nil {
-		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		h.storageError(ctx, w, err)
 		return
 	}
 
+	// the job is pending until it is claimed by the dispatcher of one of the replicas
 	h.successResponse(ctx, w, response, http.StatusAccepted)
 }
 
@@ -55,15 +94,34 @@ func (h *Handlers) HandleListEvaluations(ctx *executioncontext.ExecutionContext,
 	if !h.checkMethod(ctx, http.MethodGet, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"items":       []interface{}{},
-		"total_count": 0,
-		"limit":       50,
-		"first":       map[string]string{"href": ""},
-		"next":        nil,
-	})
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := getPageQuery(query)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	summary, err := getQueryBool(query, "summary", false)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	statusFilter := query.Get("status_filter")
+
+	response, err := h.storage.GetEvaluationJobs(ctx, summary, limit, offset, statusFilter)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, response, http.StatusOK)
 }
 
 // HandleGetEvaluation handles GET /api/v1/evaluations/jobs/{id}
@@ -71,16 +129,20 @@ func (h *Handlers) HandleGetEvaluation(ctx *executioncontext.ExecutionContext, w
 	if !h.checkMethod(ctx, http.MethodGet, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
 
 	// Extract ID from path
-	pathParts := strings.Split(ctx.URI, "/")
-	id := pathParts[len(pathParts)-1]
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation retrieval not yet implemented",
-		"id":      id,
-	})
+	id := getPathParam(ctx, evaluationJobsPath)
+
+	response, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, response, http.StatusOK)
 }
 
 // HandleCancelEvaluation handles DELETE /api/v1/evaluations/jobs/{id}
@@ -89,117 +151,179 @@ func (h *Handlers) HandleCancelEvaluation(ctx *executioncontext.ExecutionContext
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation cancellation not yet implemented",
-	})
-}
+	id := getPathParam(ctx, evaluationJobsPath)
 
-// HandleGetEvaluationSummary handles GET /api/v1/evaluations/jobs/{id}/summary
-func (h *Handlers) HandleGetEvaluationSummary(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
-	if !h.checkMethod(ctx, http.MethodGet, w) {
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hardDelete, err := getQueryBool(query, "hard_delete", false)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Evaluation summary not yet implemented",
-	})
-}
-
-// HandleListBenchmarks handles GET /api/v1/evaluations/benchmarks
-func (h *Handlers) HandleListBenchmarks(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
-	if !h.checkMethod(ctx, http.MethodGet, w) {
+	// the jobs submitted by other principals can only be cancelled with evaluations:cancel:any
+	if !h.authorizeEvaluation(ctx, w, id, authz.EvaluationsCancel) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"benchmarks":         []interface{}{},
-		"total_count":        0,
-		"providers_included": []string{},
-	})
-}
+	// the runtime running the job is stopped by the dispatcher when it sees that
+	// the job has been cancelled or deleted
+	if err := h.storage.DeleteEvaluationJob(ctx, id, hardDelete); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	if hardDelete {
+		w.WriteHeader(http.StatusNoContent)
+		logging.LogRequestSuccess(ctx, http.StatusNoContent, nil)
+		return
+	}
 
-// HandleListCollections handles GET /api/v1/evaluations/collections
-func (h *Handlers) HandleListCollections(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
-	if !h.checkMethod(ctx, http.MethodGet, w) {
+	response, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"collections":       []interface{}{},
-		"total_collections": 0,
-	})
+	h.successResponse(ctx, w, response, http.StatusOK)
 }
 
-// HandleCreateCollection handles POST /api/v1/evaluations/collections
-func (h *Handlers) HandleCreateCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+// authorizeEvaluation checks the permission that depends on the owner of the evaluation job,
+// the owner can not be changed so the job is read before it is cancelled or deleted
+func (h *Handlers) authorizeEvaluation(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, id string, permission authz.Permission) bool {
+	if h.authorizer == nil || ctx.Principal == nil {
+		return true
+	}
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return false
+	}
+	return h.authorize(ctx, w, permission, job.Owner)
+}
+
+// HandleSubmitEvaluationResults handles POST /api/v1/evaluations/jobs/{id}/results
+func (h *Handlers) HandleSubmitEvaluationResults(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
 	if !h.checkMethod(ctx, http.MethodPost, w) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection creation not yet implemented",
-	})
+	id := getPathParam(ctx, evaluationJobsPath)
+	if !h.authorizeJobReport(ctx, w, id) {
+		return
+	}
+
+	bodyBytes, err := ctx.GetBodyAsBytes()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	results := &api.EvaluationJobResultsConfig{}
+	err = serialization.Unmarshal(h.validate, ctx, bodyBytes, results)
+	if err != nil {
+		h.serializationError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	// the results are recorded in MLflow in the background once they have been stored
+	if err := h.storage.UpsertEvaluationJobResults(ctx, id, results); err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	response, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+
+	h.successResponse(ctx, w, response, http.StatusOK)
 }
 
-// HandleGetCollection handles GET /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandleGetCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+// HandleGetEvaluationSummary handles GET /api/v1/evaluations/jobs/{id}/summary
+func (h *Handlers) HandleGetEvaluationSummary(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
 	if !h.checkMethod(ctx, http.MethodGet, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
 
-	// Extract collection_id from path
-	pathParts := strings.Split(ctx.URI, "/")
-	collectionID := pathParts[len(pathParts)-1]
+	id := getPathParam(ctx, evaluationJobsPath)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":       "Collection retrieval not yet implemented",
-		"collection_id": collectionID,
-	})
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = summaryFormatJSON
+	}
+	if !isSummaryFormat(format) {
+		h.errorResponse(ctx, w, fmt.Sprintf("query parameter format must be one of %s", strings.Join(summaryFormats, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	summary := h.aggregator.Summarize(job, time.Now().UTC())
+
+	switch format {
+	case summaryFormatMarkdown:
+		h.textResponse(ctx, w, "text/markdown; charset=utf-8", renderSummaryMarkdown(summary), http.StatusOK)
+	case summaryFormatCSV:
+		h.textResponse(ctx, w, "text/csv; charset=utf-8", renderSummaryCSV(summary), http.StatusOK)
+	default:
+		h.successResponse(ctx, w, summary, http.StatusOK)
+	}
 }
 
-// HandleUpdateCollection handles PUT /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandleUpdateCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
-	if !h.checkMethod(ctx, http.MethodPut, w) {
+// HandleListEvaluationDeliveries handles GET /api/v1/evaluations/jobs/{id}/deliveries
+func (h *Handlers) HandleListEvaluationDeliveries(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection update not yet implemented",
-	})
-}
+	id := getPathParam(ctx, evaluationJobsPath)
 
-// HandlePatchCollection handles PATCH /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandlePatchCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
-	if !h.checkMethod(ctx, http.MethodPatch, w) {
+	response, err := h.storage.GetWebhookDeliveries(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection patch not yet implemented",
-	})
+	h.successResponse(ctx, w, response, http.StatusOK)
 }
 
-// HandleDeleteCollection handles DELETE /api/v1/evaluations/collections/{collection_id}
-func (h *Handlers) HandleDeleteCollection(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
-	if !h.checkMethod(ctx, http.MethodDelete, w) {
+// HandleListBenchmarks handles GET /api/v1/evaluations/benchmarks
+func (h *Handlers) HandleListBenchmarks(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.CatalogRead, "") {
+		return
+	}
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Collection deletion not yet implemented",
-	})
+	filter := catalog.BenchmarkFilter{
+		ProviderID: query.Get("provider_id"),
+		Category:   query.Get("category"),
+		Tags:       getQueryList(query, "tags"),
+	}
+	h.successResponse(ctx, w, h.catalog.GetBenchmarks(filter), http.StatusOK)
 }
 
 // HandleListProviders handles GET /api/v1/evaluations/providers
@@ -207,13 +331,11 @@ func (h *Handlers) HandleListProviders(ctx *executioncontext.ExecutionContext, w
 	if !h.checkMethod(ctx, http.MethodGet, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.CatalogRead, "") {
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"providers":        []interface{}{},
-		"total_providers":  0,
-		"total_benchmarks": 0,
-	})
+	h.successResponse(ctx, w, h.catalog.GetProviders(), http.StatusOK)
 }
 
 // HandleGetProvider handles GET /api/v1/evaluations/providers/{provider_id}
@@ -221,14 +343,15 @@ func (h *Handlers) HandleGetProvider(ctx *executioncontext.ExecutionContext, w h
 	if !h.checkMethod(ctx, http.MethodGet, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.CatalogRead, "") {
+		return
+	}
 
-	// Extract provider_id from path
-	pathParts := strings.Split(ctx.URI, "/")
-	providerID := pathParts[len(pathParts)-1]
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":     "Provider retrieval not yet implemented",
-		"provider_id": providerID,
-	})
+	providerID := getPathParam(ctx, providersPath)
+	provider, err := h.catalog.GetProvider(providerID)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	h.successResponse(ctx, w, provider, http.StatusOK)
 }
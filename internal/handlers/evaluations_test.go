@@ -0,0 +1,424 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/handlers"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestHandleCancelEvaluation(t *testing.T) {
+	storage := createStorage(t)
+	h := handlers.New(storage, nil)
+
+	cancel := func(id string, rawQuery string) *httptest.ResponseRecorder {
+		ctx := createExecutionContext(http.MethodDelete, "/api/v1/evaluations/jobs/"+id)
+		ctx.Logger = logging.FallbackLogger()
+		ctx.RawQuery = rawQuery
+		w := httptest.NewRecorder()
+		h.HandleCancelEvaluation(ctx, w)
+		return w
+	}
+
+	t.Run("cancels the job and its unfinished benchmarks", func(t *testing.T) {
+		job := createJob(t, storage)
+		ctx := createExecutionContext(http.MethodDelete, "")
+		if err := storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}); err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+
+		w := cancel(job.ID, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.EvaluationJobResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.Status.State != api.StateCancelled || got.Status.Benchmarks[0].State != api.StateCancelled {
+			t.Errorf("Expected the job and the benchmark to be cancelled: %+v", got.Status)
+		}
+
+		if w := cancel(job.ID, ""); w.Code != http.StatusOK {
+			t.Errorf("Expected cancelling twice to return %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("finished jobs can not be cancelled", func(t *testing.T) {
+		job := createJob(t, storage)
+		ctx := createExecutionContext(http.MethodDelete, "")
+		if err := storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: api.StateCompleted}); err != nil {
+			t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+		}
+		if w := cancel(job.ID, ""); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("hard delete removes the job", func(t *testing.T) {
+		job := createJob(t, storage)
+		if w := cancel(job.ID, "hard_delete=true"); w.Code != http.StatusNoContent {
+			t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if w := cancel(job.ID, "hard_delete=true"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("unknown jobs and invalid parameters", func(t *testing.T) {
+		if w := cancel("unknown", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := cancel("unknown", "hard_delete=maybe"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestHandleSubmitEvaluationResults(t *testing.T) {
+	storage := createStorage(t)
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() returned error: %v", err)
+	}
+	h := handlers.New(storage, validate)
+	job := createJob(t, storage)
+
+	submit := func(id string, body string) *httptest.ResponseRecorder {
+		ctx := executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), http.MethodPost,
+			"/api/v1/evaluations/jobs/"+id+"/results", "", "", nil, io.NopCloser(strings.NewReader(body)), "", "", "", time.Minute, 0, nil, nil, "")
+		w := httptest.NewRecorder()
+		h.HandleSubmitEvaluationResults(ctx, w)
+		return w
+	}
+
+	t.Run("stores the results", func(t *testing.T) {
+		w := submit(job.ID, `{"benchmarks":[{"name":"mmlu","metrics":{"acc":0.5}}],"aggregated_metrics":{"acc":0.5}}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.EvaluationJobResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.Results == nil || got.Results.CompletedEvaluations != 1 || got.Results.AggregatedMetrics["acc"] != 0.5 {
+			t.Errorf("Unexpected results: %+v", got.Results)
+		}
+	})
+
+	t.Run("invalid payloads", func(t *testing.T) {
+		testCases := []struct {
+			id     string
+			body   string
+			status int
+		}{
+			{job.ID, `{"benchmarks":[{"metrics":{"acc":0.5}}]}`, http.StatusBadRequest},
+			{job.ID, `{"benchmarks":[{"name":"arc"}]}`, http.StatusBadRequest},
+			{job.ID, `not json`, http.StatusBadRequest},
+			{"unknown", `{"benchmarks":[{"name":"mmlu"}]}`, http.StatusNotFound},
+		}
+		for _, tc := range testCases {
+			if w := submit(tc.id, tc.body); w.Code != tc.status {
+				t.Errorf("Expected status %d for %s, got %d", tc.status, tc.body, w.Code)
+			}
+		}
+	})
+}
+
+func TestHandleGetEvaluationSummary(t *testing.T) {
+	storage := createStorage(t)
+	aggregator, err := aggregation.NewAggregator(nil)
+	if err != nil {
+		t.Fatalf("NewAggregator() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(storage, nil, handlers.Dependencies{Aggregator: aggregator})
+	job := createJob(t, storage)
+	ctx := createExecutionContext(http.MethodGet, "")
+	if err := storage.UpsertEvaluationJobResults(ctx, job.ID, &api.EvaluationJobResultsConfig{
+		Benchmarks: []api.BenchmarkResultConfig{{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}}},
+	}); err != nil {
+		t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+	}
+	if err := storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted}); err != nil {
+		t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+	}
+
+	summarize := func(id string, rawQuery string) *httptest.ResponseRecorder {
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/jobs/"+id+"/summary")
+		ctx.Logger = logging.FallbackLogger()
+		ctx.RawQuery = rawQuery
+		w := httptest.NewRecorder()
+		h.HandleGetEvaluationSummary(ctx, w)
+		return w
+	}
+
+	t.Run("json", func(t *testing.T) {
+		w := summarize(job.ID, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		summary := &api.EvaluationJobSummary{}
+		if err := json.Unmarshal(w.Body.Bytes(), summary); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if summary.State != api.StateRunning || len(summary.Benchmarks) != 2 || summary.Benchmarks[0].Metric != "acc" {
+			t.Errorf("Unexpected summary: %+v", summary)
+		}
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		w := summarize(job.ID, "format=markdown")
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") {
+			t.Fatalf("Expected a markdown response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		for _, line := range []string{"**State:** running", "| mmlu | completed | acc | 0.5000 |", "| hellaswag | pending |"} {
+			if !strings.Contains(w.Body.String(), line) {
+				t.Errorf("Expected the report to contain %q, got\n%s", line, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		w := summarize(job.ID, "format=csv")
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+			t.Fatalf("Expected a CSV response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 4 || lines[0] != "benchmark,state,metric,value,duration_seconds,message" || !strings.HasPrefix(lines[1], "mmlu,completed,acc,0.5000,") || !strings.HasPrefix(lines[3], "overall,running,") {
+			t.Errorf("Unexpected CSV report:\n%s", w.Body.String())
+		}
+	})
+
+	t.Run("unknown jobs and invalid formats", func(t *testing.T) {
+		if w := summarize("unknown", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		if w := summarize(job.ID, "format=xml"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestHandleListEvaluationDeliveries(t *testing.T) {
+	storage := createStorage(t)
+	h := handlers.New(storage, nil)
+	job := createJob(t, storage)
+
+	list := func(id string) *httptest.ResponseRecorder {
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/jobs/"+id+"/deliveries")
+		ctx.Logger = logging.FallbackLogger()
+		w := httptest.NewRecorder()
+		h.HandleListEvaluationDeliveries(ctx, w)
+		return w
+	}
+
+	w := list(job.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	deliveries := &api.WebhookDeliveryResourceList{}
+	if err := json.Unmarshal(w.Body.Bytes(), deliveries); err != nil {
+		t.Fatalf("Failed to unmarshal the response: %v", err)
+	}
+	if deliveries.Items == nil || len(deliveries.Items) != 0 {
+		t.Errorf("Expected an empty list of deliveries, got %s", w.Body.String())
+	}
+	if w := list("unknown"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleCreateEvaluation(t *testing.T) {
+	storage := createStorage(t)
+	validate, err := validation.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() returned error: %v", err)
+	}
+	providerCatalog, err := catalog.NewCatalog(nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewCatalog() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(storage, validate, handlers.Dependencies{Catalog: providerCatalog})
+
+	create := func(body string) *httptest.ResponseRecorder {
+		ctx := executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), http.MethodPost,
+			"/api/v1/evaluations/jobs", "", "", nil, io.NopCloser(strings.NewReader(body)), "", "", "", time.Minute, 0, nil, nil, "")
+		w := httptest.NewRecorder()
+		h.HandleCreateEvaluation(ctx, w)
+		return w
+	}
+
+	t.Run("resolves the benchmarks from the catalog", func(t *testing.T) {
+		w := create(`{"model":{"url":"http://localhost:8000","name":"test-model"},"benchmarks":[{"id":"mmlu","limit":10,"parameters":{"num_fewshot":5}}]}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		got := &api.EvaluationJobResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.Benchmarks[0].ProviderID != "lm_evaluation_harness" || got.Benchmarks[0].Category != "knowledge" {
+			t.Errorf("Expected the provider and the category from the catalog, got %+v", got.Benchmarks[0])
+		}
+	})
+
+	t.Run("copies the benchmarks of the collection", func(t *testing.T) {
+		ctx := createExecutionContext(http.MethodPost, "/api/v1/evaluations/collections")
+		collection := &api.CollectionResource{
+			CollectionConfig: api.CollectionConfig{
+				Name: "reasoning",
+				Benchmarks: []api.BenchmarkConfig{
+					{Ref: api.Ref{ID: "arc_challenge"}, Parameters: map[string]any{"num_fewshot": 25, "batch_size": 8}},
+					{Ref: api.Ref{ID: "hellaswag"}},
+				},
+			},
+		}
+		if err := storage.CreateCollection(ctx, collection); err != nil {
+			t.Fatalf("CreateCollection() returned error: %v", err)
+		}
+
+		w := create(`{"model":{"url":"http://localhost:8000"},"collection":{"id":"` + collection.ID + `"},"benchmarks":[{"id":"arc_challenge","parameters":{"num_fewshot":0}}]}`)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+		}
+		got := &api.EvaluationJobResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if len(got.Benchmarks) != 2 || got.Benchmarks[1].ID != "hellaswag" || got.Benchmarks[1].Category != "reasoning" {
+			t.Fatalf("Expected the benchmarks of the collection, got %+v", got.Benchmarks)
+		}
+		if got.Benchmarks[0].Parameters["num_fewshot"] != 0.0 || got.Benchmarks[0].Parameters["batch_size"] != 8.0 {
+			t.Errorf("Expected the parameters of the request merged on the collection, got %v", got.Benchmarks[0].Parameters)
+		}
+	})
+
+	tests := []struct {
+		name string
+		body string
+		loc  []any
+	}{
+		{"unknown collection", `{"model":{"url":"http://localhost:8000"},"collection":{"id":"unknown"}}`, []any{"body", "collection", "id"}},
+		{"invalid JSON", `{"model":`, []any{"body"}},
+		{"missing model URL", `{"model":{"name":"test-model"}}`, []any{"body", "model", "url"}},
+		{"invalid model URL", `{"model":{"url":"localhost 8000","name":"test-model"}}`, []any{"body", "model", "url"}},
+		{"limit is not positive", `{"model":{"url":"http://localhost:8000"},"benchmarks":[{"id":"mmlu","limit":0}]}`, []any{"body", "benchmarks", float64(0), "limit"}},
+		{"unknown benchmark", `{"model":{"url":"http://localhost:8000"},"benchmarks":[{"id":"mmlu"},{"id":"unknown"}]}`, []any{"body", "benchmarks", float64(1), "id"}},
+		{"invalid parameter", `{"model":{"url":"http://localhost:8000"},"benchmarks":[{"id":"gsm8k","parameters":{"num_fewshot":"five"}}]}`, []any{"body", "benchmarks", float64(0), "parameters", "num_fewshot"}},
+		{"timeout is not positive", `{"model":{"url":"http://localhost:8000"},"timeout_minutes":0}`, []any{"body", "timeout_minutes"}},
+		{"retry attempts are negative", `{"model":{"url":"http://localhost:8000"},"retry_attempts":-1}`, []any{"body", "retry_attempts"}},
+		{"callback URL is not HTTP", `{"model":{"url":"http://localhost:8000"},"callback_url":"file:///etc/passwd"}`, []any{"body", "callback_url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := create(tt.body)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+			}
+			got := &api.HTTPValidationError{}
+			if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+				t.Fatalf("Failed to unmarshal the response: %v", err)
+			}
+			if len(got.Detail) != 1 || !reflect.DeepEqual(got.Detail[0].Loc, tt.loc) {
+				t.Errorf("Expected a validation error at %v, got %+v", tt.loc, got.Detail)
+			}
+		})
+	}
+}
+
+func TestHandleCatalog(t *testing.T) {
+	providerCatalog, err := catalog.NewCatalog(nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewCatalog() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(nil, nil, handlers.Dependencies{Catalog: providerCatalog})
+
+	t.Run("lists the benchmarks matching the filters", func(t *testing.T) {
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/benchmarks")
+		ctx.Logger = logging.FallbackLogger()
+		ctx.RawQuery = "provider_id=lm_evaluation_harness&category=reasoning&tags=commonsense,multiple_choice"
+		w := httptest.NewRecorder()
+		h.HandleListBenchmarks(ctx, w)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.BenchmarkResourceList{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.TotalCount != 2 || got.Items[0].ID != "hellaswag" || got.Items[1].ID != "winogrande" {
+			t.Errorf("Expected hellaswag and winogrande, got %+v", got.Items)
+		}
+	})
+
+	t.Run("gets a provider", func(t *testing.T) {
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/providers/lm_evaluation_harness")
+		ctx.Logger = logging.FallbackLogger()
+		w := httptest.NewRecorder()
+		h.HandleGetProvider(ctx, w)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		got := &api.ProviderResource{}
+		if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+			t.Fatalf("Failed to unmarshal the response: %v", err)
+		}
+		if got.ID != "lm_evaluation_harness" || len(got.SupportedBenchmarks) == 0 {
+			t.Errorf("Expected the lm_evaluation_harness provider with benchmarks, got %+v", got)
+		}
+	})
+
+	t.Run("returns 404 for an unknown provider", func(t *testing.T) {
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/providers/unknown")
+		ctx.Logger = logging.FallbackLogger()
+		w := httptest.NewRecorder()
+		h.HandleGetProvider(ctx, w)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func createJob(t *testing.T, storage abstractions.Storage) *api.EvaluationJobResource {
+	t.Helper()
+	job, err := storage.CreateEvaluationJob(createExecutionContext(http.MethodPost, ""), &api.EvaluationJobConfig{
+		Model:      api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "hellaswag"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	return job
+}
+
+func createStorage(t *testing.T) abstractions.Storage {
+	t.Helper()
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, nil, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
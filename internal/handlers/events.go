@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+)
+
+// eventKeepAlive is the interval of the comments written to an idle stream so that the
+// proxies do not close it
+const eventKeepAlive = 15 * time.Second
+
+// HandleEvaluationEvents handles GET /api/v1/evaluations/jobs/{id}/events
+//
+// The changes of the job are streamed as server-sent events. The stream starts with the
+// current job unless the client resumes with a Last-Event-ID whose following events are
+// all in the history, in which case the missed events are replayed instead.
+func (h *Handlers) HandleEvaluationEvents(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
+	if h.bus == nil {
+		h.errorResponse(ctx, w, "The event streams are not enabled", http.StatusNotImplemented)
+		return
+	}
+	lastEventID, err := getLastEventID(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := getPathParam(ctx, evaluationJobsPath)
+	tenant := ctx.Tenant
+
+	// subscribe before reading the job so that no change is missed between the two
+	subscription, replay, complete := h.bus.Subscribe(lastEventID, func(event events.Event) bool {
+		return event.JobID == id && event.Tenant == tenant
+	})
+	defer h.bus.Unsubscribe(subscription)
+
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	if lastEventID == 0 || !complete {
+		data, err := json.Marshal(job)
+		if err != nil {
+			h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		replay = []events.Event{{ID: subscription.LastEventID, Type: events.EventJob, Tenant: tenant, JobID: id, Data: data}}
+	}
+
+	h.streamEvents(ctx, w, subscription, replay)
+}
+
+// HandleListEvents handles GET /api/v1/evaluations/events
+//
+// The changes of all the jobs of the tenant are streamed as server-sent events. A client
+// that resumes with a Last-Event-ID is sent the missed events that are still in the history.
+func (h *Handlers) HandleListEvents(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
+	if h.bus == nil {
+		h.errorResponse(ctx, w, "The event streams are not enabled", http.StatusNotImplemented)
+		return
+	}
+	lastEventID, err := getLastEventID(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tenant := ctx.Tenant
+	subscription, replay, complete := h.bus.Subscribe(lastEventID, func(event events.Event) bool {
+		return event.Tenant == tenant
+	})
+	defer h.bus.Unsubscribe(subscription)
+	if lastEventID != 0 && !complete {
+		ctx.Logger.Info("Some of the events after the last event id are no longer in the history", "last_event_id", lastEventID)
+	}
+
+	h.streamEvents(ctx, w, subscription, replay)
+}
+
+// streamEvents writes the replayed events and then the events of the subscription until the
+// client disconnects. The stream is ended when the subscription is dropped for falling behind
+// so that the client reconnects with the id of the last event it has received.
+func (h *Handlers) streamEvents(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, subscription *events.Subscription, replay []events.Event) {
+	controller := http.NewResponseController(w)
+	// the stream outlives the write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ctx.Logger.Warn("Failed to clear the write deadline of the event stream", "error", err.Error())
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(message string) bool {
+		if _, err := fmt.Fprint(w, message); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+	if !write(": connected\n\n") {
+		return
+	}
+	for _, event := range replay {
+		if !write(formatEvent(event)) {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Ctx.Done():
+			return
+		case <-keepAlive.C:
+			if !write(": keepalive\n\n") {
+				return
+			}
+		case event, ok := <-subscription.C:
+			if !ok {
+				ctx.Logger.Info("Closing the event stream that fell behind")
+				return
+			}
+			if !write(formatEvent(event)) {
+				return
+			}
+		}
+	}
+}
+
+// formatEvent returns the event in the text/event-stream format, the data is a single line
+// of JSON
+func formatEvent(event events.Event) string {
+	return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// getLastEventID returns the id of the last event received by a client that reconnects, or 0
+func getLastEventID(ctx *executioncontext.ExecutionContext) (uint64, error) {
+	value := ctx.GetHeader(http.CanonicalHeaderKey("Last-Event-ID"))
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Last-Event-ID %q", value)
+	}
+	return id, nil
+}
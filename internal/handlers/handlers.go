@@ -2,27 +2,104 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
+	"github.com/julpayne/eval-hub-backend-svc/internal/auth"
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
 	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
 	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
 )
 
 type Handlers struct {
-	storage  abstractions.Storage
-	validate *validator.Validate
+	storage    abstractions.Storage
+	validate   *validator.Validate
+	aggregator *aggregation.Aggregator
+	catalog    *catalog.Catalog
+	// authorizer is nil when the authorization is not enabled
+	authorizer *authz.Authorizer
+	// bus is nil when the event streams are not served
+	bus *events.Bus
+	// logStore is nil when the benchmark logs are not served
+	logStore abstractions.LogStore
+	// artifactStore is nil when the artifacts are not stored, the uploads larger than
+	// artifactMaxSize are rejected
+	artifactStore   abstractions.ArtifactStore
+	artifactMaxSize int64
+}
+
+// Dependencies are the optional dependencies of the handlers, the endpoints that use a
+// dependency that is not set are not served
+type Dependencies struct {
+	Aggregator *aggregation.Aggregator
+	Catalog    *catalog.Catalog
+	// Authorizer is nil when the authorization is not enabled
+	Authorizer *authz.Authorizer
+	// Bus is nil when the event streams are not served
+	Bus *events.Bus
+	// LogStore is nil when the benchmark logs are not served
+	LogStore abstractions.LogStore
+	// ArtifactStore is nil when the artifacts are not stored, the uploads larger than
+	// ArtifactMaxSize are rejected
+	ArtifactStore   abstractions.ArtifactStore
+	ArtifactMaxSize int64
 }
 
 func New(storage abstractions.Storage, validate *validator.Validate) *Handlers {
+	return NewWithDependencies(storage, validate, Dependencies{})
+}
+
+func NewWithDependencies(storage abstractions.Storage, validate *validator.Validate, dependencies Dependencies) *Handlers {
 	return &Handlers{
-		storage:  storage,
-		validate: validate,
+		storage:         storage,
+		validate:        validate,
+		aggregator:      dependencies.Aggregator,
+		catalog:         dependencies.Catalog,
+		authorizer:      dependencies.Authorizer,
+		bus:             dependencies.Bus,
+		logStore:        dependencies.LogStore,
+		artifactStore:   dependencies.ArtifactStore,
+		artifactMaxSize: dependencies.ArtifactMaxSize,
 	}
 }
 
+// authorize checks that the principal of the request has the permission, the owner is the
+// owner of the resource for the permissions that depend on it. The requests without a
+// principal, that are public or are authenticated by the route, are not checked. It writes
+// the 403 response and returns false when the permission is denied.
+func (h *Handlers) authorize(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, permission authz.Permission, owner string) bool {
+	if h.authorizer == nil || ctx.Principal == nil {
+		return true
+	}
+	if !h.authorizer.Allowed(ctx.Principal, permission, owner) {
+		h.errorResponse(ctx, w, fmt.Sprintf("the permission %s is required", permission), http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// authorizeJobReport checks that the request can report the results and upload the artifacts
+// of the job. The principal of the results token of a job can only report for its own job,
+// the other principals require the permission to create evaluations. It writes the 403
+// response and returns false when the request is denied.
+func (h *Handlers) authorizeJobReport(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, id string) bool {
+	if ctx.Principal != nil && ctx.Principal.Method == auth.MethodResultsToken {
+		if ctx.Principal.Subject != id {
+			h.errorResponse(ctx, w, fmt.Sprintf("The results token is not the token of the evaluation job %s", id), http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+	return h.authorize(ctx, w, authz.EvaluationsCreate, "")
+}
+
 func (h *Handlers) checkMethod(ctx *executioncontext.ExecutionContext, method string, w http.ResponseWriter) bool {
 	if ctx.Method != method {
 		http.Error(w, fmt.Sprintf("Method %s not allowed, expecting %s", ctx.Method, method), http.StatusMethodNotAllowed)
@@ -45,6 +122,20 @@ func (h *Handlers) serializationError(ctx *executioncontext.ExecutionContext, w
 	h.errorResponse(ctx, w, msg, code)
 }
 
+// storageError maps the errors returned by the storage onto the HTTP status codes
+func (h *Handlers) storageError(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, abstractions.ErrNotFound):
+		h.errorResponse(ctx, w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, abstractions.ErrInvalidArgument):
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, abstractions.ErrInvalidTransition), errors.Is(err, abstractions.ErrConflict):
+		h.errorResponse(ctx, w, err.Error(), http.StatusConflict)
+	default:
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (h *Handlers) errorResponse(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, errorMessage string, code int) {
 	// copied from http.Error but changed because we want to return a JSON error message
 	header := w.Header()
@@ -68,6 +159,21 @@ func (h *Handlers) errorResponse(ctx *executioncontext.ExecutionContext, w http.
 	logging.LogRequestFailed(ctx, code, errorMessage)
 }
 
+// validationErrorResponse writes the fields of the request that are not valid as an HTTPValidationError
+func (h *Handlers) validationErrorResponse(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, details []api.ValidationError) {
+	jsonBytes, err := json.MarshalIndent(&api.HTTPValidationError{Detail: details}, "", "  ")
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.setApplicationJSON(w)
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write(jsonBytes)
+
+	logging.LogRequestFailed(ctx, http.StatusUnprocessableEntity, details[0].Msg)
+}
+
 func (h *Handlers) successResponse(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, response any, code int) {
 	jsonBytes, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
@@ -75,9 +181,19 @@ func (h *Handlers) successResponse(ctx *executioncontext.ExecutionContext, w htt
 		return
 	}
 
-	w.Write(jsonBytes)
+	// the headers and status code must be set before the body is written
 	h.setApplicationJSON(w)
 	w.WriteHeader(code)
+	w.Write(jsonBytes)
 
 	logging.LogRequestSuccess(ctx, code, response)
 }
+
+// textResponse writes a response that is not JSON, for example the markdown or CSV reports
+func (h *Handlers) textResponse(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, contentType string, body string, code int) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write([]byte(body))
+
+	logging.LogRequestSuccess(ctx, code, nil)
+}
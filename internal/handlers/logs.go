@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	// logFollowInterval is how often a followed log is read for the new output
+	logFollowInterval = time.Second
+	// tailChunkSize is the size of the chunks read backwards to find the last lines of a log
+	tailChunkSize = 4096
+)
+
+// HandleGetBenchmarkLogs handles GET /api/v1/evaluations/jobs/{id}/benchmarks/{name}/logs
+//
+// The log is returned as text/plain. A single byte range can be requested with the Range
+// header, tail=N returns the last N lines and follow=true keeps the response open and writes
+// the new output of the benchmark until it has finished.
+func (h *Handlers) HandleGetBenchmarkLogs(ctx *executioncontext.ExecutionContext, w http.ResponseWriter) {
+	if !h.checkMethod(ctx, http.MethodGet, w) {
+		return
+	}
+	if !h.authorize(ctx, w, authz.EvaluationsRead, "") {
+		return
+	}
+	if h.logStore == nil {
+		h.errorResponse(ctx, w, "The benchmark logs are not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	id := getPathParam(ctx, evaluationJobsPath)
+	name := getBenchmarkName(ctx)
+
+	query, err := getQuery(ctx)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	follow, err := getQueryBool(query, "follow", false)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tail, err := getQueryInt(query, "tail", -1, 0, math.MaxInt32)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	byteRange := ctx.GetHeader("Range")
+	if byteRange != "" && (follow || tail >= 0) {
+		h.errorResponse(ctx, w, "A byte range can not be combined with follow or tail", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	if !slices.ContainsFunc(job.Benchmarks, func(benchmark api.BenchmarkConfig) bool { return benchmark.ID == name }) {
+		h.errorResponse(ctx, w, fmt.Sprintf("The benchmark %s is not part of the evaluation job %s", name, id), http.StatusNotFound)
+		return
+	}
+	log, err := h.logStore.Open(id, name)
+	if err != nil {
+		h.storageError(ctx, w, err)
+		return
+	}
+	defer log.Close()
+	size, err := log.Seek(0, io.SeekEnd)
+	if err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	offset := int64(0)
+	if tail >= 0 {
+		if offset, err = tailOffset(log, size, tail); err != nil {
+			h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if follow {
+		h.followLog(ctx, w, id, name, log, offset)
+		return
+	}
+
+	code := http.StatusOK
+	header := w.Header()
+	if byteRange != "" {
+		first, last, err := parseByteRange(byteRange, size)
+		if err != nil {
+			header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			h.errorResponse(ctx, w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", first, last, size))
+		offset, size, code = first, last+1, http.StatusPartialContent
+	}
+	if _, err := log.Seek(offset, io.SeekStart); err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Content-Length", strconv.FormatInt(size-offset, 10))
+	w.WriteHeader(code)
+	if _, err := io.CopyN(w, log, size-offset); err != nil {
+		ctx.Logger.Warn("Failed to write the benchmark log", "benchmark", name, "error", err.Error())
+	}
+}
+
+// followLog writes the log from the offset and then the output that is added to it until the
+// benchmark has finished or the client disconnects
+func (h *Handlers) followLog(ctx *executioncontext.ExecutionContext, w http.ResponseWriter, id string, name string, log io.ReadSeeker, offset int64) {
+	if _, err := log.Seek(offset, io.SeekStart); err != nil {
+		h.errorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	controller := http.NewResponseController(w)
+	// the benchmark can run for longer than the write timeout of the server
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ctx.Logger.Warn("Failed to clear the write deadline of the followed log", "error", err.Error())
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(logFollowInterval)
+	defer ticker.Stop()
+	for {
+		// the state is read before the log so that the output written before the benchmark
+		// finished is not missed
+		finished := h.benchmarkFinished(ctx, id, name)
+		if _, err := io.Copy(w, log); err != nil {
+			return
+		}
+		if err := controller.Flush(); err != nil || finished {
+			return
+		}
+		select {
+		case <-ctx.Ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// benchmarkFinished returns true when the benchmark or its job is in a terminal state, or when
+// the job can no longer be read
+func (h *Handlers) benchmarkFinished(ctx *executioncontext.ExecutionContext, id string, name string) bool {
+	job, err := h.storage.GetEvaluationJob(ctx, id)
+	if err != nil {
+		ctx.Logger.Info("Stopped following the benchmark log", "benchmark", name, "error", err.Error())
+		return true
+	}
+	if statemachine.IsTerminal(job.Status.State) {
+		return true
+	}
+	for _, status := range job.Status.Benchmarks {
+		if status.Name == name {
+			return statemachine.IsTerminal(status.State)
+		}
+	}
+	return false
+}
+
+// getBenchmarkName returns the name in /api/v1/evaluations/jobs/{id}/benchmarks/{name}/logs
+func getBenchmarkName(ctx *executioncontext.ExecutionContext) string {
+	_, rest, _ := strings.Cut(ctx.URI, "/benchmarks/")
+	return strings.TrimSuffix(rest, "/logs")
+}
+
+// tailOffset returns the offset of the last n lines of the log of the given size, the newline
+// at the end of the log does not start another line
+func tailOffset(log io.ReadSeeker, size int64, n int) (int64, error) {
+	if n == 0 {
+		return size, nil
+	}
+	buf := make([]byte, tailChunkSize)
+	lines := 0
+	for offset := size; offset > 0; {
+		chunk := min(int64(len(buf)), offset)
+		offset -= chunk
+		if _, err := log.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(log, buf[:chunk]); err != nil {
+			return 0, err
+		}
+		for i := chunk - 1; i >= 0; i-- {
+			if buf[i] != '\n' || offset+i == size-1 {
+				continue
+			}
+			lines++
+			if lines == n {
+				return offset + i + 1, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// parseByteRange returns the first and the last byte of a single range of the Range header,
+// multiple ranges are not supported
+func parseByteRange(byteRange string, size int64) (int64, int64, error) {
+	invalid := fmt.Errorf("invalid or unsatisfiable range %q for a log of %d bytes", byteRange, size)
+	spec, found := strings.CutPrefix(byteRange, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, invalid
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, invalid
+	}
+	if first == "" {
+		// the suffix range bytes=-N is the last N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, 0, invalid
+		}
+		return max(size-n, 0), size - 1, nil
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, invalid
+	}
+	end := size - 1
+	if last != "" {
+		value, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || value < start {
+			return 0, 0, invalid
+		}
+		end = min(value, end)
+	}
+	return start, end, nil
+}
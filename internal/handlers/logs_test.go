@@ -0,0 +1,130 @@
+package handlers_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/handlers"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore/logstore_fs"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestHandleGetBenchmarkLogs(t *testing.T) {
+	storage := createStorage(t)
+	logStore, err := logstore_fs.NewFSLogStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSLogStore() returned error: %v", err)
+	}
+	h := handlers.NewWithDependencies(storage, nil, handlers.Dependencies{LogStore: logStore})
+	job := createJob(t, storage)
+
+	writer, _, err := logStore.Create(job.ID, "mmlu")
+	if err != nil {
+		t.Fatalf("Create() returned error: %v", err)
+	}
+	defer writer.Close()
+	if _, err := io.WriteString(writer, "line 1\nline 2\nline 3\n"); err != nil {
+		t.Fatalf("Failed to write the log: %v", err)
+	}
+
+	get := func(id string, benchmark string, rawQuery string, byteRange string) *httptest.ResponseRecorder {
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/jobs/"+id+"/benchmarks/"+benchmark+"/logs")
+		ctx.Ctx = context.Background()
+		ctx.Logger = logging.FallbackLogger()
+		ctx.RawQuery = rawQuery
+		if byteRange != "" {
+			ctx.SetHeader("Range", byteRange)
+		}
+		w := httptest.NewRecorder()
+		h.HandleGetBenchmarkLogs(ctx, w)
+		return w
+	}
+
+	tests := []struct {
+		name         string
+		rawQuery     string
+		byteRange    string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"the whole log", "", "", http.StatusOK, "line 1\nline 2\nline 3\n", ""},
+		{"the last lines", "tail=2", "", http.StatusOK, "line 2\nline 3\n", ""},
+		{"more lines than the log", "tail=10", "", http.StatusOK, "line 1\nline 2\nline 3\n", ""},
+		{"no lines", "tail=0", "", http.StatusOK, "", ""},
+		{"a byte range", "", "bytes=7-12", http.StatusPartialContent, "line 2", "bytes 7-12/21"},
+		{"an open byte range", "", "bytes=14-", http.StatusPartialContent, "line 3\n", "bytes 14-20/21"},
+		{"a suffix byte range", "", "bytes=-7", http.StatusPartialContent, "line 3\n", "bytes 14-20/21"},
+		{"a range after the end", "", "bytes=21-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */21"},
+		{"several ranges", "", "bytes=0-1,3-4", http.StatusRequestedRangeNotSatisfiable, "", "bytes */21"},
+		{"a range with tail", "tail=1", "bytes=0-1", http.StatusBadRequest, "", ""},
+		{"an invalid tail", "tail=-1", "", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(job.ID, "mmlu", tt.rawQuery, tt.byteRange)
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status < 300 && w.Body.String() != tt.body {
+				t.Errorf("Expected the body %q, got %q", tt.body, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Expected the Content-Range %q, got %q", tt.contentRange, got)
+			}
+		})
+	}
+
+	t.Run("unknown jobs, benchmarks and logs are not found", func(t *testing.T) {
+		for _, path := range [][2]string{{"unknown", "mmlu"}, {job.ID, "arc"}, {job.ID, "hellaswag"}} {
+			if w := get(path[0], path[1], "", ""); w.Code != http.StatusNotFound {
+				t.Errorf("Expected status %d for %v, got %d", http.StatusNotFound, path, w.Code)
+			}
+		}
+	})
+
+	t.Run("a followed log is written until the benchmark has finished", func(t *testing.T) {
+		ctx := createExecutionContext(http.MethodPost, "")
+		if err := storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}); err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			done <- get(job.ID, "mmlu", "follow=true&tail=1", "")
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		if _, err := io.WriteString(writer, "line 4\n"); err != nil {
+			t.Fatalf("Failed to write the log: %v", err)
+		}
+		if err := storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted}); err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+
+		select {
+		case w := <-done:
+			if body := w.Body.String(); body != "line 3\nline 4\n" {
+				t.Errorf("Expected the last line and the new output, got %q", body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the followed log to end once the benchmark has finished")
+		}
+	})
+
+	t.Run("the logs are not served without a log store", func(t *testing.T) {
+		h := handlers.New(storage, nil)
+		ctx := createExecutionContext(http.MethodGet, "/api/v1/evaluations/jobs/"+job.ID+"/benchmarks/mmlu/logs")
+		ctx.Logger = logging.FallbackLogger()
+		w := httptest.NewRecorder()
+		h.HandleGetBenchmarkLogs(ctx, w)
+		if w.Code != http.StatusNotImplemented || !strings.Contains(w.Body.String(), "not enabled") {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusNotImplemented, w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// getPathParam returns the path segment that follows the given prefix, for example
+// the id in /api/v1/evaluations/jobs/{id}/summary with the prefix /api/v1/evaluations/jobs/
+func getPathParam(ctx *executioncontext.ExecutionContext, prefix string) string {
+	rest := strings.TrimPrefix(ctx.URI, prefix)
+	return strings.SplitN(rest, "/", 2)[0]
+}
+
+func getQuery(ctx *executioncontext.ExecutionContext) (url.Values, error) {
+	return url.ParseQuery(ctx.RawQuery)
+}
+
+// getPageQuery returns the limit and the offset of a list request
+func getPageQuery(query url.Values) (int, int, error) {
+	limit, err := getQueryInt(query, "limit", defaultPageLimit, 1, maxPageLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	offset, err := getQueryInt(query, "offset", 0, 0, math.MaxInt32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return limit, offset, nil
+}
+
+func getQueryInt(query url.Values, name string, defaultValue int, minValue int, maxValue int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("query parameter %s must be an integer", name)
+	}
+	if i < minValue || i > maxValue {
+		return 0, fmt.Errorf("query parameter %s must be between %d and %d", name, minValue, maxValue)
+	}
+	return i, nil
+}
+
+func getQueryBool(query url.Values, name string, defaultValue bool) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("query parameter %s must be a boolean", name)
+	}
+	return b, nil
+}
+
+// getQueryList returns the values of a comma-separated query parameter, the parameter
+// can also be repeated
+func getQueryList(query url.Values, name string) []string {
+	values := []string{}
+	for _, value := range query[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
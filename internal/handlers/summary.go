@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The formats of the evaluation job summary
+const (
+	summaryFormatJSON     = "json"
+	summaryFormatMarkdown = "markdown"
+	summaryFormatCSV      = "csv"
+)
+
+var summaryFormats = []string{summaryFormatJSON, summaryFormatMarkdown, summaryFormatCSV}
+
+func isSummaryFormat(format string) bool {
+	return slices.Contains(summaryFormats, format)
+}
+
+// renderSummaryMarkdown renders the summary as a heading and a table of the benchmarks that
+// can be pasted into a pull request comment
+func renderSummaryMarkdown(summary *api.EvaluationJobSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Evaluation job `%s`\n\n", summary.ID)
+	fmt.Fprintf(&b, "**State:** %s", summary.State)
+	if summary.DurationSeconds != nil {
+		fmt.Fprintf(&b, " | **Duration:** %s", formatDuration(summary.DurationSeconds))
+	}
+	if summary.Score != nil {
+		fmt.Fprintf(&b, " | **Score:** %s", formatFloat(summary.Score))
+	}
+	if summary.Passed != nil {
+		passed := "no"
+		if *summary.Passed {
+			passed = "yes"
+		}
+		fmt.Fprintf(&b, " | **Passed:** %s", passed)
+	}
+	fmt.Fprintf(&b, " | **Benchmarks:** %d completed, %d failed of %d\n\n", summary.CompletedEvaluations, summary.FailedEvaluations, summary.TotalEvaluations)
+	if summary.Message != "" {
+		fmt.Fprintf(&b, "%s\n\n", escapeMarkdown(summary.Message))
+	}
+
+	b.WriteString("| Benchmark | State | Metric | Value | Duration | Message |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	for _, benchmark := range summary.Benchmarks {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			escapeMarkdown(benchmark.Name),
+			benchmark.State,
+			escapeMarkdown(benchmark.Metric),
+			formatFloat(benchmark.Value),
+			formatDuration(benchmark.DurationSeconds),
+			escapeMarkdown(benchmark.Message),
+		)
+	}
+	return b.String()
+}
+
+// renderSummaryCSV renders a row per benchmark followed by a row with the overall state and score
+func renderSummaryCSV(summary *api.EvaluationJobSummary) string {
+	var b bytes.Buffer
+	writer := csv.NewWriter(&b)
+	writer.Write([]string{"benchmark", "state", "metric", "value", "duration_seconds", "message"})
+	for _, benchmark := range summary.Benchmarks {
+		writer.Write([]string{
+			benchmark.Name,
+			string(benchmark.State),
+			benchmark.Metric,
+			formatFloat(benchmark.Value),
+			formatSeconds(benchmark.DurationSeconds),
+			benchmark.Message,
+		})
+	}
+	scoreMetric := ""
+	if summary.Score != nil {
+		scoreMetric = "score"
+	}
+	writer.Write([]string{
+		"overall",
+		string(summary.State),
+		scoreMetric,
+		formatFloat(summary.Score),
+		formatSeconds(summary.DurationSeconds),
+		summary.Message,
+	})
+	writer.Flush()
+	return b.String()
+}
+
+func formatFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 4, 64)
+}
+
+func formatSeconds(seconds *float64) string {
+	if seconds == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*seconds, 'f', 0, 64)
+}
+
+func formatDuration(seconds *float64) string {
+	if seconds == nil {
+		return ""
+	}
+	return time.Duration(*seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// escapeMarkdown keeps the text on a single table cell
+func escapeMarkdown(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
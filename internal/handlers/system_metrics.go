@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/julpayne/eval-hub-backend-svc/internal/authz"
 	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
 )
 
@@ -12,6 +13,9 @@ func (h *Handlers) HandleGetSystemMetrics(ctx *executioncontext.ExecutionContext
 	if !h.checkMethod(ctx, http.MethodGet, w) {
 		return
 	}
+	if !h.authorize(ctx, w, authz.SystemRead, "") {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -0,0 +1,30 @@
+package logstore
+
+import (
+	"log/slog"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore/logstore_fs"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore/logstore_k8s"
+)
+
+// NewLogStore creates the store of the benchmark logs, the logs of the benchmarks run by the
+// k8s runtime are read from their pods and the other logs are kept on the local filesystem of
+// the replica
+func NewLogStore(serviceConfig *config.Config, logger *slog.Logger) (abstractions.LogStore, error) {
+	// the local runtime is used when both runtimes are enabled
+	if runtime := serviceConfig.Runtime; runtime != nil && (runtime.Local == nil || !runtime.Local.Enabled) && runtime.K8s != nil && runtime.K8s.Enabled {
+		logger.Info("Using k8s log store", "namespace", runtime.K8s.Namespace)
+		return logstore_k8s.NewK8sLogStore(runtime.K8s)
+	}
+	logsConfig := serviceConfig.Logs
+	if logsConfig == nil {
+		logsConfig = &config.LogsConfig{}
+	}
+	if err := logsConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	logger.Info("Using filesystem log store", "dir", logsConfig.Dir)
+	return logstore_fs.NewFSLogStore(logsConfig.Dir)
+}
@@ -0,0 +1,66 @@
+package logstore_fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+)
+
+// FSLogStore keeps the log of each benchmark in a file of the local filesystem, the log of
+// a benchmark is <dir>/<job id>/<benchmark>.log
+type FSLogStore struct {
+	dir string
+}
+
+// NewFSLogStore creates the directory of the logs if it does not exist
+func NewFSLogStore(dir string) (abstractions.LogStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the filesystem log store requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the logs directory: %w", err)
+	}
+	return &FSLogStore{dir: dir}, nil
+}
+
+func (s *FSLogStore) Create(jobID string, benchmark string) (io.WriteCloser, string, error) {
+	path := s.path(jobID, benchmark)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, "", fmt.Errorf("failed to create the logs directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create the log file: %w", err)
+	}
+	return file, path, nil
+}
+
+func (s *FSLogStore) Open(jobID string, benchmark string) (io.ReadSeekCloser, error) {
+	file, err := os.Open(s.path(jobID, benchmark))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("log of the benchmark %s of the job %s %w", benchmark, jobID, abstractions.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (s *FSLogStore) path(jobID string, benchmark string) string {
+	return filepath.Join(s.dir, safeFileName(jobID), safeFileName(benchmark)+".log")
+}
+
+// safeFileName makes sure that a job or benchmark id can not escape the logs directory
+func safeFileName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+	if name == "" {
+		return "benchmark"
+	}
+	return name
+}
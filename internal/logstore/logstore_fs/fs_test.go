@@ -0,0 +1,63 @@
+package logstore_fs_test
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore/logstore_fs"
+)
+
+func TestFSLogStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := logstore_fs.NewFSLogStore(dir)
+	if err != nil {
+		t.Fatalf("NewFSLogStore() returned error: %v", err)
+	}
+
+	t.Run("a log is read while it is written", func(t *testing.T) {
+		writer, path, err := store.Create("job-1", "mmlu")
+		if err != nil {
+			t.Fatalf("Create() returned error: %v", err)
+		}
+		defer writer.Close()
+		if path != filepath.Join(dir, "job-1", "mmlu.log") {
+			t.Errorf("Unexpected location %s", path)
+		}
+		io.WriteString(writer, "first\n")
+
+		reader, err := store.Open("job-1", "mmlu")
+		if err != nil {
+			t.Fatalf("Open() returned error: %v", err)
+		}
+		defer reader.Close()
+		io.WriteString(writer, "second\n")
+		if logs, _ := io.ReadAll(reader); string(logs) != "first\nsecond\n" {
+			t.Errorf("Expected both lines, got %q", logs)
+		}
+	})
+
+	t.Run("a log that does not exist is not found", func(t *testing.T) {
+		if _, err := store.Open("job-1", "arc"); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("the names can not escape the logs directory", func(t *testing.T) {
+		writer, path, err := store.Create("../job", "../../mmlu")
+		if err != nil {
+			t.Fatalf("Create() returned error: %v", err)
+		}
+		writer.Close()
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			t.Errorf("Expected the log to be in %s, got %s", dir, path)
+		}
+	})
+
+	if _, err := logstore_fs.NewFSLogStore(""); err == nil {
+		t.Error("Expected an error without a directory")
+	}
+}
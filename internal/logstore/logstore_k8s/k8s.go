@@ -0,0 +1,147 @@
+package logstore_k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// requestTimeout is the timeout of the requests to the Kubernetes API
+const requestTimeout = 30 * time.Second
+
+// K8sLogStore reads the logs of the benchmarks run by the k8s runtime with the pod log API,
+// so the logs can be read from any replica. The log of a benchmark is the log of the first
+// container of the latest pod of the benchmark, the logs are written by the pods and are kept
+// as long as the pods.
+type K8sLogStore struct {
+	namespace string
+	client    kubernetes.Interface
+}
+
+// NewK8sLogStore creates the store with a client built from the configuration of the runtime
+func NewK8sLogStore(k8sConfig *config.K8sRuntimeConfig) (abstractions.LogStore, error) {
+	client, err := runtime_k8s.NewClient(k8sConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewK8sLogStoreWithClient(k8sConfig, client)
+}
+
+// NewK8sLogStoreWithClient creates the store with the given client, this is used by the
+// tests to run against a fake clientset
+func NewK8sLogStoreWithClient(k8sConfig *config.K8sRuntimeConfig, client kubernetes.Interface) (abstractions.LogStore, error) {
+	if k8sConfig.Namespace == "" {
+		return nil, fmt.Errorf("the k8s log store requires a namespace")
+	}
+	return &K8sLogStore{namespace: k8sConfig.Namespace, client: client}, nil
+}
+
+// Create fails as the logs of the benchmarks are written by their pods
+func (s *K8sLogStore) Create(jobID string, benchmark string) (io.WriteCloser, string, error) {
+	return nil, "", fmt.Errorf("the logs of the benchmarks run by the k8s runtime are written by the pods")
+}
+
+func (s *K8sLogStore) Open(jobID string, benchmark string) (io.ReadSeekCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	pods, err := s.client.CoreV1().Pods(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: runtime_k8s.LabelJobID + "=" + jobID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of the job %s: %w", jobID, err)
+	}
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Annotations[runtime_k8s.AnnotationBenchmarkID] != benchmark || len(pod.Spec.Containers) == 0 {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			latest = pod
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("log of the benchmark %s of the job %s %w", benchmark, jobID, abstractions.ErrNotFound)
+	}
+	log := &podLog{store: s, pod: latest.Name, container: latest.Spec.Containers[0].Name}
+	if err := log.read(); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// podLog is the log of a pod, the log is read again when the end of the log is reached so
+// that a followed log returns the new output of a running pod
+type podLog struct {
+	store     *K8sLogStore
+	pod       string
+	container string
+	data      []byte
+	offset    int64
+}
+
+// read reads the whole log of the pod, the log is empty until the container has started
+func (l *podLog) read() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	stream, err := l.store.client.CoreV1().Pods(l.store.namespace).GetLogs(l.pod, &corev1.PodLogOptions{Container: l.container}).Stream(ctx)
+	if apierrors.IsBadRequest(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the log of the pod %s: %w", l.pod, err)
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return fmt.Errorf("failed to read the log of the pod %s: %w", l.pod, err)
+	}
+	// the log of a pod only grows, a shorter log is an older answer of the API
+	if len(data) > len(l.data) {
+		l.data = data
+	}
+	return nil
+}
+
+func (l *podLog) Read(p []byte) (int, error) {
+	if l.offset >= int64(len(l.data)) {
+		if err := l.read(); err != nil {
+			return 0, err
+		}
+		if l.offset >= int64(len(l.data)) {
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, l.data[l.offset:])
+	l.offset += int64(n)
+	return n, nil
+}
+
+func (l *podLog) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += l.offset
+	case io.SeekEnd:
+		offset += int64(len(l.data))
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	l.offset = offset
+	return offset, nil
+}
+
+func (l *podLog) Close() error {
+	return nil
+}
@@ -0,0 +1,88 @@
+package logstore_k8s_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore/logstore_k8s"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const namespace = "eval-hub-test"
+
+func TestK8sLogStore(t *testing.T) {
+	client := fake.NewClientset()
+	store, err := logstore_k8s.NewK8sLogStoreWithClient(&config.K8sRuntimeConfig{Namespace: namespace}, client)
+	if err != nil {
+		t.Fatalf("NewK8sLogStoreWithClient() returned error: %v", err)
+	}
+	now := time.Now()
+	for _, pod := range []struct {
+		name      string
+		jobID     string
+		benchmark string
+		createdAt time.Time
+	}{
+		{"mmlu-a", "job-1", "mmlu", now.Add(-time.Minute)},
+		{"mmlu-b", "job-1", "mmlu", now},
+		{"arc-a", "job-2", "arc", now},
+	} {
+		_, err := client.CoreV1().Pods(namespace).Create(context.Background(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              pod.name,
+				Namespace:         namespace,
+				Labels:            map[string]string{runtime_k8s.LabelJobID: pod.jobID},
+				Annotations:       map[string]string{runtime_k8s.AnnotationBenchmarkID: pod.benchmark},
+				CreationTimestamp: metav1.NewTime(pod.createdAt),
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "benchmark"}}},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Failed to create the pod: %v", err)
+		}
+	}
+
+	t.Run("the log of a benchmark is the log of its pod", func(t *testing.T) {
+		reader, err := store.Open("job-1", "mmlu")
+		if err != nil {
+			t.Fatalf("Open() returned error: %v", err)
+		}
+		defer reader.Close()
+		// the fake clientset returns the same log for all the pods
+		if logs, _ := io.ReadAll(reader); string(logs) != "fake logs" {
+			t.Errorf("Expected the log of the pod, got %q", logs)
+		}
+		if size, err := reader.Seek(-4, io.SeekEnd); err != nil || size != 5 {
+			t.Errorf("Expected the offset 5, got %d %v", size, err)
+		}
+		if logs, _ := io.ReadAll(reader); string(logs) != "logs" {
+			t.Errorf("Expected the end of the log, got %q", logs)
+		}
+	})
+
+	t.Run("the benchmarks without a pod have no log", func(t *testing.T) {
+		for _, benchmark := range []struct{ jobID, name string }{{"job-1", "arc"}, {"job-3", "mmlu"}} {
+			if _, err := store.Open(benchmark.jobID, benchmark.name); !errors.Is(err, abstractions.ErrNotFound) {
+				t.Errorf("Expected ErrNotFound for %+v, got %v", benchmark, err)
+			}
+		}
+	})
+
+	t.Run("the logs are written by the pods", func(t *testing.T) {
+		if _, _, err := store.Create("job-1", "mmlu"); err == nil {
+			t.Error("Expected an error when creating a log")
+		}
+	})
+
+	if _, err := logstore_k8s.NewK8sLogStoreWithClient(&config.K8sRuntimeConfig{}, client); err == nil {
+		t.Error("Expected an error without a namespace")
+	}
+}
@@ -32,4 +32,40 @@ var (
 			Help: "Number of HTTP requests currently being processed",
 		},
 	)
+
+	// EvaluationJobsQueueDepth tracks the number of pending evaluation jobs in the storage
+	EvaluationJobsQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "evaluation_jobs_queue_depth",
+			Help: "Number of pending evaluation jobs waiting to be run",
+		},
+	)
+
+	// EvaluationJobsRunning tracks the number of evaluation jobs run by this replica
+	EvaluationJobsRunning = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "evaluation_jobs_running",
+			Help: "Number of evaluation jobs currently being run by this replica",
+		},
+	)
+
+	// EvaluationJobsClaimedTotal tracks the evaluation jobs claimed by this replica, recovered
+	// is true when the job was orphaned by another replica
+	EvaluationJobsClaimedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "evaluation_jobs_claimed_total",
+			Help: "Total number of evaluation jobs claimed by this replica",
+		},
+		[]string{"recovered"},
+	)
+
+	// WebhookDeliveryAttemptsTotal tracks the attempts to deliver the webhook events, the outcome
+	// is delivered, failed (the attempt is retried) or dead_letter
+	WebhookDeliveryAttemptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_delivery_attempts_total",
+			Help: "Total number of webhook delivery attempts made by this replica",
+		},
+		[]string{"outcome"},
+	)
 )
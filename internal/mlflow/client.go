@@ -0,0 +1,244 @@
+package mlflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+)
+
+const (
+	// ErrorCodeResourceDoesNotExist is returned by MLflow for an unknown experiment or run
+	ErrorCodeResourceDoesNotExist = "RESOURCE_DOES_NOT_EXIST"
+	// ErrorCodeResourceAlreadyExists is returned by MLflow when an experiment name is taken
+	ErrorCodeResourceAlreadyExists = "RESOURCE_ALREADY_EXISTS"
+
+	// maxParamsPerBatch and maxMetricsPerBatch are the limits of a log-batch request
+	maxParamsPerBatch  = 100
+	maxMetricsPerBatch = 1000
+	// maxParamValueLength is the longest param value accepted by MLflow
+	maxParamValueLength = 6000
+	// maxErrorBody is the number of bytes of an error response that are read
+	maxErrorBody = 4096
+)
+
+// RunStatus is the status of a run when it is terminated
+type RunStatus string
+
+const (
+	RunStatusFinished RunStatus = "FINISHED"
+	RunStatusFailed   RunStatus = "FAILED"
+)
+
+// Client is the part of the MLflow tracking API used to record the results of the jobs
+type Client interface {
+	// GetOrCreateExperiment returns the id of the experiment with the name, the experiment
+	// is created with the tags when it does not exist
+	GetOrCreateExperiment(ctx context.Context, name string, tags map[string]string) (string, error)
+	// CreateRun creates a run of the experiment that is started at startTime
+	CreateRun(ctx context.Context, experimentID string, runName string, startTime time.Time, tags map[string]string) (string, error)
+	// LogBatch logs the params and the final value of the metrics of the run
+	LogBatch(ctx context.Context, runID string, params map[string]string, metrics map[string]float64, timestamp time.Time) error
+	// TerminateRun sets the status and the end time of the run
+	TerminateRun(ctx context.Context, runID string, status RunStatus, endTime time.Time) error
+	// ExperimentURL returns the link to the experiment in the MLflow UI
+	ExperimentURL(experimentID string) string
+}
+
+// APIError is an error response of the MLflow API
+type APIError struct {
+	StatusCode int
+	Code       string `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("MLflow returned %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsErrorCode returns true if the error is an MLflow error response with the error code
+func IsErrorCode(err error, code string) bool {
+	apiError := &APIError{}
+	return errors.As(err, &apiError) && apiError.Code == code
+}
+
+// RESTClient calls the MLflow REST API of the tracking server
+type RESTClient struct {
+	trackingURI string
+	token       string
+	client      *http.Client
+}
+
+// NewRESTClient returns a client of the tracking server of the configuration, the requests are
+// authenticated with the token as a bearer token when it is set
+func NewRESTClient(mlflowConfig *config.MLflowConfig) (*RESTClient, error) {
+	if err := mlflowConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	trackingURI, err := url.Parse(mlflowConfig.TrackingURI)
+	if err != nil || (trackingURI.Scheme != "http" && trackingURI.Scheme != "https") {
+		return nil, fmt.Errorf("invalid MLflow tracking URI %q", mlflowConfig.TrackingURI)
+	}
+	return &RESTClient{
+		trackingURI: strings.TrimSuffix(mlflowConfig.TrackingURI, "/"),
+		token:       mlflowConfig.Token,
+		client:      &http.Client{Timeout: mlflowConfig.Timeout},
+	}, nil
+}
+
+type tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type param struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type metric struct {
+	Key       string  `json:"key"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+	Step      int64   `json:"step"`
+}
+
+func (c *RESTClient) GetOrCreateExperiment(ctx context.Context, name string, tags map[string]string) (string, error) {
+	id, err := c.getExperimentByName(ctx, name)
+	if !IsErrorCode(err, ErrorCodeResourceDoesNotExist) {
+		return id, err
+	}
+	response := struct {
+		ExperimentID string `json:"experiment_id"`
+	}{}
+	err = c.call(ctx, http.MethodPost, "experiments/create", map[string]any{"name": name, "tags": toTags(tags)}, &response)
+	if IsErrorCode(err, ErrorCodeResourceAlreadyExists) {
+		// the experiment has been created by another request in the meantime
+		return c.getExperimentByName(ctx, name)
+	}
+	return response.ExperimentID, err
+}
+
+func (c *RESTClient) getExperimentByName(ctx context.Context, name string) (string, error) {
+	response := struct {
+		Experiment struct {
+			ExperimentID string `json:"experiment_id"`
+		} `json:"experiment"`
+	}{}
+	err := c.call(ctx, http.MethodGet, "experiments/get-by-name?experiment_name="+url.QueryEscape(name), nil, &response)
+	return response.Experiment.ExperimentID, err
+}
+
+func (c *RESTClient) CreateRun(ctx context.Context, experimentID string, runName string, startTime time.Time, tags map[string]string) (string, error) {
+	response := struct {
+		Run struct {
+			Info struct {
+				RunID string `json:"run_id"`
+			} `json:"info"`
+		} `json:"run"`
+	}{}
+	err := c.call(ctx, http.MethodPost, "runs/create", map[string]any{
+		"experiment_id": experimentID,
+		"run_name":      runName,
+		"start_time":    startTime.UnixMilli(),
+		"tags":          toTags(tags),
+	}, &response)
+	return response.Run.Info.RunID, err
+}
+
+// LogBatch splits the params and the metrics into batches within the limits of the API, the
+// params that are too long are truncated
+func (c *RESTClient) LogBatch(ctx context.Context, runID string, params map[string]string, metrics map[string]float64, timestamp time.Time) error {
+	paramList := make([]param, 0, len(params))
+	for key, value := range params {
+		if len(value) > maxParamValueLength {
+			value = value[:maxParamValueLength]
+		}
+		paramList = append(paramList, param{Key: key, Value: value})
+	}
+	metricList := make([]metric, 0, len(metrics))
+	for key, value := range metrics {
+		metricList = append(metricList, metric{Key: key, Value: value, Timestamp: timestamp.UnixMilli()})
+	}
+	for len(paramList) > 0 || len(metricList) > 0 {
+		paramBatch := paramList[:min(len(paramList), maxParamsPerBatch)]
+		metricBatch := metricList[:min(len(metricList), maxMetricsPerBatch)]
+		paramList, metricList = paramList[len(paramBatch):], metricList[len(metricBatch):]
+		if err := c.call(ctx, http.MethodPost, "runs/log-batch", map[string]any{
+			"run_id":  runID,
+			"params":  paramBatch,
+			"metrics": metricBatch,
+		}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RESTClient) TerminateRun(ctx context.Context, runID string, status RunStatus, endTime time.Time) error {
+	return c.call(ctx, http.MethodPost, "runs/update", map[string]any{
+		"run_id":   runID,
+		"status":   status,
+		"end_time": endTime.UnixMilli(),
+	}, nil)
+}
+
+func (c *RESTClient) ExperimentURL(experimentID string) string {
+	return fmt.Sprintf("%s/#/experiments/%s", c.trackingURI, url.PathEscape(experimentID))
+}
+
+// call makes a request to the endpoint of the REST API and decodes the response into result,
+// an error response is returned as an *APIError
+func (c *RESTClient) call(ctx context.Context, method string, endpoint string, body any, result any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.trackingURI+"/api/2.0/mlflow/"+endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiError := &APIError{StatusCode: resp.StatusCode}
+		// the body is only used for the error code and the message
+		_ = json.NewDecoder(io.LimitReader(resp.Body, maxErrorBody)).Decode(apiError)
+		return apiError
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode the MLflow response of %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+func toTags(tags map[string]string) []tag {
+	list := make([]tag, 0, len(tags))
+	for key, value := range tags {
+		list = append(list, tag{Key: key, Value: value})
+	}
+	return list
+}
@@ -0,0 +1,384 @@
+package mlflow_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/mlflow"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// fakeRun is a run recorded by the fake MLflow server
+type fakeRun struct {
+	experimentID string
+	name         string
+	tags         map[string]string
+	params       map[string]string
+	metrics      map[string]float64
+	status       string
+}
+
+// fakeMLflow implements the endpoints of the MLflow REST API used by the client
+type fakeMLflow struct {
+	mu          sync.Mutex
+	token       string
+	experiments map[string]string
+	runs        map[string]*fakeRun
+	failRuns    bool
+}
+
+func newFakeMLflow(t *testing.T, token string) (*fakeMLflow, *httptest.Server) {
+	fake := &fakeMLflow{token: token, experiments: map[string]string{}, runs: map[string]*fakeRun{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeMLflow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fail := func(status int, code string, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error_code": code, "message": message})
+	}
+	if f.token != "" && r.Header.Get("Authorization") != "Bearer "+f.token {
+		fail(http.StatusUnauthorized, "UNAUTHENTICATED", "invalid token")
+		return
+	}
+	body := struct {
+		Name         string `json:"name"`
+		ExperimentID string `json:"experiment_id"`
+		RunID        string `json:"run_id"`
+		RunName      string `json:"run_name"`
+		Status       string `json:"status"`
+		Tags         []struct{ Key, Value string }
+		Params       []struct{ Key, Value string }
+		Metrics      []struct {
+			Key   string
+			Value float64
+		}
+	}{}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			fail(http.StatusBadRequest, "INVALID_PARAMETER_VALUE", err.Error())
+			return
+		}
+	}
+	tags := map[string]string{}
+	for _, tag := range body.Tags {
+		tags[tag.Key] = tag.Value
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/api/2.0/mlflow/") {
+	case "experiments/get-by-name":
+		id, found := f.experiments[r.URL.Query().Get("experiment_name")]
+		if !found {
+			fail(http.StatusNotFound, mlflow.ErrorCodeResourceDoesNotExist, "no experiment")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"experiment": map[string]string{"experiment_id": id}})
+	case "experiments/create":
+		if _, found := f.experiments[body.Name]; found {
+			fail(http.StatusBadRequest, mlflow.ErrorCodeResourceAlreadyExists, "exists")
+			return
+		}
+		id := fmt.Sprint(len(f.experiments) + 1)
+		f.experiments[body.Name] = id
+		json.NewEncoder(w).Encode(map[string]string{"experiment_id": id})
+	case "runs/create":
+		if f.failRuns {
+			fail(http.StatusServiceUnavailable, "TEMPORARILY_UNAVAILABLE", "down")
+			return
+		}
+		id := fmt.Sprintf("run-%d", len(f.runs)+1)
+		f.runs[id] = &fakeRun{experimentID: body.ExperimentID, name: body.RunName, tags: tags, params: map[string]string{}, metrics: map[string]float64{}}
+		json.NewEncoder(w).Encode(map[string]any{"run": map[string]any{"info": map[string]string{"run_id": id}}})
+	case "runs/log-batch":
+		run, found := f.runs[body.RunID]
+		if !found {
+			fail(http.StatusNotFound, mlflow.ErrorCodeResourceDoesNotExist, "no run")
+			return
+		}
+		if len(body.Params) > 100 || len(body.Metrics) > 1000 {
+			fail(http.StatusBadRequest, "INVALID_PARAMETER_VALUE", "too many params or metrics")
+			return
+		}
+		for _, param := range body.Params {
+			if len(param.Value) > 6000 {
+				fail(http.StatusBadRequest, "INVALID_PARAMETER_VALUE", "param value too long")
+				return
+			}
+			if value, found := run.params[param.Key]; found && value != param.Value {
+				fail(http.StatusBadRequest, "INVALID_PARAMETER_VALUE", "params can not be changed")
+				return
+			}
+			run.params[param.Key] = param.Value
+		}
+		for _, metric := range body.Metrics {
+			run.metrics[metric.Key] = metric.Value
+		}
+		w.Write([]byte("{}"))
+	case "runs/update":
+		run, found := f.runs[body.RunID]
+		if !found {
+			fail(http.StatusNotFound, mlflow.ErrorCodeResourceDoesNotExist, "no run")
+			return
+		}
+		run.status = body.Status
+		w.Write([]byte("{}"))
+	default:
+		fail(http.StatusNotFound, "ENDPOINT_NOT_FOUND", r.URL.Path)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	fake, server := newFakeMLflow(t, "secret")
+	tracker, err := mlflow.NewTracker(&config.MLflowConfig{Enabled: true, TrackingURI: server.URL + "/", Token: "secret", DefaultExperiment: "default"}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewTracker() returned error: %v", err)
+	}
+	errorMessage := "exit status 1"
+	job := &api.EvaluationJobResource{
+		Resource: api.Resource{ID: "job-1", Tenant: "team-a"},
+		EvaluationJobConfig: api.EvaluationJobConfig{
+			Benchmarks: []api.BenchmarkConfig{
+				{Ref: api.Ref{ID: "mmlu"}, Parameters: map[string]any{"num_fewshot": 5, "split": "test"}},
+				{Ref: api.Ref{ID: "arc"}},
+			},
+			Experiment: api.ExperimentConfig{Name: "nightly", Tags: map[string]string{"team": "eval"}},
+		},
+		Results: &api.EvaluationJobResults{Benchmarks: []api.EvaluationJobBenchmarkResult{
+			{Name: "mmlu", Metrics: map[string]any{"acc": 0.75, "samples": float64(100), "notes": "n/a"}},
+			{Name: "arc", Error: &errorMessage},
+		}},
+	}
+
+	t.Run("each benchmark is a run of the experiment of the job", func(t *testing.T) {
+		runIDs, experimentURL, err := tracker.RecordResults(context.Background(), job, []string{"mmlu", "arc", "unknown"})
+		if err != nil {
+			t.Fatalf("RecordResults() returned error: %v", err)
+		}
+
+		expectedURL := server.URL + "/#/experiments/" + fake.experiments["nightly"]
+		if experimentURL != expectedURL {
+			t.Errorf("Expected the experiment URL %s, got %s", expectedURL, experimentURL)
+		}
+		if runID, found := runIDs["unknown"]; !found || runID != "" {
+			t.Errorf("Expected no run for a benchmark without results, got %q %v", runID, found)
+		}
+		mmlu := fake.runs[runIDs["mmlu"]]
+		if mmlu.name != "mmlu" || mmlu.status != "FINISHED" || mmlu.tags[mlflow.TagJobID] != "job-1" || mmlu.tags[mlflow.TagTenant] != "team-a" || mmlu.tags["team"] != "eval" {
+			t.Errorf("Unexpected run %+v", mmlu)
+		}
+		if fmt.Sprint(mmlu.params) != "map[num_fewshot:5 split:test]" || fmt.Sprint(mmlu.metrics) != "map[acc:0.75 samples:100]" {
+			t.Errorf("Unexpected params %v and metrics %v", mmlu.params, mmlu.metrics)
+		}
+		if arc := fake.runs[runIDs["arc"]]; arc.status != "FAILED" {
+			t.Errorf("Expected the failed benchmark to be a failed run, got %s", arc.status)
+		}
+	})
+
+	t.Run("the results with a run are logged to the same run", func(t *testing.T) {
+		runID := "run-1"
+		job.Results.Benchmarks[0].MLFlowRunID = &runID
+		job.Results.Benchmarks[0].Metrics = map[string]any{"acc": 0.8}
+		runIDs, _, err := tracker.RecordResults(context.Background(), job, []string{"mmlu"})
+		if err != nil {
+			t.Fatalf("RecordResults() returned error: %v", err)
+		}
+		if runIDs["mmlu"] != runID || fake.runs[runID].metrics["acc"] != 0.8 || len(fake.runs) != 2 {
+			t.Errorf("Expected the metrics to be logged to %s, got %v", runID, fake.runs[runID].metrics)
+		}
+	})
+
+	t.Run("the jobs without an experiment use the default experiment", func(t *testing.T) {
+		other := &api.EvaluationJobResource{
+			Resource: api.Resource{ID: "job-2"},
+			Results:  &api.EvaluationJobResults{Benchmarks: []api.EvaluationJobBenchmarkResult{{Name: "mmlu"}}},
+		}
+		runIDs, _, err := tracker.RecordResults(context.Background(), other, []string{"mmlu"})
+		if err != nil {
+			t.Fatalf("RecordResults() returned error: %v", err)
+		}
+		if _, found := fake.experiments["default"]; !found || runIDs["mmlu"] == "" {
+			t.Errorf("Expected a run of the default experiment, got %v", fake.experiments)
+		}
+	})
+
+	t.Run("the failures are returned and the benchmarks are left without a run", func(t *testing.T) {
+		fake.mu.Lock()
+		fake.failRuns = true
+		fake.mu.Unlock()
+		other := &api.EvaluationJobResource{
+			Resource: api.Resource{ID: "job-3"},
+			Results:  &api.EvaluationJobResults{Benchmarks: []api.EvaluationJobBenchmarkResult{{Name: "arc"}}},
+		}
+		runIDs, _, err := tracker.RecordResults(context.Background(), other, []string{"arc"})
+		if _, found := runIDs["arc"]; !mlflow.IsErrorCode(err, "TEMPORARILY_UNAVAILABLE") || found {
+			t.Errorf("Expected the MLflow error and no run, got %v %v", err, runIDs)
+		}
+	})
+}
+
+func TestRESTClient(t *testing.T) {
+	fake, server := newFakeMLflow(t, "secret")
+
+	t.Run("an experiment is created once", func(t *testing.T) {
+		client, err := mlflow.NewRESTClient(&config.MLflowConfig{TrackingURI: server.URL, Token: "secret"})
+		if err != nil {
+			t.Fatalf("NewRESTClient() returned error: %v", err)
+		}
+		first, err := client.GetOrCreateExperiment(context.Background(), "experiment", nil)
+		if err != nil {
+			t.Fatalf("GetOrCreateExperiment() returned error: %v", err)
+		}
+		if second, err := client.GetOrCreateExperiment(context.Background(), "experiment", nil); err != nil || second != first {
+			t.Errorf("Expected the experiment %s to be reused, got %s %v", first, second, err)
+		}
+	})
+
+	t.Run("the batches are split within the limits of the API", func(t *testing.T) {
+		client, _ := mlflow.NewRESTClient(&config.MLflowConfig{TrackingURI: server.URL, Token: "secret"})
+		runID, err := client.CreateRun(context.Background(), "1", "run", time.Now(), nil)
+		if err != nil {
+			t.Fatalf("CreateRun() returned error: %v", err)
+		}
+		params := map[string]string{}
+		for i := range 250 {
+			params[fmt.Sprintf("param-%d", i)] = strings.Repeat("x", 7000)
+		}
+		if err := client.LogBatch(context.Background(), runID, params, map[string]float64{"acc": 1}, time.Now()); err != nil {
+			t.Fatalf("LogBatch() returned error: %v", err)
+		}
+		if run := fake.runs[runID]; len(run.params) != 250 || run.metrics["acc"] != 1 {
+			t.Errorf("Expected all the params and the metric to be logged, got %d params", len(run.params))
+		}
+	})
+
+	t.Run("the error responses are returned as API errors", func(t *testing.T) {
+		client, _ := mlflow.NewRESTClient(&config.MLflowConfig{TrackingURI: server.URL, Token: "wrong"})
+		_, err := client.GetOrCreateExperiment(context.Background(), "experiment", nil)
+		if !mlflow.IsErrorCode(err, "UNAUTHENTICATED") {
+			t.Errorf("Expected an UNAUTHENTICATED error, got %v", err)
+		}
+	})
+
+	for _, trackingURI := range []string{"", "localhost:5000", "ftp://mlflow"} {
+		if _, err := mlflow.NewRESTClient(&config.MLflowConfig{TrackingURI: trackingURI}); err == nil {
+			t.Errorf("Expected an error for the tracking URI %q", trackingURI)
+		}
+	}
+	if tracker, err := mlflow.NewTracker(&config.MLflowConfig{TrackingURI: server.URL}, logging.FallbackLogger()); tracker != nil || err != nil {
+		t.Errorf("Expected no tracker when the tracking is not enabled, got %v %v", tracker, err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	fake, server := newFakeMLflow(t, "")
+	mlflowConfig := &config.MLflowConfig{Enabled: true, TrackingURI: server.URL, DefaultExperiment: "default"}
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, nil, mlflowConfig, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	recorder, err := mlflow.NewRecorder(mlflowConfig, storage, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewRecorder() returned error: %v", err)
+	}
+	recorder.Start()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := recorder.Stop(ctx); err != nil {
+			t.Errorf("Stop() returned error: %v", err)
+		}
+	}()
+
+	ctx := executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), "", "", "", "", nil, nil, "", "", "", time.Minute, 0, nil, nil, "")
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model:      api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "arc"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	report := func(t *testing.T, results *api.EvaluationJobResultsConfig) {
+		t.Helper()
+		if err := storage.UpsertEvaluationJobResults(ctx, job.ID, results); err != nil {
+			t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+		}
+	}
+	// waitFor waits until the run of the benchmark is stored with the results and has the metric
+	waitFor := func(t *testing.T, benchmark string, metric float64) *api.EvaluationJobResource {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			stored, err := storage.GetEvaluationJob(ctx, job.ID)
+			if err != nil {
+				t.Fatalf("GetEvaluationJob() returned error: %v", err)
+			}
+			for _, result := range stored.Results.Benchmarks {
+				if result.Name != benchmark || result.MLFlowRunID == nil {
+					continue
+				}
+				fake.mu.Lock()
+				recorded := fake.runs[*result.MLFlowRunID].metrics["acc"] == metric
+				fake.mu.Unlock()
+				if recorded {
+					return stored
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for the run of %s, got %+v", benchmark, stored.Results)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	runtimeRun := "runtime-run"
+	report(t, &api.EvaluationJobResultsConfig{Benchmarks: []api.BenchmarkResultConfig{
+		{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}},
+		{Name: "arc", MLFlowRunID: &runtimeRun},
+	}})
+	stored := waitFor(t, "mmlu", 0.5)
+	runID := *stored.Results.Benchmarks[0].MLFlowRunID
+
+	t.Run("the stored results are recorded in the background", func(t *testing.T) {
+		if stored.Results.MLFlowExperimentURL == nil || *stored.Results.MLFlowExperimentURL != server.URL+"/#/experiments/"+fake.experiments["default"] {
+			t.Errorf("Expected the experiment URL to be stored, got %v", stored.Results.MLFlowExperimentURL)
+		}
+		if arc := stored.Results.Benchmarks[1]; arc.MLFlowRunID == nil || *arc.MLFlowRunID != runtimeRun {
+			t.Errorf("Expected the run reported by the runtime to be kept, got %v", arc.MLFlowRunID)
+		}
+	})
+
+	t.Run("the results reported again are recorded in the same run", func(t *testing.T) {
+		report(t, &api.EvaluationJobResultsConfig{Benchmarks: []api.BenchmarkResultConfig{{Name: "mmlu", Metrics: map[string]any{"acc": 0.9}}}})
+		stored := waitFor(t, "mmlu", 0.9)
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		if *stored.Results.Benchmarks[0].MLFlowRunID != runID || len(fake.runs) != 1 {
+			t.Errorf("Expected a single run %s, got %s and %d runs", runID, *stored.Results.Benchmarks[0].MLFlowRunID, len(fake.runs))
+		}
+	})
+
+	if recorder, err := mlflow.NewRecorder(&config.MLflowConfig{TrackingURI: server.URL}, storage, logging.FallbackLogger()); recorder != nil || err != nil {
+		t.Errorf("Expected no recorder when the tracking is not enabled, got %v %v", recorder, err)
+	}
+}
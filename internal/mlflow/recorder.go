@@ -0,0 +1,199 @@
+package mlflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+)
+
+const (
+	// recordingPollInterval is the interval at which the due recordings are claimed
+	recordingPollInterval = time.Second
+	// recordingInitialBackoff and recordingMaxBackoff bound the wait before a failed recording
+	// is attempted again, the wait is doubled after each failed attempt
+	recordingInitialBackoff = 10 * time.Second
+	recordingMaxBackoff     = 10 * time.Minute
+)
+
+// Recorder records the results written to the outbox by the storage in MLflow, the results are
+// stored first and recorded in the background so that the reports of the results do not wait
+// for MLflow. A recording is claimed by one replica at a time so that the results of a benchmark
+// are recorded in a single run, the failed recordings are retried with an exponential backoff.
+type Recorder struct {
+	tracker *Tracker
+	storage abstractions.Storage
+	logger  *slog.Logger
+	owner   string
+	// lease is the lease of a claimed recording, a recording is given half of the lease to
+	// complete so that it is not claimed by another replica while it is running
+	lease time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRecorder returns nil when the MLflow tracking is not enabled
+func NewRecorder(mlflowConfig *config.MLflowConfig, storage abstractions.Storage, logger *slog.Logger) (*Recorder, error) {
+	tracker, err := NewTracker(mlflowConfig, logger)
+	if err != nil || tracker == nil {
+		return nil, err
+	}
+	return NewRecorderWithTracker(tracker, storage, mlflowConfig.Timeout, logger), nil
+}
+
+// NewRecorderWithTracker returns a recorder that uses the tracker, the timeout is the timeout of
+// a request to MLflow
+func NewRecorderWithTracker(tracker *Tracker, storage abstractions.Storage, timeout time.Duration, logger *slog.Logger) *Recorder {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "eval-hub"
+	}
+	owner := fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	return &Recorder{
+		tracker: tracker,
+		storage: storage,
+		logger:  logger.With("mlflow_recorder", owner),
+		owner:   owner,
+		lease:   10 * max(timeout, config.DefaultMLflowTimeout),
+	}
+}
+
+// Start starts recording the due results in the background
+func (r *Recorder) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.run(ctx)
+}
+
+// Stop stops claiming recordings and waits for the running recording until the context is done,
+// a recording that is not completed is claimed again once its lease has expired
+func (r *Recorder) Stop(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("MLflow recording is still running: %w", ctx.Err())
+	}
+}
+
+func (r *Recorder) run(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(recordingPollInterval)
+	defer ticker.Stop()
+	for {
+		r.recordDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordDue records the recordings that are due until no recording is due or the recorder stops
+func (r *Recorder) recordDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		recording, err := r.storage.ClaimResultsRecording(r.executionContext(""), r.owner, r.lease)
+		if err != nil {
+			r.logger.Error("Failed to claim an MLflow recording", "error", err.Error())
+		}
+		if recording == nil {
+			return
+		}
+		r.record(recording)
+	}
+}
+
+// record records the results of the benchmarks of the recording and completes it, the
+// benchmarks that could not be recorded are attempted again after the backoff
+func (r *Recorder) record(recording *abstractions.ResultsRecording) {
+	ctx := r.executionContext(recording.JobID)
+	recording.RunIDs = map[string]string{}
+
+	names := make([]string, 0, len(recording.Benchmarks))
+	for name := range recording.Benchmarks {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	job, err := r.storage.GetEvaluationJob(ctx, recording.JobID)
+	switch {
+	case errors.Is(err, abstractions.ErrNotFound):
+		// the job has been deleted, there is nothing left to record
+		err = nil
+		for _, name := range names {
+			recording.RunIDs[name] = ""
+		}
+	case err != nil:
+		err = fmt.Errorf("failed to read the evaluation job: %w", err)
+	default:
+		recordCtx, cancel := context.WithTimeout(context.Background(), r.lease/2)
+		recording.RunIDs, recording.ExperimentURL, err = r.tracker.RecordResults(recordCtx, job, names)
+		cancel()
+	}
+
+	if err != nil {
+		recording.Attempts++
+		nextAttemptAt := time.Now().Add(r.backoff(recording.Attempts))
+		recording.NextAttemptAt = &nextAttemptAt
+		ctx.Logger.Warn("Failed to record the results in MLflow", "attempt", recording.Attempts, "next_attempt_at", nextAttemptAt, "error", err.Error())
+	} else {
+		ctx.Logger.Info("Recorded the results in MLflow", "benchmarks", len(recording.RunIDs))
+	}
+
+	if err := r.storage.CompleteResultsRecording(ctx, recording, r.owner); err != nil {
+		ctx.Logger.Error("Failed to complete the MLflow recording", "error", err.Error())
+	}
+}
+
+// backoff returns the time to wait after the given number of failed attempts
+func (r *Recorder) backoff(attempts int) time.Duration {
+	backoff := recordingInitialBackoff
+	for i := 1; i < attempts && backoff < recordingMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, recordingMaxBackoff)
+}
+
+// executionContext returns the context used for the storage calls, there is no request so
+// the job id is used as the request id and the jobs of all the tenants are recorded
+func (r *Recorder) executionContext(jobID string) *executioncontext.ExecutionContext {
+	logger := r.logger
+	if jobID != "" {
+		logger = logger.With("job_id", jobID)
+	}
+	return executioncontext.NewExecutionContext(
+		context.Background(),
+		jobID,
+		logger,
+		"",
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		jobID,
+		"",
+		"",
+		constants.DefaultJobTimeout,
+		constants.DefaultJobRetryAttempts,
+		make(map[string]interface{}),
+		nil,
+		"",
+	)
+}
@@ -0,0 +1,207 @@
+package mlflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	// TagJobID, TagBenchmark and TagTenant are set on the runs of the benchmarks
+	TagJobID     = "eval_hub.job_id"
+	TagBenchmark = "eval_hub.benchmark"
+	TagTenant    = "eval_hub.tenant"
+)
+
+// Tracker records the results of the benchmarks in MLflow, each benchmark of a job is a run of
+// the experiment of the job with the parameters of the benchmark and its final metrics
+type Tracker struct {
+	client            Client
+	defaultExperiment string
+	logger            *slog.Logger
+}
+
+// NewTracker returns nil when the MLflow tracking is not enabled
+func NewTracker(mlflowConfig *config.MLflowConfig, logger *slog.Logger) (*Tracker, error) {
+	if mlflowConfig == nil || !mlflowConfig.Enabled {
+		return nil, nil
+	}
+	client, err := NewRESTClient(mlflowConfig)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Tracking the results in MLflow", "tracking_uri", mlflowConfig.TrackingURI, "default_experiment", mlflowConfig.DefaultExperiment)
+	return NewTrackerWithClient(client, mlflowConfig.DefaultExperiment, logger), nil
+}
+
+// NewTrackerWithClient returns a tracker that uses the client, the jobs without an experiment
+// are recorded in the default experiment
+func NewTrackerWithClient(client Client, defaultExperiment string, logger *slog.Logger) *Tracker {
+	return &Tracker{client: client, defaultExperiment: defaultExperiment, logger: logger}
+}
+
+// RecordResults records the stored results of the named benchmarks of the job and returns the
+// run id of each recorded benchmark and the URL of the experiment. A benchmark whose result has a
+// run id has its metrics logged to the same run. The benchmarks of a job that is not tracked and
+// the benchmarks without a stored result have no run, their run id is empty. The benchmarks that
+// could not be recorded are left out of the run ids and the errors are returned.
+func (t *Tracker) RecordResults(ctx context.Context, job *api.EvaluationJobResource, names []string) (map[string]string, string, error) {
+	runIDs := map[string]string{}
+	name := job.Experiment.Name
+	if name == "" {
+		name = t.defaultExperiment
+	}
+	if name == "" {
+		for _, benchmark := range names {
+			runIDs[benchmark] = ""
+		}
+		return runIDs, "", nil
+	}
+	experimentID, err := t.client.GetOrCreateExperiment(ctx, name, job.Experiment.Tags)
+	if err != nil {
+		return runIDs, "", fmt.Errorf("failed to get the MLflow experiment %s: %w", name, err)
+	}
+
+	var errs []error
+	for _, benchmark := range names {
+		result := storedResult(job, benchmark)
+		if result == nil || !isJobBenchmark(job, benchmark) {
+			runIDs[benchmark] = ""
+			continue
+		}
+		runID, err := t.trackBenchmark(ctx, job, experimentID, result)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to record the benchmark %s in MLflow: %w", benchmark, err))
+			continue
+		}
+		runIDs[benchmark] = runID
+	}
+	return runIDs, t.client.ExperimentURL(experimentID), errors.Join(errs...)
+}
+
+// trackBenchmark records the result in the run of the benchmark and returns the id of the run
+func (t *Tracker) trackBenchmark(ctx context.Context, job *api.EvaluationJobResource, experimentID string, result *api.EvaluationJobBenchmarkResult) (string, error) {
+	now := time.Now().UTC()
+	startTime, endTime := now, now
+	if result.StartedAt != nil {
+		startTime = *result.StartedAt
+	}
+	if result.CompletedAt != nil {
+		endTime = *result.CompletedAt
+	}
+
+	// the params of a run can not be changed so they are only logged when the run is created
+	var params map[string]string
+	var runID string
+	if result.MLFlowRunID != nil {
+		runID = *result.MLFlowRunID
+	} else {
+		tags := map[string]string{TagJobID: job.ID, TagBenchmark: result.Name}
+		if job.Tenant != "" {
+			tags[TagTenant] = string(job.Tenant)
+		}
+		for key, value := range job.Experiment.Tags {
+			tags[key] = value
+		}
+		var err error
+		if runID, err = t.client.CreateRun(ctx, experimentID, result.Name, startTime, tags); err != nil {
+			return "", err
+		}
+		params = benchmarkParams(job, result.Name)
+	}
+
+	metrics := map[string]float64{}
+	for key, value := range result.Metrics {
+		if number, ok := toFloat(value); ok {
+			metrics[key] = number
+		} else {
+			t.logger.Debug("Skipping the metric that is not a number", "job_id", job.ID, "benchmark", result.Name, "metric", key)
+		}
+	}
+	if err := t.client.LogBatch(ctx, runID, params, metrics, endTime); err != nil {
+		return "", err
+	}
+
+	status := RunStatusFinished
+	if result.Error != nil {
+		status = RunStatusFailed
+	}
+	if err := t.client.TerminateRun(ctx, runID, status, endTime); err != nil {
+		return "", err
+	}
+	return runID, nil
+}
+
+// isJobBenchmark returns true if the benchmark is requested by the job, any benchmark is
+// accepted for a job that does not list its benchmarks
+func isJobBenchmark(job *api.EvaluationJobResource, name string) bool {
+	return len(job.Benchmarks) == 0 || slices.ContainsFunc(job.Benchmarks, func(benchmark api.BenchmarkConfig) bool {
+		return benchmark.ID == name
+	})
+}
+
+// storedResult returns the stored result of the benchmark or nil if it has not been reported
+func storedResult(job *api.EvaluationJobResource, name string) *api.EvaluationJobBenchmarkResult {
+	if job.Results == nil {
+		return nil
+	}
+	for i := range job.Results.Benchmarks {
+		if job.Results.Benchmarks[i].Name == name {
+			return &job.Results.Benchmarks[i]
+		}
+	}
+	return nil
+}
+
+// benchmarkParams returns the parameters of the benchmark as strings, the values that are not
+// strings are JSON encoded
+func benchmarkParams(job *api.EvaluationJobResource, name string) map[string]string {
+	params := map[string]string{}
+	for _, benchmark := range job.Benchmarks {
+		if benchmark.ID != name {
+			continue
+		}
+		for key, value := range benchmark.Parameters {
+			if s, ok := value.(string); ok {
+				params[key] = s
+				continue
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				continue
+			}
+			params[key] = string(encoded)
+		}
+	}
+	return params
+}
+
+// toFloat returns the value of a numeric metric, the metrics decoded from JSON are float64
+func toFloat(value any) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case float32:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case json.Number:
+		f, err := number.Float64()
+		return f, err == nil
+	case bool:
+		if number {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
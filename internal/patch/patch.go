@@ -0,0 +1,375 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+var (
+	ErrInvalidOperation = errors.New("invalid operation")
+	ErrInvalidPath      = errors.New("invalid path")
+	ErrPathNotFound     = errors.New("path not found")
+	ErrImmutable        = errors.New("immutable field")
+	ErrTestFailed       = errors.New("test failed")
+	ErrInvalidResult    = errors.New("invalid result")
+)
+
+// ImmutableResourceFields are the paths of the fields of api.Resource, these are managed by
+// the service and can not be patched
+var ImmutableResourceFields = []string{"/id", "/tenant", "/owner", "/created_at", "/updated_at"}
+
+// errorTypes are the suffixes of the validation error types of the patch errors
+var errorTypes = map[error]string{
+	ErrInvalidOperation: "invalid_operation",
+	ErrInvalidPath:      "invalid_path",
+	ErrPathNotFound:     "path_not_found",
+	ErrImmutable:        "immutable",
+	ErrTestFailed:       "test_failed",
+}
+
+// Error is the error of an operation of the patch, the field is the member of the operation
+// that caused the error (op, path, from or value)
+type Error struct {
+	Index int
+	Field string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("patch operation %d: %v", e.Index, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError returns the error located at the member of the operation in the request body
+func (e *Error) ValidationError() api.ValidationError {
+	errorType := "value_error.patch"
+	for err, suffix := range errorTypes {
+		if errors.Is(e.Err, err) {
+			errorType += "." + suffix
+			break
+		}
+	}
+	return api.ValidationError{
+		Loc:  []any{validation.BodyLoc, e.Index, e.Field},
+		Msg:  e.Err.Error(),
+		Type: errorType,
+	}
+}
+
+// Apply applies the operations of the patch in order to a document decoded from JSON, that is
+// made of maps, slices and values. The document is not changed, the patched document is
+// returned only when all the operations succeed. The operations that change one of the
+// immutable paths, or a parent of one of them, are rejected.
+func Apply(document any, patch api.Patch, immutable ...string) (any, error) {
+	document, err := deepCopy(document)
+	if err != nil {
+		return nil, err
+	}
+	for i, operation := range patch {
+		document, err = apply(document, operation, immutable)
+		if err != nil {
+			var patchError *Error
+			if errors.As(err, &patchError) {
+				patchError.Index = i
+				return nil, patchError
+			}
+			return nil, &Error{Index: i, Field: "path", Err: err}
+		}
+	}
+	return document, nil
+}
+
+// ApplyTo applies the patch to the JSON representation of the resource, the patched document
+// must decode into the type of the resource without unknown fields. The resource is only
+// changed when the whole patch is applied.
+func ApplyTo[T any](resource *T, patch api.Patch, immutable ...string) error {
+	jsonBytes, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+	var document any
+	if err := json.Unmarshal(jsonBytes, &document); err != nil {
+		return err
+	}
+	document, err = Apply(document, patch, immutable...)
+	if err != nil {
+		return err
+	}
+	if jsonBytes, err = json.Marshal(document); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.DisallowUnknownFields()
+	patched := new(T)
+	if err := decoder.Decode(patched); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+	*resource = *patched
+	return nil
+}
+
+func apply(document any, operation api.PatchOperation, immutable []string) (any, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, &Error{Field: "path", Err: err}
+	}
+	var from []string
+	if operation.Op == api.PatchOpMove || operation.Op == api.PatchOpCopy {
+		if from, err = parsePointer(operation.From); err != nil {
+			return nil, &Error{Field: "from", Err: err}
+		}
+	}
+	if operation.Op != api.PatchOpTest && isImmutable(operation.Path, immutable) {
+		return nil, &Error{Field: "path", Err: fmt.Errorf("%w %s", ErrImmutable, operation.Path)}
+	}
+	var value any
+	if operation.Op == api.PatchOpAdd || operation.Op == api.PatchOpReplace || operation.Op == api.PatchOpTest {
+		// a missing value is not the same as null, which is a valid value
+		if len(operation.Value) == 0 {
+			return nil, &Error{Field: "value", Err: fmt.Errorf("%w: the %s operation requires a value", ErrInvalidOperation, operation.Op)}
+		}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, &Error{Field: "value", Err: fmt.Errorf("%w: %v", ErrInvalidOperation, err)}
+		}
+	}
+
+	switch operation.Op {
+	case api.PatchOpAdd:
+		return add(document, path, value)
+	case api.PatchOpRemove:
+		document, _, err = remove(document, path)
+		return document, err
+	case api.PatchOpReplace:
+		if _, err := get(document, path); err != nil {
+			return nil, err
+		}
+		document, _, err = remove(document, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(document, path, value)
+	case api.PatchOpMove:
+		if isImmutable(operation.From, immutable) {
+			return nil, &Error{Field: "from", Err: fmt.Errorf("%w %s", ErrImmutable, operation.From)}
+		}
+		if operation.Path != operation.From && strings.HasPrefix(operation.Path, operation.From+"/") {
+			return nil, &Error{Field: "path", Err: fmt.Errorf("%w: %s can not be moved into one of its children", ErrInvalidPath, operation.From)}
+		}
+		document, value, err := remove(document, from)
+		if err != nil {
+			return nil, &Error{Field: "from", Err: err}
+		}
+		return add(document, path, value)
+	case api.PatchOpCopy:
+		value, err := get(document, from)
+		if err != nil {
+			return nil, &Error{Field: "from", Err: err}
+		}
+		if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+		return add(document, path, value)
+	case api.PatchOpTest:
+		current, err := get(document, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, &Error{Field: "value", Err: fmt.Errorf("%w: the value of %s is different", ErrTestFailed, operation.Path)}
+		}
+		return document, nil
+	default:
+		return nil, &Error{Field: "op", Err: fmt.Errorf("%w %q", ErrInvalidOperation, operation.Op)}
+	}
+}
+
+// parsePointer returns the reference tokens of a JSON pointer, the empty pointer is the
+// whole document
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("%w %q, a JSON pointer must start with /", ErrInvalidPath, path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j == len(token)-1 || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, fmt.Errorf("%w %q, ~ must be followed by 0 or 1", ErrInvalidPath, path)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// isImmutable returns true when the path is one of the immutable paths, one of their children
+// or one of their parents
+func isImmutable(path string, immutable []string) bool {
+	return slices.ContainsFunc(immutable, func(field string) bool {
+		return path == field || strings.HasPrefix(path, field+"/") || strings.HasPrefix(field, path+"/") || path == ""
+	})
+}
+
+func get(document any, path []string) (any, error) {
+	value := document
+	for i, token := range path {
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, notFound(path[:i+1])
+			}
+			value = child
+		case []any:
+			index, err := arrayIndex(token, len(node)-1, path[:i+1])
+			if err != nil {
+				return nil, err
+			}
+			value = node[index]
+		default:
+			return nil, notFound(path[:i+1])
+		}
+	}
+	return value, nil
+}
+
+// add returns the document with the value added at the path, the parent must exist. The
+// member of an object is replaced and the value is inserted in an array, - appends it.
+func add(document any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(document, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			index, err := arrayIndex(token, len(node), path)
+			if err != nil {
+				return nil, err
+			}
+			return slices.Insert(node, index, value), nil
+		default:
+			return nil, notFound(path[:len(path)-1])
+		}
+	})
+}
+
+// remove returns the document without the value at the path and the removed value
+func remove(document any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, document, nil
+	}
+	var removed any
+	document, err := update(document, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, notFound(path)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			index, err := arrayIndex(token, len(node)-1, path)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[index]
+			return slices.Delete(node, index, index+1), nil
+		default:
+			return nil, notFound(path)
+		}
+	})
+	return document, removed, err
+}
+
+// update calls the change function with the parent of the path and the last token, the
+// parent returned by the function replaces the parent in the document
+func update(document any, path []string, change func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return change(document, path[0])
+	}
+	switch node := document.(type) {
+	case map[string]any:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, notFound(path[:1])
+		}
+		child, err := update(child, path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = child
+		return node, nil
+	case []any:
+		index, err := arrayIndex(path[0], len(node)-1, path[:1])
+		if err != nil {
+			return nil, err
+		}
+		child, err := update(node[index], path[1:], change)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+		return node, nil
+	default:
+		return nil, notFound(path[:1])
+	}
+}
+
+// arrayIndex parses the index of an array, the index must not be greater than the maximum
+func arrayIndex(token string, maxIndex int, path []string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w %s, %q is not an array index", ErrInvalidPath, pointer(path), token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > maxIndex {
+		return 0, notFound(path)
+	}
+	return index, nil
+}
+
+func notFound(path []string) error {
+	return fmt.Errorf("%w %s", ErrPathNotFound, pointer(path))
+}
+
+func pointer(path []string) string {
+	var builder strings.Builder
+	for _, token := range path {
+		builder.WriteString("/")
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return builder.String()
+}
+
+// deepCopy copies the value through its JSON representation, this also converts the
+// numbers to float64 so that the values can be compared
+func deepCopy(value any) (any, error) {
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied any
+	err = json.Unmarshal(jsonBytes, &copied)
+	return copied, err
+}
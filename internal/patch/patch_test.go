@@ -0,0 +1,163 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/patch"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const document = `{
+	"id": "c1",
+	"tenant": "t1",
+	"name": "reasoning",
+	"tags": ["a", "b"],
+	"benchmarks": [
+		{"id": "arc_challenge", "parameters": {"num_fewshot": 25}},
+		{"id": "hellaswag"}
+	],
+	"a/b": {"~c": 1}
+}`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		path     string
+		expected string
+	}{
+		{"replace a member", `[{"op":"replace","path":"/name","value":"knowledge"}]`, "/name", `"knowledge"`},
+		{"add a member", `[{"op":"add","path":"/description","value":"d"}]`, "/description", `"d"`},
+		{"add to an array", `[{"op":"add","path":"/tags/1","value":"c"}]`, "/tags", `["a","c","b"]`},
+		{"append to an array", `[{"op":"add","path":"/tags/-","value":"c"}]`, "/tags", `["a","b","c"]`},
+		{"remove from an array", `[{"op":"remove","path":"/benchmarks/0"}]`, "/benchmarks", `[{"id":"hellaswag"}]`},
+		{"remove a nested member", `[{"op":"remove","path":"/benchmarks/0/parameters/num_fewshot"}]`, "/benchmarks/0/parameters", `{}`},
+		{"replace a nested member", `[{"op":"replace","path":"/benchmarks/0/parameters/num_fewshot","value":5}]`, "/benchmarks/0/parameters/num_fewshot", `5`},
+		{"escaped pointer", `[{"op":"replace","path":"/a~1b/~0c","value":2}]`, "/a~1b", `{"~c":2}`},
+		{"move", `[{"op":"move","from":"/benchmarks/1","path":"/benchmarks/0"}]`, "/benchmarks/0", `{"id":"hellaswag"}`},
+		{"copy", `[{"op":"copy","from":"/benchmarks/0/parameters","path":"/benchmarks/1/parameters"}]`, "/benchmarks/1", `{"id":"hellaswag","parameters":{"num_fewshot":25}}`},
+		{"test and replace", `[{"op":"test","path":"/benchmarks/0/parameters/num_fewshot","value":25},{"op":"replace","path":"/name","value":"x"}]`, "/name", `"x"`},
+		{"test an immutable field", `[{"op":"test","path":"/id","value":"c1"}]`, "/id", `"c1"`},
+		{"replace with null", `[{"op":"replace","path":"/name","value":null}]`, "/name", `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := decode(t, document)
+			patched, err := patch.Apply(original, decodePatch(t, tt.patch), patch.ImmutableResourceFields...)
+			if err != nil {
+				t.Fatalf("Apply() returned error: %v", err)
+			}
+			if _, err := patch.Apply(patched, api.Patch{{Op: api.PatchOpTest, Path: tt.path, Value: json.RawMessage(tt.expected)}}); err != nil {
+				t.Errorf("Expected %s at %s: %v", tt.expected, tt.path, err)
+			}
+			if !reflect.DeepEqual(original, decode(t, document)) {
+				t.Errorf("Expected the original document to be unchanged")
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected error
+		index    int
+		field    string
+	}{
+		{"unknown operation", `[{"op":"merge","path":"/name"}]`, patch.ErrInvalidOperation, 0, "op"},
+		{"relative path", `[{"op":"replace","path":"name","value":"x"}]`, patch.ErrInvalidPath, 0, "path"},
+		{"invalid escape", `[{"op":"replace","path":"/a~2b","value":"x"}]`, patch.ErrInvalidPath, 0, "path"},
+		{"invalid index", `[{"op":"add","path":"/tags/01","value":"x"}]`, patch.ErrInvalidPath, 0, "path"},
+		{"index out of range", `[{"op":"add","path":"/tags/3","value":"x"}]`, patch.ErrPathNotFound, 0, "path"},
+		{"append is not an element", `[{"op":"remove","path":"/tags/-"}]`, patch.ErrInvalidPath, 0, "path"},
+		{"replace a missing member", `[{"op":"replace","path":"/description","value":"x"}]`, patch.ErrPathNotFound, 0, "path"},
+		{"remove a missing member", `[{"op":"remove","path":"/benchmarks/1/parameters"}]`, patch.ErrPathNotFound, 0, "path"},
+		{"missing parent", `[{"op":"add","path":"/missing/name","value":"x"}]`, patch.ErrPathNotFound, 0, "path"},
+		{"missing from", `[{"op":"copy","from":"/missing","path":"/name"}]`, patch.ErrPathNotFound, 0, "from"},
+		{"move into a child", `[{"op":"move","from":"/benchmarks","path":"/benchmarks/0/benchmarks"}]`, patch.ErrInvalidPath, 0, "path"},
+		{"failed test", `[{"op":"replace","path":"/name","value":"x"},{"op":"test","path":"/name","value":"reasoning"}]`, patch.ErrTestFailed, 1, "value"},
+		{"replace an immutable field", `[{"op":"replace","path":"/id","value":"c2"}]`, patch.ErrImmutable, 0, "path"},
+		{"remove an immutable field", `[{"op":"remove","path":"/tenant"}]`, patch.ErrImmutable, 0, "path"},
+		{"replace the document", `[{"op":"replace","path":"","value":{}}]`, patch.ErrImmutable, 0, "path"},
+		{"move an immutable field", `[{"op":"move","from":"/id","path":"/name"}]`, patch.ErrImmutable, 0, "from"},
+		{"add without a value", `[{"op":"add","path":"/description"}]`, patch.ErrInvalidOperation, 0, "value"},
+		{"replace without a value", `[{"op":"replace","path":"/name"}]`, patch.ErrInvalidOperation, 0, "value"},
+		{"test without a value", `[{"op":"test","path":"/name"}]`, patch.ErrInvalidOperation, 0, "value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := decode(t, document)
+			_, err := patch.Apply(original, decodePatch(t, tt.patch), patch.ImmutableResourceFields...)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, err)
+			}
+			var patchError *patch.Error
+			if !errors.As(err, &patchError) {
+				t.Fatalf("Expected a patch error, got %T", err)
+			}
+			detail := patchError.ValidationError()
+			if !reflect.DeepEqual(detail.Loc, []any{"body", tt.index, tt.field}) {
+				t.Errorf("Expected the location of the operation %d %s, got %v", tt.index, tt.field, detail.Loc)
+			}
+			if !reflect.DeepEqual(original, decode(t, document)) {
+				t.Errorf("Expected the original document to be unchanged")
+			}
+		})
+	}
+}
+
+func TestApplyTo(t *testing.T) {
+	description := "old"
+	collection := &api.CollectionResource{
+		Resource: api.Resource{ID: "c1"},
+		CollectionConfig: api.CollectionConfig{
+			Name:        "reasoning",
+			Description: &description,
+			Benchmarks:  []api.BenchmarkConfig{{Ref: api.Ref{ID: "arc_challenge"}}},
+		},
+	}
+
+	err := patch.ApplyTo(collection, decodePatch(t, `[
+		{"op":"remove","path":"/description"},
+		{"op":"add","path":"/benchmarks/-","value":{"id":"hellaswag","limit":10}}
+	]`), patch.ImmutableResourceFields...)
+	if err != nil {
+		t.Fatalf("ApplyTo() returned error: %v", err)
+	}
+	if collection.ID != "c1" || collection.Description != nil || len(collection.Benchmarks) != 2 || *collection.Benchmarks[1].Limit != 10 {
+		t.Errorf("Unexpected patched collection: %+v", collection)
+	}
+
+	before := *collection
+	if err := patch.ApplyTo(collection, decodePatch(t, `[{"op":"add","path":"/unknown","value":1}]`)); !errors.Is(err, patch.ErrInvalidResult) {
+		t.Errorf("Expected ErrInvalidResult for an unknown field, got %v", err)
+	}
+	if err := patch.ApplyTo(collection, decodePatch(t, `[{"op":"replace","path":"/name","value":1}]`)); !errors.Is(err, patch.ErrInvalidResult) {
+		t.Errorf("Expected ErrInvalidResult for a value of the wrong type, got %v", err)
+	}
+	if !reflect.DeepEqual(before, *collection) {
+		t.Errorf("Expected the collection to be unchanged when the patch fails")
+	}
+}
+
+func decode(t *testing.T, value string) any {
+	t.Helper()
+	var decoded any
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", value, err)
+	}
+	return decoded
+}
+
+func decodePatch(t *testing.T, value string) api.Patch {
+	t.Helper()
+	operations := api.Patch{}
+	if err := json.Unmarshal([]byte(value), &operations); err != nil {
+		t.Fatalf("Failed to unmarshal the patch %s: %v", value, err)
+	}
+	return operations
+}
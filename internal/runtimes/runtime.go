@@ -0,0 +1,30 @@
+package runtimes
+
+import (
+	"log/slog"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_k8s"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_local"
+)
+
+// NewRuntime returns the first enabled runtime, if no runtime is enabled then nil is
+// returned and the evaluation jobs are only stored. The local runtime writes the output of the
+// benchmarks to the log store, the logs of the k8s runtime are the logs of the pods.
+func NewRuntime(serviceConfig *config.Config, logStore abstractions.LogStore, logger *slog.Logger) (abstractions.Runtime, error) {
+	if serviceConfig.Runtime == nil {
+		logger.Info("No runtime configured")
+		return nil, nil
+	}
+	if (serviceConfig.Runtime.Local != nil) && serviceConfig.Runtime.Local.Enabled {
+		logger.Info("Using local runtime configuration")
+		return runtime_local.NewLocalRuntime(serviceConfig.Runtime.Local, logStore, logger)
+	}
+	if (serviceConfig.Runtime.K8s != nil) && serviceConfig.Runtime.K8s.Enabled {
+		logger.Info("Using k8s runtime configuration")
+		return runtime_k8s.NewK8sRuntime(serviceConfig.Runtime.K8s, logger)
+	}
+	logger.Info("No runtime enabled")
+	return nil, nil
+}
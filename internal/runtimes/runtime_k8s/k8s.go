@@ -0,0 +1,625 @@
+package runtime_k8s
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// LabelJobID is set on the Jobs, pods, ConfigMaps and Secrets of an evaluation job
+	LabelJobID = "eval-hub/job-id"
+	// LabelBenchmarkIndex is the index of the benchmark in the evaluation job
+	LabelBenchmarkIndex = "eval-hub/benchmark-index"
+	// AnnotationBenchmarkID is set on the pods of a benchmark so that its log can be found by
+	// the id of the benchmark, the id is not always a valid label value
+	AnnotationBenchmarkID = "eval-hub/benchmark-id"
+
+	// ConfigMountPath is where the benchmark ConfigMap is mounted in the containers
+	ConfigMountPath = "/etc/eval-hub"
+
+	configVolumeName = "eval-hub-benchmark"
+	// resultsTokenKey is the key of the results token in the benchmark Secret
+	resultsTokenKey = "results_token"
+	cleanupTimeout  = 30 * time.Second
+)
+
+//go:embed job_template.yaml
+var defaultJobTemplate string
+
+// K8sRuntime runs each benchmark of an evaluation job as a Kubernetes batch/v1 Job and
+// follows the progress of the benchmarks by watching the pods of the Jobs.
+type K8sRuntime struct {
+	config   *config.K8sRuntimeConfig
+	client   kubernetes.Interface
+	logger   *slog.Logger
+	template *template.Template
+}
+
+// templateData is the data used to render the Job template
+type templateData struct {
+	Name               string
+	Namespace          string
+	Image              string
+	ServiceAccountName string
+	Job                *api.EvaluationJobResource
+	Model              api.ModelRef
+	Benchmark          api.BenchmarkConfig
+}
+
+// NewK8sRuntime creates the runtime with a client built from the kubeconfig file or
+// from the in-cluster configuration when no kubeconfig is set
+func NewK8sRuntime(k8sConfig *config.K8sRuntimeConfig, logger *slog.Logger) (abstractions.Runtime, error) {
+	client, err := NewClient(k8sConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewK8sRuntimeWithClient(k8sConfig, client, logger)
+}
+
+// NewClient returns a client built from the kubeconfig file or from the in-cluster
+// configuration when no kubeconfig is set
+func NewClient(k8sConfig *config.K8sRuntimeConfig) (kubernetes.Interface, error) {
+	var restConfig *rest.Config
+	var err error
+	if k8sConfig.Kubeconfig != "" {
+		restConfig, err = clientcmd.BuildConfigFromFlags("", k8sConfig.Kubeconfig)
+	} else {
+		restConfig, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Kubernetes configuration: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the Kubernetes client: %w", err)
+	}
+	return client, nil
+}
+
+// NewK8sRuntimeWithClient creates the runtime with the given client, this is used by the
+// tests to run against a fake clientset
+func NewK8sRuntimeWithClient(k8sConfig *config.K8sRuntimeConfig, client kubernetes.Interface, logger *slog.Logger) (abstractions.Runtime, error) {
+	if k8sConfig.Namespace == "" {
+		return nil, fmt.Errorf("the k8s runtime requires a namespace")
+	}
+	text := defaultJobTemplate
+	if k8sConfig.JobTemplate != "" {
+		content, err := os.ReadFile(k8sConfig.JobTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the job template %s: %w", k8sConfig.JobTemplate, err)
+		}
+		text = string(content)
+	}
+	tmpl, err := template.New("job").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job template: %w", err)
+	}
+	logger.Info("Creating k8s runtime", "namespace", k8sConfig.Namespace, "image", k8sConfig.Image)
+	return &K8sRuntime{
+		config:   k8sConfig,
+		client:   client,
+		logger:   logger,
+		template: tmpl,
+	}, nil
+}
+
+func (r *K8sRuntime) GetRuntimeName() string {
+	return "k8s"
+}
+
+// RunEvaluationJob creates one Job per benchmark and blocks until all the benchmarks have
+// finished. Each Job is retried up to RetryAttempts times and all the Jobs are deleted
+// when TimeoutMinutes is exceeded.
+func (r *K8sRuntime) RunEvaluationJob(ctx *executioncontext.ExecutionContext, evaluation *api.EvaluationJobResource, storage abstractions.Storage) error {
+	timeout := ctx.Timeout
+	if evaluation.TimeoutMinutes != nil {
+		timeout = time.Duration(*evaluation.TimeoutMinutes) * time.Minute
+	}
+	retryAttempts := ctx.RetryAttempts
+	if evaluation.RetryAttempts != nil {
+		retryAttempts = *evaluation.RetryAttempts
+	}
+	logger := ctx.Logger.With("job_id", evaluation.ID, "runtime", r.GetRuntimeName())
+
+	jobCtx, cancel := context.WithTimeout(ctx.Ctx, timeout)
+	defer cancel()
+
+	if len(evaluation.Benchmarks) == 0 {
+		return storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: "Evaluation job has no benchmarks",
+		})
+	}
+
+	if err := storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{
+		State:   api.StateRunning,
+		Message: "Evaluation job running",
+	}); err != nil {
+		return err
+	}
+
+	tracker := &jobTracker{
+		runtime:       r,
+		ctx:           ctx,
+		logger:        logger,
+		storage:       storage,
+		evaluation:    evaluation,
+		retryAttempts: retryAttempts,
+		benchmarks:    make([]*benchmarkTracker, len(evaluation.Benchmarks)),
+	}
+	// the benchmarks that have finished before the job was recovered from another replica are not run again
+	previous := map[string]api.BenchmarkStatus{}
+	for _, status := range evaluation.Status.Benchmarks {
+		previous[status.Name] = status
+	}
+	for i, benchmark := range evaluation.Benchmarks {
+		status, ok := previous[benchmark.ID]
+		if !ok {
+			status = api.BenchmarkStatus{Name: benchmark.ID, State: api.StatePending}
+		}
+		tracker.benchmarks[i] = &benchmarkTracker{
+			status:     status,
+			failedPods: map[string]bool{},
+		}
+		if statemachine.IsTerminal(status.State) {
+			continue
+		}
+		if err := r.createBenchmarkJob(jobCtx, evaluation, i, benchmark, retryAttempts, timeout, ctx.ResultsToken); err != nil {
+			tracker.finish(i, api.StateFailed, err.Error())
+			continue
+		}
+		tracker.update(i)
+	}
+
+	err := tracker.watch(jobCtx)
+	if errors.Is(context.Cause(jobCtx), abstractions.ErrReplicaStopped) {
+		// the replica that recovers the job follows the existing Jobs
+		logger.Info("Evaluation job left running as the replica is stopping")
+		return jobCtx.Err()
+	}
+	if errors.Is(jobCtx.Err(), context.Canceled) {
+		logger.Info("Evaluation job stopped")
+		r.deleteJobs(logger, evaluation)
+		return jobCtx.Err()
+	}
+	if err != nil {
+		if errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+			tracker.finishAll(api.StateFailed, "Evaluation job timed out")
+		} else {
+			tracker.finishAll(api.StateFailed, fmt.Sprintf("Failed to watch the benchmark pods: %s", err.Error()))
+		}
+		r.deleteJobs(logger, evaluation)
+	}
+
+	failed := 0
+	for _, benchmark := range tracker.benchmarks {
+		if benchmark.status.State != api.StateCompleted {
+			failed++
+		}
+	}
+	state := api.EvaluationJobState{
+		State:   api.StateCompleted,
+		Message: "Evaluation job completed",
+	}
+	if failed > 0 {
+		state = api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: fmt.Sprintf("%d of %d benchmarks failed", failed, len(evaluation.Benchmarks)),
+		}
+	}
+	logger.Info("Evaluation job finished", "state", state.State, "message", state.Message)
+	return storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, state)
+}
+
+// createBenchmarkJob creates the Job of the benchmark, the ConfigMap that holds the model
+// and the benchmark parameters and the Secret that holds the results token. The ConfigMap
+// and the Secret are owned by the Job so that they are garbage collected with the Job. When
+// the Job already exists the job was recovered from another replica and the existing Job is
+// followed instead.
+func (r *K8sRuntime) createBenchmarkJob(ctx context.Context, evaluation *api.EvaluationJobResource, index int, benchmark api.BenchmarkConfig, retryAttempts int, timeout time.Duration, resultsToken string) error {
+	job, err := r.renderJob(evaluation, index, benchmark, retryAttempts, timeout, resultsToken)
+	if err != nil {
+		return err
+	}
+	configMap, err := r.benchmarkConfigMap(evaluation, index, benchmark)
+	if err != nil {
+		return err
+	}
+	created, err := r.client.BatchV1().Jobs(r.config.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		r.logger.Info("Following the existing Job", "job_id", evaluation.ID, "name", job.Name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create the Job %s: %w", job.Name, err)
+	}
+	owner := []metav1.OwnerReference{{
+		APIVersion: batchv1.SchemeGroupVersion.String(),
+		Kind:       "Job",
+		Name:       created.Name,
+		UID:        created.UID,
+	}}
+	configMap.OwnerReferences = owner
+	if _, err := r.client.CoreV1().ConfigMaps(r.config.Namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create the ConfigMap %s: %w", configMap.Name, err)
+	}
+	if resultsToken != "" {
+		secret := r.benchmarkSecret(evaluation, index, resultsToken)
+		secret.OwnerReferences = owner
+		if _, err := r.client.CoreV1().Secrets(r.config.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create the Secret %s: %w", secret.Name, err)
+		}
+	}
+	return nil
+}
+
+// renderJob renders the Job template and sets the fields that the runtime relies on
+func (r *K8sRuntime) renderJob(evaluation *api.EvaluationJobResource, index int, benchmark api.BenchmarkConfig, retryAttempts int, timeout time.Duration, resultsToken string) (*batchv1.Job, error) {
+	name := r.jobName(evaluation, index)
+	var buf bytes.Buffer
+	if err := r.template.Execute(&buf, templateData{
+		Name:               name,
+		Namespace:          r.config.Namespace,
+		Image:              r.config.Image,
+		ServiceAccountName: r.config.ServiceAccountName,
+		Job:                evaluation,
+		Model:              evaluation.Model,
+		Benchmark:          benchmark,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render the job template: %w", err)
+	}
+	job := &batchv1.Job{}
+	if err := yaml.UnmarshalStrict(buf.Bytes(), job); err != nil {
+		return nil, fmt.Errorf("the rendered job template is not a valid Job: %w", err)
+	}
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("the rendered job template has no containers")
+	}
+
+	labels := map[string]string{
+		LabelJobID:          evaluation.ID,
+		LabelBenchmarkIndex: strconv.Itoa(index),
+	}
+	job.Name = name
+	job.Namespace = r.config.Namespace
+	job.Labels = mergeLabels(job.Labels, labels)
+	job.Spec.Template.Labels = mergeLabels(job.Spec.Template.Labels, labels)
+	job.Spec.Template.Annotations = mergeLabels(job.Spec.Template.Annotations, map[string]string{AnnotationBenchmarkID: benchmark.ID})
+	backoffLimit := int32(retryAttempts)
+	job.Spec.BackoffLimit = &backoffLimit
+	deadline := int64(math.Ceil(timeout.Seconds()))
+	job.Spec.ActiveDeadlineSeconds = &deadline
+	if job.Spec.Template.Spec.RestartPolicy == "" {
+		job.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+
+	env, err := environment(evaluation, benchmark, name, resultsToken != "")
+	if err != nil {
+		return nil, err
+	}
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: configVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			},
+		},
+	})
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		container.Env = append(container.Env, env...)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      configVolumeName,
+			MountPath: ConfigMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return job, nil
+}
+
+// benchmarkConfigMap returns the ConfigMap that is mounted at ConfigMountPath
+func (r *K8sRuntime) benchmarkConfigMap(evaluation *api.EvaluationJobResource, index int, benchmark api.BenchmarkConfig) (*corev1.ConfigMap, error) {
+	parameters, err := json.Marshal(benchmark.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the benchmark parameters: %w", err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.jobName(evaluation, index),
+			Namespace: r.config.Namespace,
+			Labels: map[string]string{
+				LabelJobID:          evaluation.ID,
+				LabelBenchmarkIndex: strconv.Itoa(index),
+			},
+		},
+		Data: map[string]string{
+			"job_id":          evaluation.ID,
+			"benchmark_id":    benchmark.ID,
+			"model_url":       evaluation.Model.URL,
+			"model_name":      evaluation.Model.Name,
+			"parameters.json": string(parameters),
+		},
+	}, nil
+}
+
+// benchmarkSecret returns the Secret of the results token, the token is read from the Secret
+// so that it is not visible in the Job
+func (r *K8sRuntime) benchmarkSecret(evaluation *api.EvaluationJobResource, index int, resultsToken string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.jobName(evaluation, index),
+			Namespace: r.config.Namespace,
+			Labels: map[string]string{
+				LabelJobID:          evaluation.ID,
+				LabelBenchmarkIndex: strconv.Itoa(index),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{resultsTokenKey: []byte(resultsToken)},
+	}
+}
+
+// deleteJobs deletes all the Jobs of the evaluation job together with their pods, a new
+// context is used because the job context is usually done at this point
+func (r *K8sRuntime) deleteJobs(logger *slog.Logger, evaluation *api.EvaluationJobResource) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	jobs := r.client.BatchV1().Jobs(r.config.Namespace)
+	list, err := jobs.List(ctx, metav1.ListOptions{LabelSelector: LabelJobID + "=" + evaluation.ID})
+	if err != nil {
+		logger.Error("Failed to list the benchmark Jobs", "error", err.Error())
+		return
+	}
+	propagation := metav1.DeletePropagationBackground
+	for _, job := range list.Items {
+		if err := jobs.Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error("Failed to delete the benchmark Job", "name", job.Name, "error", err.Error())
+		}
+	}
+}
+
+// jobName is unique per benchmark and short enough to be used as a label value
+func (r *K8sRuntime) jobName(evaluation *api.EvaluationJobResource, index int) string {
+	return fmt.Sprintf("eval-hub-%s-%d", evaluation.ID, index)
+}
+
+// environment returns the evaluation details in the EVAL_HUB_* variables, the results token
+// is read from the benchmark Secret and is only set when the service has a results secret
+func environment(evaluation *api.EvaluationJobResource, benchmark api.BenchmarkConfig, secretName string, hasResultsToken bool) ([]corev1.EnvVar, error) {
+	parameters, err := json.Marshal(benchmark.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the benchmark parameters: %w", err)
+	}
+	env := []corev1.EnvVar{
+		{Name: constants.EnvVarJobID, Value: evaluation.ID},
+		{Name: constants.EnvVarBenchmarkID, Value: benchmark.ID},
+		{Name: constants.EnvVarBenchmarkParameters, Value: string(parameters)},
+		{Name: constants.EnvVarModelURL, Value: evaluation.Model.URL},
+		{Name: constants.EnvVarModelName, Value: evaluation.Model.Name},
+	}
+	if benchmark.Limit != nil {
+		env = append(env, corev1.EnvVar{Name: constants.EnvVarBenchmarkLimit, Value: strconv.Itoa(*benchmark.Limit)})
+	}
+	if hasResultsToken {
+		env = append(env, corev1.EnvVar{
+			Name: constants.EnvVarResultsToken,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  resultsTokenKey,
+				},
+			},
+		})
+	}
+	return env, nil
+}
+
+func mergeLabels(labels map[string]string, extra map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for name, value := range extra {
+		labels[name] = value
+	}
+	return labels
+}
+
+// PodPhaseToState maps the phase of a benchmark pod onto the benchmark state, an
+// empty state is returned for the unknown phase so that the current state is kept
+func PodPhaseToState(phase corev1.PodPhase) api.State {
+	switch phase {
+	case corev1.PodPending:
+		return api.StatePending
+	case corev1.PodRunning:
+		return api.StateRunning
+	case corev1.PodSucceeded:
+		return api.StateCompleted
+	case corev1.PodFailed:
+		return api.StateFailed
+	default:
+		return ""
+	}
+}
+
+// podMessage returns the most useful reason found in the pod status
+func podMessage(pod *corev1.Pod) string {
+	for _, container := range pod.Status.ContainerStatuses {
+		if waiting := container.State.Waiting; waiting != nil && waiting.Reason != "" {
+			return strings.TrimSpace(waiting.Reason + " " + waiting.Message)
+		}
+		if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return strings.TrimSpace(fmt.Sprintf("%s (exit code %d) %s", terminated.Reason, terminated.ExitCode, terminated.Message))
+		}
+	}
+	return strings.TrimSpace(pod.Status.Reason + " " + pod.Status.Message)
+}
+
+// jobTracker follows the pods of all the benchmarks of an evaluation job
+type jobTracker struct {
+	runtime       *K8sRuntime
+	ctx           *executioncontext.ExecutionContext
+	logger        *slog.Logger
+	storage       abstractions.Storage
+	evaluation    *api.EvaluationJobResource
+	retryAttempts int
+	benchmarks    []*benchmarkTracker
+}
+
+type benchmarkTracker struct {
+	status     api.BenchmarkStatus
+	failedPods map[string]bool
+}
+
+func (t *jobTracker) done() bool {
+	for _, benchmark := range t.benchmarks {
+		if !statemachine.IsTerminal(benchmark.status.State) {
+			return false
+		}
+	}
+	return true
+}
+
+// watch processes the pod events until all the benchmarks have finished, the watch is
+// restarted when the API server closes it
+func (t *jobTracker) watch(ctx context.Context) error {
+	pods := t.runtime.client.CoreV1().Pods(t.runtime.config.Namespace)
+	options := metav1.ListOptions{LabelSelector: LabelJobID + "=" + t.evaluation.ID}
+	for !t.done() {
+		watcher, err := pods.Watch(ctx, options)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		err = t.processEvents(ctx, watcher)
+		watcher.Stop()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *jobTracker) processEvents(ctx context.Context, watcher watch.Interface) error {
+	for !t.done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok || event.Type == watch.Deleted {
+				continue
+			}
+			t.podChanged(pod)
+		}
+	}
+	return nil
+}
+
+// podChanged updates the benchmark of the pod, a failed pod only fails the benchmark
+// once the retry attempts are exhausted because the Job creates a new pod otherwise
+func (t *jobTracker) podChanged(pod *corev1.Pod) {
+	index, err := strconv.Atoi(pod.Labels[LabelBenchmarkIndex])
+	if err != nil || index < 0 || index >= len(t.benchmarks) {
+		return
+	}
+	benchmark := t.benchmarks[index]
+	if statemachine.IsTerminal(benchmark.status.State) {
+		return
+	}
+	state := PodPhaseToState(pod.Status.Phase)
+	message := podMessage(pod)
+	switch state {
+	case "":
+		return
+	case api.StateCompleted:
+		t.finish(index, api.StateCompleted, "Benchmark completed")
+		return
+	case api.StateFailed:
+		benchmark.failedPods[pod.Name] = true
+		if len(benchmark.failedPods) > t.retryAttempts {
+			t.finish(index, api.StateFailed, fmt.Sprintf("Benchmark failed after %d attempts: %s", len(benchmark.failedPods), message))
+			return
+		}
+		state = api.StateRunning
+		message = fmt.Sprintf("Attempt %d failed, retrying: %s", len(benchmark.failedPods), message)
+	case api.StatePending:
+		// the pod of a retry is pending but the benchmark keeps running
+		if benchmark.status.State == api.StateRunning {
+			state = api.StateRunning
+		}
+	case api.StateRunning:
+		if benchmark.status.StartedAt == nil {
+			startedAt := time.Now().UTC()
+			benchmark.status.StartedAt = &startedAt
+		}
+	}
+	if benchmark.status.State == state && benchmark.status.Message == message {
+		return
+	}
+	benchmark.status.State = state
+	benchmark.status.Message = message
+	t.update(index)
+}
+
+func (t *jobTracker) finish(index int, state api.State, message string) {
+	benchmark := t.benchmarks[index]
+	completedAt := time.Now().UTC()
+	benchmark.status.State = state
+	benchmark.status.Message = message
+	benchmark.status.CompletedAt = &completedAt
+	t.logger.Info("Benchmark finished", "benchmark_id", benchmark.status.Name, "state", state, "message", message)
+	t.update(index)
+}
+
+func (t *jobTracker) finishAll(state api.State, message string) {
+	for i, benchmark := range t.benchmarks {
+		if !statemachine.IsTerminal(benchmark.status.State) {
+			t.finish(i, state, message)
+		}
+	}
+}
+
+func (t *jobTracker) update(index int) {
+	benchmark := t.benchmarks[index]
+	if err := t.storage.UpdateBenchmarkStatusForJob(t.ctx, t.evaluation.ID, benchmark.status); err != nil {
+		t.logger.Error("Failed to update the benchmark status", "benchmark_id", benchmark.status.Name, "error", err.Error())
+	}
+}
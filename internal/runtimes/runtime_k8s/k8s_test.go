@@ -0,0 +1,291 @@
+package runtime_k8s_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_k8s"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const namespace = "eval-hub-test"
+
+func TestRunEvaluationJob(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext(time.Minute)
+	ctx.ResultsToken = "job-token"
+
+	t.Run("renders a Job per benchmark and follows the pods", func(t *testing.T) {
+		client := fake.NewClientset()
+		runtime := createRuntime(t, client)
+		job := createJob(t, storage, ctx, 1, "mmlu", "hellaswag")
+
+		done := run(runtime, ctx, job, storage)
+		waitForWatch(t, client)
+
+		jobs, err := client.BatchV1().Jobs(namespace).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("Failed to list the Jobs: %v", err)
+		}
+		if len(jobs.Items) != 2 {
+			t.Fatalf("Expected 2 Jobs, got %d", len(jobs.Items))
+		}
+		k8sJob := jobs.Items[0]
+		if k8sJob.Labels[runtime_k8s.LabelJobID] != job.ID || k8sJob.Spec.Template.Labels[runtime_k8s.LabelJobID] != job.ID {
+			t.Errorf("Expected the Job and the pods to be labelled with the job id: %+v", k8sJob.Labels)
+		}
+		if k8sJob.Spec.Template.Annotations[runtime_k8s.AnnotationBenchmarkID] != job.Benchmarks[0].ID {
+			t.Errorf("Expected the pods to be annotated with the benchmark id: %+v", k8sJob.Spec.Template.Annotations)
+		}
+		if k8sJob.Spec.BackoffLimit == nil || *k8sJob.Spec.BackoffLimit != 1 {
+			t.Errorf("Expected a backoff limit of 1, got %v", k8sJob.Spec.BackoffLimit)
+		}
+		container := k8sJob.Spec.Template.Spec.Containers[0]
+		if container.Image != "eval-hub/test:latest" {
+			t.Errorf("Expected the configured image, got %s", container.Image)
+		}
+		if !hasEnv(container, constants.EnvVarModelURL, "http://localhost:8000") {
+			t.Errorf("Expected the model URL in the environment: %+v", container.Env)
+		}
+		if !hasSecretEnv(container, constants.EnvVarResultsToken, k8sJob.Name) {
+			t.Errorf("Expected the results token from the Secret in the environment: %+v", container.Env)
+		}
+		if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != runtime_k8s.ConfigMountPath {
+			t.Errorf("Expected the benchmark configuration to be mounted: %+v", container.VolumeMounts)
+		}
+		configMap, err := client.CoreV1().ConfigMaps(namespace).Get(context.Background(), k8sJob.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get the ConfigMap: %v", err)
+		}
+		if configMap.Data["model_url"] != "http://localhost:8000" || configMap.Data["parameters.json"] != `{"num_fewshot":5}` {
+			t.Errorf("Unexpected ConfigMap data: %+v", configMap.Data)
+		}
+		secret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), k8sJob.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get the Secret: %v", err)
+		}
+		if string(secret.Data["results_token"]) != "job-token" {
+			t.Errorf("Expected the results token in the Secret, got %q", secret.Data["results_token"])
+		}
+		if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].Kind != "Job" || secret.OwnerReferences[0].Name != k8sJob.Name {
+			t.Errorf("Expected the Secret to be owned by the Job: %+v", secret.OwnerReferences)
+		}
+
+		// the first benchmark runs and succeeds, the second one fails twice
+		setPod(t, client, job, 0, "mmlu-a", corev1.PodRunning)
+		setPod(t, client, job, 0, "mmlu-a", corev1.PodSucceeded)
+		setPod(t, client, job, 1, "hellaswag-a", corev1.PodFailed)
+		setPod(t, client, job, 1, "hellaswag-b", corev1.PodFailed)
+
+		if err := wait(t, done); err != nil {
+			t.Fatalf("RunEvaluationJob() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateFailed || got.Status.Message != "1 of 2 benchmarks failed" {
+			t.Errorf("Unexpected job state: %+v", got.Status.EvaluationJobState)
+		}
+		states := map[string]api.State{}
+		for _, status := range got.Status.Benchmarks {
+			states[status.Name] = status.State
+		}
+		if states["mmlu"] != api.StateCompleted || states["hellaswag"] != api.StateFailed {
+			t.Errorf("Unexpected benchmark states: %+v", states)
+		}
+	})
+
+	t.Run("timed out jobs are deleted", func(t *testing.T) {
+		client := fake.NewClientset()
+		runtime := createRuntime(t, client)
+		shortCtx := createExecutionContext(100 * time.Millisecond)
+		job := createJob(t, storage, shortCtx, 0, "mmlu")
+
+		if err := wait(t, run(runtime, shortCtx, job, storage)); err != nil {
+			t.Fatalf("RunEvaluationJob() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateFailed || len(got.Status.Benchmarks) != 1 || got.Status.Benchmarks[0].Message != "Evaluation job timed out" {
+			t.Errorf("Unexpected job status: %+v", got.Status)
+		}
+		jobs, err := client.BatchV1().Jobs(namespace).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("Failed to list the Jobs: %v", err)
+		}
+		if len(jobs.Items) != 0 {
+			t.Errorf("Expected the Jobs to be deleted, got %d", len(jobs.Items))
+		}
+	})
+}
+
+func TestPodPhaseToState(t *testing.T) {
+	cases := map[corev1.PodPhase]api.State{
+		corev1.PodPending:   api.StatePending,
+		corev1.PodRunning:   api.StateRunning,
+		corev1.PodSucceeded: api.StateCompleted,
+		corev1.PodFailed:    api.StateFailed,
+		corev1.PodUnknown:   "",
+	}
+	for phase, expected := range cases {
+		if got := runtime_k8s.PodPhaseToState(phase); got != expected {
+			t.Errorf("Expected %q for phase %s, got %q", expected, phase, got)
+		}
+	}
+}
+
+func TestNewK8sRuntimeWithClient(t *testing.T) {
+	client := fake.NewClientset()
+	if _, err := runtime_k8s.NewK8sRuntimeWithClient(&config.K8sRuntimeConfig{}, client, logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error when the namespace is missing")
+	}
+	if _, err := runtime_k8s.NewK8sRuntimeWithClient(&config.K8sRuntimeConfig{Namespace: namespace, JobTemplate: "missing.yaml"}, client, logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error when the job template does not exist")
+	}
+}
+
+func createRuntime(t *testing.T, client *fake.Clientset) abstractions.Runtime {
+	t.Helper()
+	runtime, err := runtime_k8s.NewK8sRuntimeWithClient(&config.K8sRuntimeConfig{
+		Enabled:   true,
+		Namespace: namespace,
+		Image:     "eval-hub/test:latest",
+	}, client, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewK8sRuntimeWithClient() returned error: %v", err)
+	}
+	return runtime
+}
+
+func run(runtime abstractions.Runtime, ctx *executioncontext.ExecutionContext, job *api.EvaluationJobResource, storage abstractions.Storage) chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- runtime.RunEvaluationJob(ctx, job, storage)
+	}()
+	return done
+}
+
+func wait(t *testing.T, done chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("RunEvaluationJob() did not return")
+		return nil
+	}
+}
+
+// waitForWatch waits until the runtime watches the pods so that no pod event is missed
+func waitForWatch(t *testing.T, client *fake.Clientset) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "watch" && action.GetResource().Resource == "pods" {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("The runtime did not watch the pods")
+}
+
+// setPod creates or updates the pod of a benchmark in the given phase
+func setPod(t *testing.T, client *fake.Clientset, job *api.EvaluationJobResource, index int, name string, phase corev1.PodPhase) {
+	t.Helper()
+	pods := client.CoreV1().Pods(namespace)
+	pod, err := pods.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels: map[string]string{
+					runtime_k8s.LabelJobID:          job.ID,
+					runtime_k8s.LabelBenchmarkIndex: strconv.Itoa(index),
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+		if _, err := pods.Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create the pod: %v", err)
+		}
+		return
+	}
+	pod.Status.Phase = phase
+	if _, err := pods.Update(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update the pod: %v", err)
+	}
+}
+
+func hasEnv(container corev1.Container, name string, value string) bool {
+	for _, env := range container.Env {
+		if env.Name == name && env.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSecretEnv(container corev1.Container, name string, secretName string) bool {
+	for _, env := range container.Env {
+		if env.Name == name && env.Value == "" && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+func createJob(t *testing.T, storage abstractions.Storage, ctx *executioncontext.ExecutionContext, retryAttempts int, benchmarks ...string) *api.EvaluationJobResource {
+	t.Helper()
+	config := &api.EvaluationJobConfig{
+		Model:         api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		RetryAttempts: &retryAttempts,
+	}
+	for _, benchmark := range benchmarks {
+		config.Benchmarks = append(config.Benchmarks, api.BenchmarkConfig{
+			Ref:        api.Ref{ID: benchmark},
+			Parameters: map[string]any{"num_fewshot": 5},
+		})
+	}
+	job, err := storage.CreateEvaluationJob(ctx, config)
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	return job
+}
+
+func createStorage(t *testing.T) abstractions.Storage {
+	t.Helper()
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, nil, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func createExecutionContext(timeout time.Duration) *executioncontext.ExecutionContext {
+	return executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), "", "", "", "", nil, nil, "", "", "", timeout, 0, nil, nil, "")
+}
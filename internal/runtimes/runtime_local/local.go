@@ -0,0 +1,261 @@
+package runtime_local
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// LocalRuntime runs each benchmark of an evaluation job as an OS process on the
+// machine running the service, this is intended for development and testing.
+type LocalRuntime struct {
+	config   *config.LocalRuntimeConfig
+	logStore abstractions.LogStore
+	logger   *slog.Logger
+	args     []*template.Template
+}
+
+// templateData is the data used to render the command arguments
+type templateData struct {
+	Job       *api.EvaluationJobResource
+	Model     api.ModelRef
+	Benchmark api.BenchmarkConfig
+}
+
+// NewLocalRuntime creates the runtime, the output of the benchmarks is written to the log store
+func NewLocalRuntime(localConfig *config.LocalRuntimeConfig, logStore abstractions.LogStore, logger *slog.Logger) (abstractions.Runtime, error) {
+	if localConfig.Command == "" {
+		return nil, fmt.Errorf("the local runtime requires a command")
+	}
+	if logStore == nil {
+		return nil, fmt.Errorf("the local runtime requires a log store")
+	}
+	args := make([]*template.Template, 0, len(localConfig.Args))
+	for i, arg := range localConfig.Args {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local runtime argument %q: %w", arg, err)
+		}
+		args = append(args, tmpl)
+	}
+	logger.Info("Creating local runtime", "command", localConfig.Command)
+	return &LocalRuntime{
+		config:   localConfig,
+		logStore: logStore,
+		logger:   logger,
+		args:     args,
+	}, nil
+}
+
+func (r *LocalRuntime) GetRuntimeName() string {
+	return "local"
+}
+
+// RunEvaluationJob runs the benchmarks one after the other, each benchmark is retried up to
+// RetryAttempts times and the whole job is stopped when TimeoutMinutes is exceeded.
+func (r *LocalRuntime) RunEvaluationJob(ctx *executioncontext.ExecutionContext, evaluation *api.EvaluationJobResource, storage abstractions.Storage) error {
+	timeout := ctx.Timeout
+	if evaluation.TimeoutMinutes != nil {
+		timeout = time.Duration(*evaluation.TimeoutMinutes) * time.Minute
+	}
+	retryAttempts := ctx.RetryAttempts
+	if evaluation.RetryAttempts != nil {
+		retryAttempts = *evaluation.RetryAttempts
+	}
+	// a benchmark is always attempted once
+	retryAttempts = max(retryAttempts, 0)
+	logger := ctx.Logger.With("job_id", evaluation.ID, "runtime", r.GetRuntimeName())
+
+	jobCtx, cancel := context.WithTimeout(ctx.Ctx, timeout)
+	defer cancel()
+
+	if len(evaluation.Benchmarks) == 0 {
+		return storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: "Evaluation job has no benchmarks",
+		})
+	}
+
+	if err := storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, api.EvaluationJobState{
+		State:   api.StateRunning,
+		Message: "Evaluation job running",
+	}); err != nil {
+		return err
+	}
+
+	// the benchmarks that have finished before the job was recovered from another replica are not run again
+	finished := map[string]api.State{}
+	for _, status := range evaluation.Status.Benchmarks {
+		if statemachine.IsTerminal(status.State) {
+			finished[status.Name] = status.State
+		}
+	}
+
+	failed := 0
+	for _, benchmark := range evaluation.Benchmarks {
+		if state, ok := finished[benchmark.ID]; ok {
+			if state != api.StateCompleted {
+				failed++
+			}
+			continue
+		}
+		status := r.runBenchmark(jobCtx, logger, evaluation, benchmark, retryAttempts, ctx.ResultsToken, func(status api.BenchmarkStatus) {
+			if err := storage.UpdateBenchmarkStatusForJob(ctx, evaluation.ID, status); err != nil {
+				logger.Error("Failed to update the benchmark status", "benchmark_id", benchmark.ID, "error", err.Error())
+			}
+		})
+		if errors.Is(jobCtx.Err(), context.Canceled) {
+			logger.Info("Evaluation job stopped", "benchmark_id", benchmark.ID)
+			return jobCtx.Err()
+		}
+		if status.State != api.StateCompleted {
+			failed++
+		}
+		if err := storage.UpdateBenchmarkStatusForJob(ctx, evaluation.ID, status); err != nil {
+			logger.Error("Failed to update the benchmark status", "benchmark_id", benchmark.ID, "error", err.Error())
+		}
+	}
+
+	state := api.EvaluationJobState{
+		State:   api.StateCompleted,
+		Message: "Evaluation job completed",
+	}
+	if failed > 0 {
+		state = api.EvaluationJobState{
+			State:   api.StateFailed,
+			Message: fmt.Sprintf("%d of %d benchmarks failed", failed, len(evaluation.Benchmarks)),
+		}
+	}
+	logger.Info("Evaluation job finished", "state", state.State, "message", state.Message)
+	return storage.UpdateEvaluationJobStatus(ctx, evaluation.ID, state)
+}
+
+// runBenchmark runs a single benchmark and returns the final benchmark status, started is called
+// once the benchmark is running. The output of all the attempts is written to the same log file.
+func (r *LocalRuntime) runBenchmark(jobCtx context.Context, logger *slog.Logger, evaluation *api.EvaluationJobResource, benchmark api.BenchmarkConfig, retryAttempts int, resultsToken string, started func(status api.BenchmarkStatus)) api.BenchmarkStatus {
+	startedAt := time.Now().UTC()
+	status := api.BenchmarkStatus{
+		Name:      benchmark.ID,
+		State:     api.StateRunning,
+		StartedAt: &startedAt,
+	}
+	finish := func(state api.State, message string) api.BenchmarkStatus {
+		completedAt := time.Now().UTC()
+		status.State = state
+		status.Message = message
+		status.CompletedAt = &completedAt
+		logger.Info("Benchmark finished", "benchmark_id", benchmark.ID, "state", state, "message", message)
+		return status
+	}
+
+	args, err := r.renderArgs(evaluation, benchmark)
+	if err != nil {
+		return finish(api.StateFailed, err.Error())
+	}
+
+	logFile, logPath, err := r.logStore.Create(evaluation.ID, benchmark.ID)
+	if err != nil {
+		return finish(api.StateFailed, err.Error())
+	}
+	defer logFile.Close()
+	status.Logs = &api.BenchmarkStatusLogs{Path: logPath}
+	started(status)
+
+	env, err := r.environment(evaluation, benchmark, resultsToken)
+	if err != nil {
+		return finish(api.StateFailed, err.Error())
+	}
+
+	var runErr error
+	for attempt := 0; attempt <= retryAttempts; attempt++ {
+		if jobCtx.Err() != nil {
+			break
+		}
+		fmt.Fprintf(logFile, "=== eval-hub: attempt %d of %d: %s %s\n", attempt+1, retryAttempts+1, r.config.Command, strings.Join(args, " "))
+		logger.Info("Starting benchmark process", "benchmark_id", benchmark.ID, "attempt", attempt+1, "log", logPath)
+
+		cmd := exec.CommandContext(jobCtx, r.config.Command, args...)
+		cmd.Dir = r.config.WorkDir
+		cmd.Env = env
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+		cmd.WaitDelay = 10 * time.Second
+		runErr = cmd.Run()
+		if runErr == nil {
+			return finish(api.StateCompleted, "Benchmark completed")
+		}
+		fmt.Fprintf(logFile, "=== eval-hub: attempt %d failed: %s\n", attempt+1, runErr.Error())
+	}
+
+	if errors.Is(jobCtx.Err(), context.DeadlineExceeded) {
+		return finish(api.StateFailed, "Evaluation job timed out")
+	}
+	if runErr == nil {
+		runErr = jobCtx.Err()
+	}
+	if runErr == nil {
+		return finish(api.StateFailed, "Benchmark was not run")
+	}
+	return finish(api.StateFailed, fmt.Sprintf("Benchmark failed after %d attempts: %s", retryAttempts+1, runErr.Error()))
+}
+
+func (r *LocalRuntime) renderArgs(evaluation *api.EvaluationJobResource, benchmark api.BenchmarkConfig) ([]string, error) {
+	data := templateData{
+		Job:       evaluation,
+		Model:     evaluation.Model,
+		Benchmark: benchmark,
+	}
+	args := make([]string, 0, len(r.args))
+	for _, tmpl := range r.args {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render the benchmark command: %w", err)
+		}
+		args = append(args, buf.String())
+	}
+	return args, nil
+}
+
+// environment returns the service environment, the configured environment and the
+// evaluation details in the EVAL_HUB_* variables, the results token is only set when
+// the service has a results secret
+func (r *LocalRuntime) environment(evaluation *api.EvaluationJobResource, benchmark api.BenchmarkConfig, resultsToken string) ([]string, error) {
+	env := os.Environ()
+	for name, value := range r.config.Env {
+		env = append(env, name+"="+value)
+	}
+	parameters, err := json.Marshal(benchmark.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the benchmark parameters: %w", err)
+	}
+	env = append(env,
+		constants.EnvVarJobID+"="+evaluation.ID,
+		constants.EnvVarBenchmarkID+"="+benchmark.ID,
+		constants.EnvVarBenchmarkParameters+"="+string(parameters),
+		constants.EnvVarModelURL+"="+evaluation.Model.URL,
+		constants.EnvVarModelName+"="+evaluation.Model.Name,
+	)
+	if benchmark.Limit != nil {
+		env = append(env, constants.EnvVarBenchmarkLimit+"="+strconv.Itoa(*benchmark.Limit))
+	}
+	if resultsToken != "" {
+		env = append(env, constants.EnvVarResultsToken+"="+resultsToken)
+	}
+	return env, nil
+}
@@ -0,0 +1,176 @@
+package runtime_local_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore/logstore_fs"
+	"github.com/julpayne/eval-hub-backend-svc/internal/runtimes/runtime_local"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestRunEvaluationJob(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	t.Run("successful benchmarks complete the job", func(t *testing.T) {
+		runtime := createRuntime(t, `echo "running {{.Benchmark.ID}} for $EVAL_HUB_MODEL_NAME"`)
+		job := createJob(t, storage, ctx, 0, "mmlu", "hellaswag")
+
+		if err := runtime.RunEvaluationJob(ctx, job, storage); err != nil {
+			t.Fatalf("RunEvaluationJob() returned error: %v", err)
+		}
+
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateCompleted {
+			t.Errorf("Expected state %s, got %s (%s)", api.StateCompleted, got.Status.State, got.Status.Message)
+		}
+		if len(got.Status.Benchmarks) != 2 {
+			t.Fatalf("Expected 2 benchmark statuses, got %d", len(got.Status.Benchmarks))
+		}
+		for _, status := range got.Status.Benchmarks {
+			if status.State != api.StateCompleted || status.StartedAt == nil || status.CompletedAt == nil {
+				t.Errorf("Unexpected benchmark status: %+v", status)
+			}
+			logs := readLogs(t, status)
+			expected := fmt.Sprintf("running %s for test-model", status.Name)
+			if !strings.Contains(logs, expected) {
+				t.Errorf("Expected the logs to contain %q, got %q", expected, logs)
+			}
+		}
+	})
+
+	t.Run("failing benchmarks are retried and fail the job", func(t *testing.T) {
+		runtime := createRuntime(t, `echo "failing {{.Benchmark.ID}}"; exit 3`)
+		job := createJob(t, storage, ctx, 1, "mmlu")
+
+		if err := runtime.RunEvaluationJob(ctx, job, storage); err != nil {
+			t.Fatalf("RunEvaluationJob() returned error: %v", err)
+		}
+
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateFailed {
+			t.Errorf("Expected state %s, got %s", api.StateFailed, got.Status.State)
+		}
+		if len(got.Status.Benchmarks) != 1 || got.Status.Benchmarks[0].State != api.StateFailed {
+			t.Fatalf("Unexpected benchmark statuses: %+v", got.Status.Benchmarks)
+		}
+		logs := readLogs(t, got.Status.Benchmarks[0])
+		if strings.Count(logs, "\nfailing mmlu\n") != 2 {
+			t.Errorf("Expected 2 attempts in the logs, got %q", logs)
+		}
+	})
+
+	t.Run("a negative retry count runs the benchmarks once", func(t *testing.T) {
+		runtime := createRuntime(t, `echo "running {{.Benchmark.ID}}"`)
+		job := createJob(t, storage, ctx, -1, "mmlu")
+
+		if err := runtime.RunEvaluationJob(ctx, job, storage); err != nil {
+			t.Fatalf("RunEvaluationJob() returned error: %v", err)
+		}
+
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateCompleted {
+			t.Errorf("Expected state %s, got %s (%s)", api.StateCompleted, got.Status.State, got.Status.Message)
+		}
+	})
+}
+
+func TestNewLocalRuntime(t *testing.T) {
+	if _, err := runtime_local.NewLocalRuntime(&config.LocalRuntimeConfig{}, createLogStore(t), logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error when the command is missing")
+	}
+	if _, err := runtime_local.NewLocalRuntime(&config.LocalRuntimeConfig{Command: "sh", Args: []string{"{{.Benchmark"}}, createLogStore(t), logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error for an invalid argument template")
+	}
+	if _, err := runtime_local.NewLocalRuntime(&config.LocalRuntimeConfig{Command: "sh"}, nil, logging.FallbackLogger()); err == nil {
+		t.Error("Expected an error when the log store is missing")
+	}
+}
+
+func createRuntime(t *testing.T, script string) abstractions.Runtime {
+	t.Helper()
+	runtime, err := runtime_local.NewLocalRuntime(&config.LocalRuntimeConfig{
+		Enabled: true,
+		Command: "sh",
+		Args:    []string{"-c", script},
+	}, createLogStore(t), logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewLocalRuntime() returned error: %v", err)
+	}
+	return runtime
+}
+
+func createLogStore(t *testing.T) abstractions.LogStore {
+	t.Helper()
+	logStore, err := logstore_fs.NewFSLogStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFSLogStore() returned error: %v", err)
+	}
+	return logStore
+}
+
+func createJob(t *testing.T, storage abstractions.Storage, ctx *executioncontext.ExecutionContext, retryAttempts int, benchmarks ...string) *api.EvaluationJobResource {
+	t.Helper()
+	config := &api.EvaluationJobConfig{
+		Model:         api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		RetryAttempts: &retryAttempts,
+	}
+	for _, benchmark := range benchmarks {
+		config.Benchmarks = append(config.Benchmarks, api.BenchmarkConfig{Ref: api.Ref{ID: benchmark}})
+	}
+	job, err := storage.CreateEvaluationJob(ctx, config)
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	return job
+}
+
+func readLogs(t *testing.T, status api.BenchmarkStatus) string {
+	t.Helper()
+	if status.Logs == nil || status.Logs.Path == "" {
+		t.Fatalf("Benchmark %s has no logs", status.Name)
+	}
+	logs, err := os.ReadFile(status.Logs.Path)
+	if err != nil {
+		t.Fatalf("Failed to read the logs: %v", err)
+	}
+	return string(logs)
+}
+
+func createStorage(t *testing.T) abstractions.Storage {
+	t.Helper()
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, nil, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func createExecutionContext() *executioncontext.ExecutionContext {
+	return executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), "", "", "", "", nil, nil, "", "", "", time.Minute, 0, nil, nil, "")
+}
@@ -0,0 +1,161 @@
+package statemachine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// transitions lists the states that can be reached from each state, the same state can
+// always be set again to update the message. There is no transition out of the terminal
+// states and nothing goes back to pending.
+var transitions = map[api.State][]api.State{
+	api.StatePending: {api.StateRunning, api.StateCompleted, api.StateFailed, api.StateCancelled},
+	api.StateRunning: {api.StateCompleted, api.StateFailed, api.StateCancelled},
+}
+
+// CanTransition returns true if the state machine allows the change from one state to the other
+func CanTransition(from api.State, to api.State) bool {
+	if from == to || from == "" {
+		return true
+	}
+	for _, state := range transitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal returns true for the states that a job or a benchmark can not leave
+func IsTerminal(state api.State) bool {
+	return state == api.StateCompleted || state == api.StateFailed || state == api.StateCancelled
+}
+
+// SetJobState validates the change of the job state and stamps the job start and completion times
+func SetJobState(job *api.EvaluationJobResource, state api.EvaluationJobState, now time.Time) error {
+	if !CanTransition(job.Status.State, state.State) {
+		return invalidTransition("evaluation job", job.Status.State, state.State)
+	}
+	job.Status.EvaluationJobState = state
+	job.Status.StartedAt, job.Status.CompletedAt = stamp(state.State, job.Status.StartedAt, job.Status.CompletedAt, now)
+	return nil
+}
+
+// SetBenchmarkStatus replaces the status of the benchmark with the same name, or adds it if the
+// benchmark does not have a status yet, and derives the job state from the benchmark statuses
+func SetBenchmarkStatus(job *api.EvaluationJobResource, status api.BenchmarkStatus, now time.Time) error {
+	index := -1
+	for i := range job.Status.Benchmarks {
+		if job.Status.Benchmarks[i].Name == status.Name {
+			index = i
+			break
+		}
+	}
+	if index >= 0 {
+		current := job.Status.Benchmarks[index]
+		if !CanTransition(current.State, status.State) {
+			return invalidTransition(fmt.Sprintf("benchmark %s", status.Name), current.State, status.State)
+		}
+		// keep the times stamped by the previous updates
+		if status.StartedAt == nil {
+			status.StartedAt = current.StartedAt
+		}
+		if status.CompletedAt == nil {
+			status.CompletedAt = current.CompletedAt
+		}
+	} else if IsTerminal(job.Status.State) {
+		return invalidTransition(fmt.Sprintf("benchmark %s", status.Name), job.Status.State, status.State)
+	}
+	status.StartedAt, status.CompletedAt = stamp(status.State, status.StartedAt, status.CompletedAt, now)
+	if index >= 0 {
+		job.Status.Benchmarks[index] = status
+	} else {
+		job.Status.Benchmarks = append(job.Status.Benchmarks, status)
+	}
+
+	derived := DeriveJobState(job)
+	if derived.State == job.Status.State {
+		return nil
+	}
+	return SetJobState(job, derived, now)
+}
+
+// CancelJob moves the job and the benchmarks that have not finished to cancelled, cancelling
+// a cancelled job does nothing and a job that has completed or failed can not be cancelled
+func CancelJob(job *api.EvaluationJobResource, now time.Time) error {
+	if job.Status.State == api.StateCancelled {
+		return nil
+	}
+	if err := SetJobState(job, api.EvaluationJobState{State: api.StateCancelled, Message: "Evaluation job cancelled"}, now); err != nil {
+		return err
+	}
+	for i := range job.Status.Benchmarks {
+		benchmark := &job.Status.Benchmarks[i]
+		if IsTerminal(benchmark.State) {
+			continue
+		}
+		benchmark.State = api.StateCancelled
+		benchmark.Message = "Benchmark cancelled"
+		benchmark.StartedAt, benchmark.CompletedAt = stamp(benchmark.State, benchmark.StartedAt, benchmark.CompletedAt, now)
+	}
+	return nil
+}
+
+// DeriveJobState returns the job state implied by the benchmark statuses. The job is running
+// as soon as a benchmark is running or has finished and it finishes with the last benchmark,
+// a terminal job state is never changed.
+func DeriveJobState(job *api.EvaluationJobResource) api.EvaluationJobState {
+	if IsTerminal(job.Status.State) {
+		return job.Status.EvaluationJobState
+	}
+	total := max(len(job.Benchmarks), len(job.Status.Benchmarks))
+	finished, running, failed, cancelled := 0, 0, 0, 0
+	for _, benchmark := range job.Status.Benchmarks {
+		switch benchmark.State {
+		case api.StateRunning:
+			running++
+		case api.StateCompleted:
+			finished++
+		case api.StateFailed:
+			finished++
+			failed++
+		case api.StateCancelled:
+			finished++
+			cancelled++
+		}
+	}
+	switch {
+	case total > 0 && finished == total && failed > 0:
+		return api.EvaluationJobState{State: api.StateFailed, Message: fmt.Sprintf("%d of %d benchmarks failed", failed, total)}
+	case total > 0 && finished == total && cancelled > 0:
+		return api.EvaluationJobState{State: api.StateCancelled, Message: "Evaluation job cancelled"}
+	case total > 0 && finished == total:
+		return api.EvaluationJobState{State: api.StateCompleted, Message: "Evaluation job completed"}
+	case running > 0 || finished > 0:
+		if job.Status.State == api.StateRunning {
+			return job.Status.EvaluationJobState
+		}
+		return api.EvaluationJobState{State: api.StateRunning, Message: "Evaluation job running"}
+	default:
+		return job.Status.EvaluationJobState
+	}
+}
+
+// stamp sets the start time when the work starts and the completion time when finishing,
+// the times that are already set are kept. A job cancelled while pending has no start time.
+func stamp(state api.State, startedAt *time.Time, completedAt *time.Time, now time.Time) (*time.Time, *time.Time) {
+	if state != api.StatePending && state != api.StateCancelled && startedAt == nil {
+		startedAt = &now
+	}
+	if IsTerminal(state) && completedAt == nil {
+		completedAt = &now
+	}
+	return startedAt, completedAt
+}
+
+func invalidTransition(what string, from api.State, to api.State) error {
+	return fmt.Errorf("%s %w from %s to %s", what, abstractions.ErrInvalidTransition, from, to)
+}
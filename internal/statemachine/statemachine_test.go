@@ -0,0 +1,177 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestCanTransition(t *testing.T) {
+	testCases := []struct {
+		from     api.State
+		to       api.State
+		expected bool
+	}{
+		{api.StatePending, api.StateRunning, true},
+		{api.StatePending, api.StateCancelled, true},
+		{api.StateRunning, api.StateRunning, true},
+		{api.StateRunning, api.StateCompleted, true},
+		{api.StateRunning, api.StatePending, false},
+		{api.StateCompleted, api.StateRunning, false},
+		{api.StateFailed, api.StateCompleted, false},
+		{api.StateCancelled, api.StateCancelled, true},
+		{api.StateCancelled, api.StateFailed, false},
+	}
+	for _, tc := range testCases {
+		if got := statemachine.CanTransition(tc.from, tc.to); got != tc.expected {
+			t.Errorf("Expected CanTransition(%s, %s) to be %v, got %v", tc.from, tc.to, tc.expected, got)
+		}
+	}
+}
+
+func TestSetJobState(t *testing.T) {
+	now := time.Now().UTC()
+	job := createJob()
+
+	if err := statemachine.SetJobState(job, api.EvaluationJobState{State: api.StateRunning}, now); err != nil {
+		t.Fatalf("SetJobState() returned error: %v", err)
+	}
+	if job.Status.StartedAt == nil || job.Status.CompletedAt != nil {
+		t.Errorf("Expected only the start time to be stamped: %+v", job.Status)
+	}
+	if err := statemachine.SetJobState(job, api.EvaluationJobState{State: api.StateCompleted}, now); err != nil {
+		t.Fatalf("SetJobState() returned error: %v", err)
+	}
+	if job.Status.CompletedAt == nil {
+		t.Errorf("Expected the completion time to be stamped: %+v", job.Status)
+	}
+	err := statemachine.SetJobState(job, api.EvaluationJobState{State: api.StateRunning}, now)
+	if !errors.Is(err, abstractions.ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestSetBenchmarkStatus(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("the job follows the benchmarks", func(t *testing.T) {
+		job := createJob("mmlu", "hellaswag")
+		steps := []struct {
+			benchmark string
+			state     api.State
+			expected  api.State
+		}{
+			{"mmlu", api.StatePending, api.StatePending},
+			{"mmlu", api.StateRunning, api.StateRunning},
+			{"mmlu", api.StateCompleted, api.StateRunning},
+			{"hellaswag", api.StateRunning, api.StateRunning},
+			{"hellaswag", api.StateFailed, api.StateFailed},
+		}
+		for _, step := range steps {
+			if err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: step.benchmark, State: step.state}, now); err != nil {
+				t.Fatalf("SetBenchmarkStatus(%s, %s) returned error: %v", step.benchmark, step.state, err)
+			}
+			if job.Status.State != step.expected {
+				t.Errorf("Expected job state %s after %s is %s, got %s", step.expected, step.benchmark, step.state, job.Status.State)
+			}
+		}
+		if job.Status.Message != "1 of 2 benchmarks failed" || job.Status.CompletedAt == nil {
+			t.Errorf("Unexpected job status: %+v", job.Status)
+		}
+	})
+
+	t.Run("finished benchmarks can not be restarted", func(t *testing.T) {
+		job := createJob("mmlu", "hellaswag")
+		if err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted}, now); err != nil {
+			t.Fatalf("SetBenchmarkStatus() returned error: %v", err)
+		}
+		err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}, now)
+		if !errors.Is(err, abstractions.ErrInvalidTransition) {
+			t.Errorf("Expected ErrInvalidTransition, got %v", err)
+		}
+		if job.Status.Benchmarks[0].StartedAt == nil || job.Status.Benchmarks[0].CompletedAt == nil {
+			t.Errorf("Expected the benchmark times to be stamped: %+v", job.Status.Benchmarks[0])
+		}
+	})
+
+	t.Run("a cancelled job keeps its state", func(t *testing.T) {
+		job := createJob("mmlu")
+		if err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}, now); err != nil {
+			t.Fatalf("SetBenchmarkStatus() returned error: %v", err)
+		}
+		if err := statemachine.SetJobState(job, api.EvaluationJobState{State: api.StateCancelled}, now); err != nil {
+			t.Fatalf("SetJobState() returned error: %v", err)
+		}
+		if err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: "mmlu", State: api.StateFailed}, now); err != nil {
+			t.Fatalf("SetBenchmarkStatus() returned error: %v", err)
+		}
+		if job.Status.State != api.StateCancelled {
+			t.Errorf("Expected the job to stay cancelled, got %s", job.Status.State)
+		}
+	})
+}
+
+func TestCancelJob(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("the unfinished benchmarks are cancelled", func(t *testing.T) {
+		job := createJob("mmlu", "hellaswag")
+		if err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted}, now); err != nil {
+			t.Fatalf("SetBenchmarkStatus() returned error: %v", err)
+		}
+		if err := statemachine.SetBenchmarkStatus(job, api.BenchmarkStatus{Name: "hellaswag", State: api.StateRunning}, now); err != nil {
+			t.Fatalf("SetBenchmarkStatus() returned error: %v", err)
+		}
+		if err := statemachine.CancelJob(job, now); err != nil {
+			t.Fatalf("CancelJob() returned error: %v", err)
+		}
+		if job.Status.State != api.StateCancelled || job.Status.CompletedAt == nil {
+			t.Errorf("Expected the job to be cancelled: %+v", job.Status)
+		}
+		if job.Status.Benchmarks[0].State != api.StateCompleted {
+			t.Errorf("Expected mmlu to stay completed, got %s", job.Status.Benchmarks[0].State)
+		}
+		if job.Status.Benchmarks[1].State != api.StateCancelled {
+			t.Errorf("Expected hellaswag to be cancelled, got %s", job.Status.Benchmarks[1].State)
+		}
+		if err := statemachine.CancelJob(job, now); err != nil {
+			t.Errorf("Expected cancelling twice to succeed, got %v", err)
+		}
+	})
+
+	t.Run("a pending job has no start time", func(t *testing.T) {
+		job := createJob("mmlu")
+		if err := statemachine.CancelJob(job, now); err != nil {
+			t.Fatalf("CancelJob() returned error: %v", err)
+		}
+		if job.Status.StartedAt != nil {
+			t.Errorf("Expected no start time, got %v", job.Status.StartedAt)
+		}
+	})
+
+	t.Run("a finished job can not be cancelled", func(t *testing.T) {
+		job := createJob("mmlu")
+		if err := statemachine.SetJobState(job, api.EvaluationJobState{State: api.StateCompleted}, now); err != nil {
+			t.Fatalf("SetJobState() returned error: %v", err)
+		}
+		if err := statemachine.CancelJob(job, now); !errors.Is(err, abstractions.ErrInvalidTransition) {
+			t.Errorf("Expected ErrInvalidTransition, got %v", err)
+		}
+	})
+}
+
+func createJob(benchmarks ...string) *api.EvaluationJobResource {
+	job := &api.EvaluationJobResource{
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{State: api.StatePending},
+		},
+	}
+	for _, benchmark := range benchmarks {
+		job.Benchmarks = append(job.Benchmarks, api.BenchmarkConfig{Ref: api.Ref{ID: benchmark}})
+	}
+	return job
+}
@@ -5,16 +5,24 @@ import (
 	"log/slog"
 
 	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
 	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
 	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
 )
 
-func NewStorage(serviceConfig *config.Config, logger *slog.Logger) (abstractions.Storage, error) {
+// NewStorage creates the storage of the first enabled database configuration, the changes
+// of the evaluation jobs are published to the bus when it is not nil
+func NewStorage(serviceConfig *config.Config, bus *events.Bus, logger *slog.Logger) (abstractions.Storage, error) {
+	aggregator, err := aggregation.NewAggregator(serviceConfig.Aggregation)
+	if err != nil {
+		return nil, err
+	}
 	// search for the first enabled database configuration
 	for name, sqlConfig := range serviceConfig.Database.SQL {
 		if sqlConfig.Enabled {
 			logger.Info("Using SQL database configuration", "name", name)
-			return storage_sql.NewSQLStorage(&sqlConfig, logger)
+			return storage_sql.NewSQLStorage(&sqlConfig, aggregator, serviceConfig.Webhooks, serviceConfig.MLflow, bus, logger)
 		}
 	}
 	for name, jsonConfig := range serviceConfig.Database.JSON {
@@ -33,8 +41,26 @@ func NewStorage(serviceConfig *config.Config, logger *slog.Logger) (abstractions
 	for name, sqlConfig := range serviceConfig.Database.SQL {
 		if sqlConfig.Fallback {
 			logger.Info("Using fallback SQL database configuration", "name", name)
-			return storage_sql.NewSQLStorage(&sqlConfig, logger)
+			return storage_sql.NewSQLStorage(&sqlConfig, aggregator, serviceConfig.Webhooks, serviceConfig.MLflow, bus, logger)
 		}
 	}
 	return nil, fmt.Errorf("failed to find a supported and enabled database configuration")
 }
+
+// Migrate runs the schema migration command (up, down or status) against the database
+// that NewStorage would use. Only the SQL database configurations have migrations.
+func Migrate(serviceConfig *config.Config, logger *slog.Logger, command string) (string, error) {
+	for name, sqlConfig := range serviceConfig.Database.SQL {
+		if sqlConfig.Enabled {
+			logger.Info("Migrating SQL database configuration", "name", name, "command", command)
+			return storage_sql.Migrate(&sqlConfig, logger, command)
+		}
+	}
+	for name, sqlConfig := range serviceConfig.Database.SQL {
+		if sqlConfig.Fallback {
+			logger.Info("Migrating fallback SQL database configuration", "name", name, "command", command)
+			return storage_sql.Migrate(&sqlConfig, logger, command)
+		}
+	}
+	return "", fmt.Errorf("failed to find an enabled SQL database configuration to migrate")
+}
@@ -0,0 +1,28 @@
+package storage_sql
+
+import (
+	"slices"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// AddEvaluationJobArtifact adds the artifact to the results of the job, an artifact with the
+// same name and benchmark is replaced. The content of the replaced artifact is not deleted from
+// the artifact store as it can be shared with other jobs.
+func (s *SQLStorage) AddEvaluationJobArtifact(ctx *executioncontext.ExecutionContext, id string, artifact *api.ArtifactResource) error {
+	return s.updateEvaluationJob(ctx, id, func(evaluation *api.EvaluationJobResource) error {
+		if evaluation.Results == nil {
+			evaluation.Results = &api.EvaluationJobResults{TotalEvaluations: len(evaluation.Benchmarks)}
+		}
+		index := slices.IndexFunc(evaluation.Results.Artifacts, func(a api.ArtifactResource) bool {
+			return a.Name == artifact.Name && a.Benchmark == artifact.Benchmark
+		})
+		if index >= 0 {
+			evaluation.Results.Artifacts[index] = *artifact
+		} else {
+			evaluation.Results.Artifacts = append(evaluation.Results.Artifacts, *artifact)
+		}
+		return nil
+	})
+}
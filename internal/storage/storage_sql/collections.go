@@ -0,0 +1,216 @@
+package storage_sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/patch"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// collectionStatus is the status column of the collections, collections do not have a lifecycle
+const collectionStatus = "active"
+
+// CreateCollection stores the collection with a new id, the id and the timestamps
+// are set on the given collection. An error wrapping abstractions.ErrConflict is
+// returned if the tenant already has a collection with the same name.
+func (s *SQLStorage) CreateCollection(ctx *executioncontext.ExecutionContext, collection *api.CollectionResource) error {
+	now := time.Now().UTC()
+	collection.ID = uuid.NewString()
+	collection.Tenant = ctx.Tenant
+	collection.Owner = ctx.Owner()
+	collection.CreatedAt = now
+	collection.UpdatedAt = now
+	collectionJSON, err := json.Marshal(collection)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(createAddCollectionStatement(s.dialect, s.sqlConfig.Collections.TableName), collection.ID, collectionStatus, string(collection.Tenant), collection.Name, string(collectionJSON))
+	if s.dialect.IsUniqueViolation(err) {
+		return duplicateCollectionName(collection.Name)
+	}
+	return err
+}
+
+// GetCollection returns the collection with the given id or an error wrapping
+// abstractions.ErrNotFound if there is no such collection, when summary is true
+// the benchmarks are not returned
+func (s *SQLStorage) GetCollection(ctx *executioncontext.ExecutionContext, id string, summary bool) (*api.CollectionResource, error) {
+	collection, _, err := s.getCollection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if summary {
+		collection.Benchmarks = nil
+	}
+	return collection, nil
+}
+
+// GetCollections returns a page of collections ordered by creation
+func (s *SQLStorage) GetCollections(ctx *executioncontext.ExecutionContext, limit int, offset int) (*api.CollectionResourceList, error) {
+	tableName := s.sqlConfig.Collections.TableName
+
+	args := tenantArgs(ctx)
+	totalCount := 0
+	if err := s.queryRow(createCountEntitiesStatement(tableName, len(args) > 0, false), args...).Scan(&totalCount); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(createListEntitiesStatement(tableName, len(args) > 0, false), append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []api.CollectionResource{}
+	for rows.Next() {
+		var resourceID string
+		var entity string
+		if err := rows.Scan(&resourceID, &entity); err != nil {
+			return nil, err
+		}
+		collection, err := unmarshalCollection(resourceID, entity)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *collection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &api.CollectionResourceList{
+		Page:  newPage(ctx, limit, offset, len(items), totalCount),
+		Items: items,
+	}, nil
+}
+
+// UpdateCollection replaces the configuration of the collection with the same id, the
+// id, the tenant and the creation time are kept and the collection is updated with the
+// stored values. An error wrapping abstractions.ErrNotFound is returned if there is no
+// such collection and abstractions.ErrConflict if the new name is already used.
+func (s *SQLStorage) UpdateCollection(ctx *executioncontext.ExecutionContext, collection *api.CollectionResource) error {
+	return s.updateCollection(ctx, collection.ID, func(stored *api.CollectionResource) error {
+		stored.CollectionConfig = collection.CollectionConfig
+		*collection = *stored
+		return nil
+	})
+}
+
+// PatchCollection applies the patch to the JSON representation of the collection, the fields of
+// the resource can not be patched. The patch is applied again to the latest version of the
+// collection when it is updated concurrently.
+func (s *SQLStorage) PatchCollection(ctx *executioncontext.ExecutionContext, id string, operations api.Patch, check func(collection *api.CollectionResource) error) (*api.CollectionResource, error) {
+	var patched *api.CollectionResource
+	err := s.updateCollection(ctx, id, func(collection *api.CollectionResource) error {
+		if err := patch.ApplyTo(collection, operations, patch.ImmutableResourceFields...); err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(collection); err != nil {
+				return err
+			}
+		}
+		patched = collection
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// DeleteCollection removes the collection, the evaluation jobs created from the collection
+// keep their copy of the benchmarks
+func (s *SQLStorage) DeleteCollection(ctx *executioncontext.ExecutionContext, id string) error {
+	args := tenantArgs(ctx)
+	result, err := s.exec(createDeleteEntityStatement(s.sqlConfig.Collections.TableName, len(args) > 0), append([]any{id}, args...)...)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return collectionNotFound(id)
+	}
+	return nil
+}
+
+// updateCollection reads the collection, applies the update function and writes the collection
+// back if nobody else has updated it in the meantime, otherwise the update is retried with the
+// latest version of the collection
+func (s *SQLStorage) updateCollection(ctx *executioncontext.ExecutionContext, id string, update func(collection *api.CollectionResource) error) error {
+	for range maxUpdateAttempts {
+		collection, version, err := s.getCollection(ctx, id)
+		if err != nil {
+			return err
+		}
+		collection.UpdatedAt = time.Now().UTC()
+		if err := update(collection); err != nil {
+			return err
+		}
+		collectionJSON, err := json.Marshal(collection)
+		if err != nil {
+			return err
+		}
+		result, err := s.exec(createUpdateCollectionStatement(s.sqlConfig.Collections.TableName), collection.Name, string(collectionJSON), id, version)
+		if s.dialect.IsUniqueViolation(err) {
+			return duplicateCollectionName(collection.Name)
+		}
+		if err != nil {
+			return err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("collection %s was updated concurrently %w", id, abstractions.ErrConflict)
+}
+
+// getCollection returns the collection and the version of the row, the collection of another
+// tenant is not found
+func (s *SQLStorage) getCollection(ctx *executioncontext.ExecutionContext, id string) (*api.CollectionResource, int64, error) {
+	var resourceID string
+	var entity string
+	var version int64
+	args := tenantArgs(ctx)
+	err := s.queryRow(createGetEntityStatement(s.sqlConfig.Collections.TableName, len(args) > 0), append([]any{id}, args...)...).Scan(&resourceID, &entity, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, collectionNotFound(id)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	collection, err := unmarshalCollection(resourceID, entity)
+	return collection, version, err
+}
+
+// unmarshalCollection the resource id column is the source of truth for the id
+func unmarshalCollection(resourceID string, entity string) (*api.CollectionResource, error) {
+	collection := &api.CollectionResource{}
+	if err := json.Unmarshal([]byte(entity), collection); err != nil {
+		return nil, err
+	}
+	collection.ID = resourceID
+	return collection, nil
+}
+
+func collectionNotFound(id string) error {
+	return fmt.Errorf("collection %s %w", id, abstractions.ErrNotFound)
+}
+
+func duplicateCollectionName(name string) error {
+	return fmt.Errorf("a collection named %s already exists %w", name, abstractions.ErrConflict)
+}
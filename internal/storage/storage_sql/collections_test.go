@@ -0,0 +1,210 @@
+package storage_sql_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/patch"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestCreateAndGetCollection(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	limit := 10
+	collection := &api.CollectionResource{
+		CollectionConfig: api.CollectionConfig{
+			Name: "reasoning",
+			Benchmarks: []api.BenchmarkConfig{
+				{Ref: api.Ref{ID: "arc_challenge"}, Limit: &limit, Parameters: map[string]any{"num_fewshot": 25.0}},
+				{Ref: api.Ref{ID: "hellaswag"}},
+			},
+		},
+	}
+	if err := storage.CreateCollection(ctx, collection); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+	if _, err := uuid.Parse(collection.ID); err != nil {
+		t.Fatalf("CreateCollection() did not set a UUID: %q", collection.ID)
+	}
+
+	got, err := storage.GetCollection(ctx, collection.ID, false)
+	if err != nil {
+		t.Fatalf("GetCollection() returned error: %v", err)
+	}
+	if got.Name != "reasoning" || len(got.Benchmarks) != 2 || *got.Benchmarks[0].Limit != 10 || got.Benchmarks[0].Parameters["num_fewshot"] != 25.0 {
+		t.Errorf("Unexpected collection returned: %+v", got)
+	}
+
+	summary, err := storage.GetCollection(ctx, collection.ID, true)
+	if err != nil {
+		t.Fatalf("GetCollection() returned error: %v", err)
+	}
+	if summary.Benchmarks != nil {
+		t.Errorf("Expected the summary without benchmarks, got %+v", summary.Benchmarks)
+	}
+
+	if _, err := storage.GetCollection(ctx, uuid.NewString(), false); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCollectionNamesAreUnique(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	first := &api.CollectionResource{CollectionConfig: api.CollectionConfig{Name: "reasoning"}}
+	if err := storage.CreateCollection(ctx, first); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+	duplicate := &api.CollectionResource{CollectionConfig: api.CollectionConfig{Name: "reasoning"}}
+	if err := storage.CreateCollection(ctx, duplicate); !errors.Is(err, abstractions.ErrConflict) {
+		t.Errorf("Expected ErrConflict for a duplicate name, got %v", err)
+	}
+
+	second := &api.CollectionResource{CollectionConfig: api.CollectionConfig{Name: "knowledge"}}
+	if err := storage.CreateCollection(ctx, second); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+	second.Name = "reasoning"
+	if err := storage.UpdateCollection(ctx, second); !errors.Is(err, abstractions.ErrConflict) {
+		t.Errorf("Expected ErrConflict when renaming to an existing name, got %v", err)
+	}
+}
+
+func TestUpdateAndDeleteCollection(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	collection := &api.CollectionResource{CollectionConfig: api.CollectionConfig{
+		Name:       "reasoning",
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "arc_challenge"}}},
+	}}
+	if err := storage.CreateCollection(ctx, collection); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+	createdAt := collection.CreatedAt
+
+	description := "Reasoning benchmarks"
+	update := &api.CollectionResource{
+		Resource: api.Resource{ID: collection.ID},
+		CollectionConfig: api.CollectionConfig{
+			Name:        "reasoning-v2",
+			Description: &description,
+			Benchmarks:  []api.BenchmarkConfig{{Ref: api.Ref{ID: "hellaswag"}}, {Ref: api.Ref{ID: "winogrande"}}},
+		},
+	}
+	if err := storage.UpdateCollection(ctx, update); err != nil {
+		t.Fatalf("UpdateCollection() returned error: %v", err)
+	}
+	if !update.CreatedAt.Equal(createdAt) || update.UpdatedAt.Before(createdAt) {
+		t.Errorf("Expected the creation time to be kept, got %v and %v", update.CreatedAt, update.UpdatedAt)
+	}
+
+	got, err := storage.GetCollection(ctx, collection.ID, false)
+	if err != nil {
+		t.Fatalf("GetCollection() returned error: %v", err)
+	}
+	if got.Name != "reasoning-v2" || got.Description == nil || *got.Description != description || len(got.Benchmarks) != 2 {
+		t.Errorf("Unexpected collection returned: %+v", got)
+	}
+
+	unknown := &api.CollectionResource{Resource: api.Resource{ID: uuid.NewString()}, CollectionConfig: api.CollectionConfig{Name: "unknown"}}
+	if err := storage.UpdateCollection(ctx, unknown); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when updating an unknown collection, got %v", err)
+	}
+
+	if err := storage.DeleteCollection(ctx, collection.ID); err != nil {
+		t.Fatalf("DeleteCollection() returned error: %v", err)
+	}
+	if _, err := storage.GetCollection(ctx, collection.ID, false); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after the delete, got %v", err)
+	}
+	if err := storage.DeleteCollection(ctx, collection.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound when deleting twice, got %v", err)
+	}
+}
+
+func TestGetCollections(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+	ctx.URI = "/api/v1/evaluations/collections"
+
+	for _, name := range []string{"a", "b", "c"} {
+		collection := &api.CollectionResource{CollectionConfig: api.CollectionConfig{Name: name}}
+		if err := storage.CreateCollection(ctx, collection); err != nil {
+			t.Fatalf("CreateCollection() returned error: %v", err)
+		}
+	}
+
+	page, err := storage.GetCollections(ctx, 2, 0)
+	if err != nil {
+		t.Fatalf("GetCollections() returned error: %v", err)
+	}
+	if page.TotalCount != 3 || len(page.Items) != 2 {
+		t.Fatalf("Expected 2 of 3 collections, got %d of %d", len(page.Items), page.TotalCount)
+	}
+	if page.Next == nil {
+		t.Errorf("Expected a link to the next page")
+	}
+
+	page, err = storage.GetCollections(ctx, 2, 2)
+	if err != nil {
+		t.Fatalf("GetCollections() returned error: %v", err)
+	}
+	if len(page.Items) != 1 || page.Next != nil {
+		t.Errorf("Expected the last collection without a next page, got %d items and %v", len(page.Items), page.Next)
+	}
+}
+
+func TestPatchCollection(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	collection := &api.CollectionResource{CollectionConfig: api.CollectionConfig{
+		Name:       "reasoning",
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "arc_challenge"}}},
+	}}
+	if err := storage.CreateCollection(ctx, collection); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+
+	patched, err := storage.PatchCollection(ctx, collection.ID, api.Patch{
+		{Op: api.PatchOpReplace, Path: "/name", Value: json.RawMessage(`"reasoning-v2"`)},
+		{Op: api.PatchOpAdd, Path: "/benchmarks/-", Value: json.RawMessage(`{"id":"hellaswag"}`)},
+	}, nil)
+	if err != nil {
+		t.Fatalf("PatchCollection() returned error: %v", err)
+	}
+	if patched.ID != collection.ID || patched.Name != "reasoning-v2" || len(patched.Benchmarks) != 2 {
+		t.Errorf("Unexpected patched collection: %+v", patched)
+	}
+
+	checkFailed := errors.New("check failed")
+	_, err = storage.PatchCollection(ctx, collection.ID, api.Patch{{Op: api.PatchOpRemove, Path: "/benchmarks/0"}}, func(*api.CollectionResource) error {
+		return checkFailed
+	})
+	if !errors.Is(err, checkFailed) {
+		t.Errorf("Expected the error of the check, got %v", err)
+	}
+	_, err = storage.PatchCollection(ctx, collection.ID, api.Patch{{Op: api.PatchOpReplace, Path: "/created_at", Value: json.RawMessage(`"2020-01-01T00:00:00Z"`)}}, nil)
+	if !errors.Is(err, patch.ErrImmutable) {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+
+	got, err := storage.GetCollection(ctx, collection.ID, false)
+	if err != nil {
+		t.Fatalf("GetCollection() returned error: %v", err)
+	}
+	if got.Name != "reasoning-v2" || len(got.Benchmarks) != 2 || !got.CreatedAt.Equal(collection.CreatedAt) {
+		t.Errorf("Expected only the successful patch to be stored, got %+v", got)
+	}
+
+	if _, err := storage.PatchCollection(ctx, uuid.NewString(), api.Patch{}, nil); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
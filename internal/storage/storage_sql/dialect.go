@@ -0,0 +1,158 @@
+package storage_sql
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dialect hides the differences between the SQL databases supported by SQLStorage.
+// The statements in helper.go are written with ? placeholders and converted to the
+// dialect placeholders with Rebind.
+type dialect interface {
+	// Name is the name of the dialect used in the logs
+	Name() string
+	// Placeholder returns the placeholder for the n-th (1 based) query argument
+	Placeholder(n int) string
+	// Rebind converts the ? placeholders in the query to the dialect placeholders
+	Rebind(query string) string
+	// JSONType is the column type used to store the JSON entities
+	JSONType() string
+	// InsertReturningID returns an insert statement that returns the generated id,
+	// LastInsertId() is not supported by all the drivers (i.e. pgx)
+	InsertReturningID(tableName string, columns ...string) string
+	// Upsert returns an insert statement that updates the given columns when a row
+	// with the same conflict columns already exists
+	Upsert(tableName string, conflictColumns []string, columns ...string) string
+	// IsUniqueViolation reports whether the error was returned because a unique index
+	// already has a row with the same values
+	IsUniqueViolation(err error) bool
+	// TransactionLock returns the statement that takes the lock with the given key until the
+	// end of the transaction, or an empty string when the writes are already serialised
+	TransactionLock(key int64) string
+}
+
+// newDialect returns the dialect for the configured driver
+func newDialect(driver string) (dialect, error) {
+	switch driver {
+	case "sqlite":
+		return &sqliteDialect{}, nil
+	case "pgx":
+		return &postgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported SQL driver %s", driver)
+	}
+}
+
+type sqliteDialect struct{}
+
+func (d *sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (d *sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (d *sqliteDialect) JSONType() string {
+	return "TEXT"
+}
+
+func (d *sqliteDialect) InsertReturningID(tableName string, columns ...string) string {
+	return insertStatement(d, tableName, columns) + " RETURNING id;"
+}
+
+func (d *sqliteDialect) Upsert(tableName string, conflictColumns []string, columns ...string) string {
+	return upsertStatement(d, tableName, conflictColumns, columns)
+}
+
+func (d *sqliteDialect) IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// TransactionLock sqlite serialises the write transactions with the database lock
+func (d *sqliteDialect) TransactionLock(key int64) string {
+	return ""
+}
+
+type postgresDialect struct{}
+
+func (d *postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (d *postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// Rebind replaces the ? placeholders with $1, $2, ... ignoring any ? in quoted strings
+func (d *postgresDialect) Rebind(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+	n := 0
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+			sb.WriteRune(r)
+		case r == '?' && !inQuote:
+			n++
+			sb.WriteString(d.Placeholder(n))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func (d *postgresDialect) JSONType() string {
+	return "JSONB"
+}
+
+func (d *postgresDialect) InsertReturningID(tableName string, columns ...string) string {
+	return insertStatement(d, tableName, columns) + " RETURNING id;"
+}
+
+func (d *postgresDialect) Upsert(tableName string, conflictColumns []string, columns ...string) string {
+	return upsertStatement(d, tableName, conflictColumns, columns)
+}
+
+// uniqueViolationCode is the SQLSTATE of the unique_violation errors
+const uniqueViolationCode = "23505"
+
+func (d *postgresDialect) IsUniqueViolation(err error) bool {
+	var pgError *pgconn.PgError
+	return errors.As(err, &pgError) && pgError.Code == uniqueViolationCode
+}
+
+func (d *postgresDialect) TransactionLock(key int64) string {
+	return fmt.Sprintf("SELECT pg_advisory_xact_lock(%d);", key)
+}
+
+func insertStatement(d dialect, tableName string, columns []string) string {
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = d.Placeholder(i + 1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", tableName, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+}
+
+// upsertStatement uses the ON CONFLICT clause that is supported by both sqlite and postgres
+func upsertStatement(d dialect, tableName string, conflictColumns []string, columns []string) string {
+	updates := []string{}
+	for _, column := range columns {
+		if !slices.Contains(conflictColumns, column) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
+		}
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s;", insertStatement(d, tableName, columns), strings.Join(conflictColumns, ", "), strings.Join(updates, ", "))
+}
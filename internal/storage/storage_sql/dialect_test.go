@@ -0,0 +1,40 @@
+package storage_sql
+
+import "testing"
+
+func TestDialects(t *testing.T) {
+	sqlite, err := newDialect("sqlite")
+	if err != nil {
+		t.Fatalf("newDialect(sqlite) returned error: %v", err)
+	}
+	postgres, err := newDialect("pgx")
+	if err != nil {
+		t.Fatalf("newDialect(pgx) returned error: %v", err)
+	}
+	if _, err := newDialect("mysql"); err == nil {
+		t.Error("Expected an error for an unsupported driver")
+	}
+
+	testCases := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"sqlite rebind", sqlite.Rebind("SELECT * FROM t WHERE a = ? AND b = ?"), "SELECT * FROM t WHERE a = ? AND b = ?"},
+		{"postgres rebind", postgres.Rebind("SELECT * FROM t WHERE a = ? AND b = '?' AND c = ?"), "SELECT * FROM t WHERE a = $1 AND b = '?' AND c = $2"},
+		{"sqlite json type", sqlite.JSONType(), "TEXT"},
+		{"postgres json type", postgres.JSONType(), "JSONB"},
+		{"sqlite insert", sqlite.InsertReturningID("t", "a", "b"), "INSERT INTO t (a, b) VALUES (?, ?) RETURNING id;"},
+		{"postgres insert", postgres.InsertReturningID("t", "a", "b"), "INSERT INTO t (a, b) VALUES ($1, $2) RETURNING id;"},
+		{"sqlite transaction lock", sqlite.TransactionLock(7), ""},
+		{"postgres transaction lock", postgres.TransactionLock(7), "SELECT pg_advisory_xact_lock(7);"},
+		{"postgres upsert", postgres.Upsert("t", []string{"a"}, "a", "b", "c"), "INSERT INTO t (a, b, c) VALUES ($1, $2, $3) ON CONFLICT (a) DO UPDATE SET b = excluded.b, c = excluded.c;"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, tc.got)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package storage_sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// maxUpdateAttempts is the number of times an update is retried when the job is updated concurrently
+const maxUpdateAttempts = 5
+
+// queryer is implemented by both *sql.DB and *sql.Tx so that the read helpers
+// can be used inside and outside of a transaction
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// CreateEvaluationJob creates a new evaluation job in the database
+// the evaluation job is stored in the evaluations table as a JSON string
+// the evaluation job is returned as a EvaluationJobResource
+func (s *SQLStorage) CreateEvaluationJob(executionContext *executioncontext.ExecutionContext, evaluation *api.EvaluationJobConfig) (*api.EvaluationJobResource, error) {
+	now := time.Now().UTC()
+	evaluationResource := &api.EvaluationJobResource{
+		Resource: api.Resource{
+			ID:        uuid.NewString(),
+			Tenant:    executionContext.Tenant,
+			Owner:     executionContext.Owner(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		EvaluationJobConfig: *evaluation,
+		Status: api.EvaluationJobStatus{
+			EvaluationJobState: api.EvaluationJobState{
+				State:   api.StatePending,
+				Message: "Evaluation job created",
+			},
+			Benchmarks: nil,
+		},
+		Results: nil,
+	}
+	evaluationJSON, err := json.Marshal(evaluationResource)
+	if err != nil {
+		return nil, err
+	}
+	err = s.withTransaction(func(tx *sql.Tx) error {
+		_, err := tx.Exec(s.dialect.Rebind(createAddEntityStatement(s.dialect, s.sqlConfig.Evaluations.TableName)), evaluationResource.ID, string(evaluationResource.Status.State), string(evaluationResource.Tenant), string(evaluationJSON))
+		if err != nil {
+			return err
+		}
+		return s.addEvent(tx, evaluationResource, evaluationJSON)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notifyEvents()
+	return evaluationResource, nil
+}
+
+// GetEvaluationJob returns the evaluation job with the given id or an error
+// wrapping abstractions.ErrNotFound if there is no such job
+func (s *SQLStorage) GetEvaluationJob(ctx *executioncontext.ExecutionContext, id string) (*api.EvaluationJobResource, error) {
+	evaluation, _, err := s.getEvaluationJob(ctx, s.pool, id)
+	return evaluation, err
+}
+
+// GetEvaluationJobs returns a page of evaluation jobs ordered by creation, optionally
+// filtered by the job state. When summary is true the benchmark statuses and the
+// results are not returned.
+func (s *SQLStorage) GetEvaluationJobs(ctx *executioncontext.ExecutionContext, summary bool, limit int, offset int, statusFilter string) (*api.EvaluationJobResourceList, error) {
+	tableName := s.sqlConfig.Evaluations.TableName
+	filterByStatus := statusFilter != ""
+
+	countArgs := tenantArgs(ctx)
+	filterByTenant := len(countArgs) > 0
+	if filterByStatus {
+		countArgs = append(countArgs, statusFilter)
+	}
+	totalCount := 0
+	if err := s.queryRow(createCountEntitiesStatement(tableName, filterByTenant, filterByStatus), countArgs...).Scan(&totalCount); err != nil {
+		return nil, err
+	}
+
+	listArgs := append(countArgs, limit, offset)
+	rows, err := s.query(createListEntitiesStatement(tableName, filterByTenant, filterByStatus), listArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []api.EvaluationJobResource{}
+	for rows.Next() {
+		var resourceID string
+		var entity string
+		if err := rows.Scan(&resourceID, &entity); err != nil {
+			return nil, err
+		}
+		evaluation, err := unmarshalEvaluationJob(resourceID, entity)
+		if err != nil {
+			return nil, err
+		}
+		if summary {
+			evaluation.Status.Benchmarks = nil
+			evaluation.Results = nil
+		}
+		items = append(items, *evaluation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &api.EvaluationJobResourceList{
+		Page:  newPage(ctx, limit, offset, len(items), totalCount),
+		Items: items,
+	}, nil
+}
+
+// DeleteEvaluationJob removes the evaluation job from the database when hardDelete
+// is true, otherwise the job is kept and the job and its unfinished benchmarks are
+// marked as cancelled. Cancelling a job that has already finished returns an error
+// wrapping abstractions.ErrInvalidTransition.
+func (s *SQLStorage) DeleteEvaluationJob(ctx *executioncontext.ExecutionContext, id string, hardDelete bool) error {
+	if !hardDelete {
+		return s.updateEvaluationJob(ctx, id, func(evaluation *api.EvaluationJobResource) error {
+			return statemachine.CancelJob(evaluation, time.Now().UTC())
+		})
+	}
+	args := tenantArgs(ctx)
+	result, err := s.exec(createDeleteEntityStatement(s.sqlConfig.Evaluations.TableName, len(args) > 0), append([]any{id}, args...)...)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound(id)
+	}
+	return nil
+}
+
+// UpdateBenchmarkStatusForJob replaces the status of the benchmark with the same name
+// or adds it to the job if the benchmark does not have a status yet, the job state is
+// derived from the benchmark statuses
+func (s *SQLStorage) UpdateBenchmarkStatusForJob(ctx *executioncontext.ExecutionContext, id string, status api.BenchmarkStatus) error {
+	return s.updateEvaluationJob(ctx, id, func(evaluation *api.EvaluationJobResource) error {
+		return statemachine.SetBenchmarkStatus(evaluation, status, time.Now().UTC())
+	})
+}
+
+// UpdateEvaluationJobStatus sets the overall state and message of the job, an error wrapping
+// abstractions.ErrInvalidTransition is returned if the state machine does not allow the change
+func (s *SQLStorage) UpdateEvaluationJobStatus(ctx *executioncontext.ExecutionContext, id string, state api.EvaluationJobState) error {
+	return s.updateEvaluationJob(ctx, id, func(evaluation *api.EvaluationJobResource) error {
+		return statemachine.SetJobState(evaluation, state, time.Now().UTC())
+	})
+}
+
+// updateEvaluationJob reads the job, applies the update function and writes the job back
+// if nobody else has updated it in the meantime, otherwise the update is retried with the
+// latest version of the job. The version identifies the row so the update is scoped by
+// the tenant of the read. The webhook deliveries and the event of the update are written in
+// the same transaction as the job.
+func (s *SQLStorage) updateEvaluationJob(ctx *executioncontext.ExecutionContext, id string, update func(evaluation *api.EvaluationJobResource) error) error {
+	return s.updateEvaluationJobInTransaction(ctx, id, update, nil)
+}
+
+// updateEvaluationJobInTransaction is updateEvaluationJob with a function that is called in the
+// transaction of the update once the job has been updated, the update is rolled back if it fails
+func (s *SQLStorage) updateEvaluationJobInTransaction(ctx *executioncontext.ExecutionContext, id string, update func(evaluation *api.EvaluationJobResource) error, inTransaction func(tx *sql.Tx, evaluation *api.EvaluationJobResource) error) error {
+	for range maxUpdateAttempts {
+		evaluation, version, err := s.getEvaluationJob(ctx, s.pool, id)
+		if err != nil {
+			return err
+		}
+		before := snapshotJob(evaluation)
+		if err := update(evaluation); err != nil {
+			return err
+		}
+		// the aggregated metrics are computed once the last benchmark has finished and
+		// again when results are reported after that
+		if statemachine.IsTerminal(evaluation.Status.State) {
+			s.aggregator.AggregateJob(evaluation)
+		}
+		evaluation.UpdatedAt = time.Now().UTC()
+		evaluationJSON, err := json.Marshal(evaluation)
+		if err != nil {
+			return err
+		}
+		events := s.webhookEvents(before, evaluation, evaluation.UpdatedAt)
+		updated := false
+		err = s.withTransaction(func(tx *sql.Tx) error {
+			result, err := tx.Exec(s.dialect.Rebind(createUpdateEntityStatement(s.sqlConfig.Evaluations.TableName)), string(evaluation.Status.State), string(evaluationJSON), id, version)
+			if err != nil {
+				return err
+			}
+			count, err := result.RowsAffected()
+			if err != nil || count == 0 {
+				return err
+			}
+			updated = true
+			if err := s.addWebhookDeliveries(tx, evaluation, events); err != nil {
+				return err
+			}
+			if inTransaction != nil {
+				if err := inTransaction(tx, evaluation); err != nil {
+					return err
+				}
+			}
+			return s.addEvent(tx, evaluation, evaluationJSON)
+		})
+		if err != nil {
+			return err
+		}
+		if updated {
+			s.notifyEvents()
+			return nil
+		}
+	}
+	return fmt.Errorf("evaluation job %s was updated concurrently %w", id, abstractions.ErrConflict)
+}
+
+func (s *SQLStorage) getEvaluationJob(ctx *executioncontext.ExecutionContext, q queryer, id string) (*api.EvaluationJobResource, int64, error) {
+	var resourceID string
+	var entity string
+	var version int64
+	args := tenantArgs(ctx)
+	err := q.QueryRow(s.dialect.Rebind(createGetEntityStatement(s.sqlConfig.Evaluations.TableName, len(args) > 0)), append([]any{id}, args...)...).Scan(&resourceID, &entity, &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, notFound(id)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	evaluation, err := unmarshalEvaluationJob(resourceID, entity)
+	return evaluation, version, err
+}
+
+// unmarshalEvaluationJob the resource id column is the source of truth for the id
+func unmarshalEvaluationJob(resourceID string, entity string) (*api.EvaluationJobResource, error) {
+	evaluation := &api.EvaluationJobResource{}
+	if err := json.Unmarshal([]byte(entity), evaluation); err != nil {
+		return nil, err
+	}
+	evaluation.ID = resourceID
+	return evaluation, nil
+}
+
+func notFound(id string) error {
+	return fmt.Errorf("evaluation job %s %w", id, abstractions.ErrNotFound)
+}
@@ -0,0 +1,165 @@
+package storage_sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+const (
+	// eventsLockID is the key of the Postgres advisory lock that serialises the event writes, the
+	// events are committed in the order of their ids so that a replica that reads the events after
+	// the last id it has read does not miss an event that is committed later with a lower id
+	eventsLockID = 7_216_002
+	// eventPollInterval is the interval at which the events written by the other replicas are read
+	eventPollInterval = 250 * time.Millisecond
+	// eventBatchSize is the number of events read by a query
+	eventBatchSize = 256
+	// eventRetention is how long the events are kept in the database
+	eventRetention = time.Hour
+	// eventPruneInterval is the interval at which the events older than the retention are deleted
+	eventPruneInterval = time.Minute
+)
+
+// eventsTable is the table of the events of the jobs, it is named after the evaluations table
+func (s *SQLStorage) eventsTable() string {
+	return s.sqlConfig.Evaluations.TableName + "_events"
+}
+
+// addEvent writes the change of the job in the transaction of the change so that the event
+// is only published if the change has been stored, the events are only written when there
+// is a bus to publish them to
+func (s *SQLStorage) addEvent(tx *sql.Tx, evaluation *api.EvaluationJobResource, evaluationJSON []byte) error {
+	if s.bus == nil {
+		return nil
+	}
+	if lock := s.dialect.TransactionLock(eventsLockID); lock != "" {
+		if _, err := tx.Exec(lock); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(s.dialect.Rebind(createAddEventStatement(s.dialect, s.eventsTable())), evaluation.ID, string(evaluation.Tenant), evaluation.UpdatedAt.UnixMilli(), string(evaluationJSON))
+	return err
+}
+
+// notifyEvents makes the storage read the events without waiting for the poll interval, the
+// changes made by this replica are published as soon as they have been committed
+func (s *SQLStorage) notifyEvents() {
+	if s.bus == nil {
+		return
+	}
+	select {
+	case s.eventsWake <- struct{}{}:
+	default:
+	}
+}
+
+// startEvents loads the last events into the history of the bus and then reads the events
+// written by all the replicas in the background until the storage is closed
+func (s *SQLStorage) startEvents() error {
+	rows, err := s.query(createListLastEventsStatement(s.eventsTable()), s.bus.HistorySize())
+	if err != nil {
+		return err
+	}
+	history, err := scanEvents(rows)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(history)
+
+	// all the events after the one before the first loaded event are in the history
+	var lastID uint64
+	if len(history) > 0 {
+		lastID = history[0].ID - 1
+	}
+	s.bus.Seek(lastID)
+	s.bus.Publish(history...)
+	if len(history) > 0 {
+		lastID = history[len(history)-1].ID
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopEvents = cancel
+	s.eventsDone = make(chan struct{})
+	go s.pollEvents(ctx, lastID)
+	return nil
+}
+
+func (s *SQLStorage) pollEvents(ctx context.Context, lastID uint64) {
+	defer close(s.eventsDone)
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+	var prunedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.eventsWake:
+		}
+		lastID = s.readEvents(lastID)
+
+		if time.Since(prunedAt) < eventPruneInterval {
+			continue
+		}
+		prunedAt = time.Now()
+		if _, err := s.exec(createDeleteEventsStatement(s.eventsTable()), prunedAt.Add(-eventRetention).UnixMilli()); err != nil {
+			s.logger.Error("Failed to delete the old job events", "error", err.Error())
+		}
+	}
+}
+
+// readEvents publishes the events written after lastID and returns the id of the last event
+// that has been published
+func (s *SQLStorage) readEvents(lastID uint64) uint64 {
+	for {
+		rows, err := s.query(createListEventsStatement(s.eventsTable()), int64(lastID), eventBatchSize)
+		if err != nil {
+			s.logger.Error("Failed to read the job events", "error", err.Error())
+			return lastID
+		}
+		batch, err := scanEvents(rows)
+		if err != nil {
+			s.logger.Error("Failed to read the job events", "error", err.Error())
+			return lastID
+		}
+		s.bus.Publish(batch...)
+		if len(batch) > 0 {
+			lastID = batch[len(batch)-1].ID
+		}
+		if len(batch) < eventBatchSize {
+			return lastID
+		}
+	}
+}
+
+// scanEvents reads and closes the rows, the data of an event is marshalled again so that it
+// is the same JSON as the job returned by the API whatever the column type
+func scanEvents(rows *sql.Rows) ([]events.Event, error) {
+	defer rows.Close()
+	result := []events.Event{}
+	for rows.Next() {
+		var id int64
+		var jobID string
+		var tenant string
+		var entity string
+		if err := rows.Scan(&id, &jobID, &tenant, &entity); err != nil {
+			return nil, err
+		}
+		evaluation, err := unmarshalEvaluationJob(jobID, entity)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(evaluation)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, events.Event{ID: uint64(id), Type: events.EventJob, Tenant: api.Tenant(tenant), JobID: jobID, Data: data})
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,96 @@
+package storage_sql_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestJobEvents(t *testing.T) {
+	// the replicas share the database
+	sqlConfig := &config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}
+	createReplica := func(t *testing.T) (abstractions.Storage, *events.Bus) {
+		t.Helper()
+		bus := events.NewBus(events.DefaultHistorySize)
+		storage, err := storage_sql.NewSQLStorage(sqlConfig, nil, nil, nil, bus, logging.FallbackLogger())
+		if err != nil {
+			t.Fatalf("NewSQLStorage() returned error: %v", err)
+		}
+		t.Cleanup(func() { storage.Close() })
+		return storage, bus
+	}
+	next := func(t *testing.T, subscription *events.Subscription) events.Event {
+		t.Helper()
+		select {
+		case event := <-subscription.C:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for the next event")
+		}
+		return events.Event{}
+	}
+	all := func(event events.Event) bool { return true }
+
+	ctx := createExecutionContext()
+	ctx.Tenant = "team-a"
+	first, firstBus := createReplica(t)
+	second, secondBus := createReplica(t)
+
+	firstSubscription, _, _ := firstBus.Subscribe(0, all)
+	defer firstBus.Unsubscribe(firstSubscription)
+	secondSubscription, _, _ := secondBus.Subscribe(0, all)
+	defer secondBus.Unsubscribe(secondSubscription)
+
+	job, err := first.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model:      api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	created := next(t, firstSubscription)
+
+	t.Run("the changes made by a replica are published by all the replicas with the same id", func(t *testing.T) {
+		event := next(t, secondSubscription)
+		if event.ID != created.ID || event.JobID != job.ID || event.Tenant != "team-a" || event.Type != events.EventJob {
+			t.Errorf("Expected the event %d of the job %s, got %+v", created.ID, job.ID, event)
+		}
+	})
+
+	if err := second.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: api.StateCancelled, Message: "Cancelled"}); err != nil {
+		t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+	}
+	cancelled := next(t, secondSubscription)
+	if event := next(t, firstSubscription); event.ID != cancelled.ID {
+		t.Errorf("Expected the event %d on the first replica, got %+v", cancelled.ID, event)
+	}
+
+	t.Run("a client resumes on another replica after the last event it has received", func(t *testing.T) {
+		resumed, replay, complete := firstBus.Subscribe(created.ID, all)
+		defer firstBus.Unsubscribe(resumed)
+		if !complete || len(replay) != 1 || replay[0].ID != cancelled.ID {
+			t.Errorf("Expected the event %d to be replayed, got %+v %v", cancelled.ID, replay, complete)
+		}
+	})
+
+	t.Run("a replica that starts loads the last events", func(t *testing.T) {
+		_, thirdBus := createReplica(t)
+		resumed, replay, complete := thirdBus.Subscribe(created.ID, all)
+		defer thirdBus.Unsubscribe(resumed)
+		if !complete || len(replay) != 1 || replay[0].ID != cancelled.ID || resumed.LastEventID != cancelled.ID {
+			t.Errorf("Expected the event %d to be replayed, got %+v %v", cancelled.ID, replay, complete)
+		}
+	})
+}
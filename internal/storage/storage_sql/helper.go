@@ -1,23 +1,231 @@
 package storage_sql
 
-// createDatabaseStatement the order or arguments is:
-// database_name
-func createDatabaseStatement() string {
-	return `CREATE DATABASE IF NOT EXISTS ?;`
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// Table names can not be passed as query arguments so they are added to the
+// statements here, all the other values are passed as query arguments.
+// The tables are created by the migrations in the migrations package.
+
+// createAddEntityStatement the order or arguments is:
+// resource_id status tenant entity
+func createAddEntityStatement(d dialect, tableName string) string {
+	return insertStatement(d, tableName, []string{"resource_id", "status", "tenant", "entity"}) + ";"
 }
 
-// createTableStatement	the order or arguments is:
-// table_name json_field_type
-func createTableStatement() string {
-	return `CREATE TABLE IF NOT EXISTS ? (
-    id      INTEGER PRIMARY KEY AUTOINCREMENT,
-    entity 	? NOT NULL
-);`
+// createAddCollectionStatement the name of a collection is unique per tenant, the order or arguments is:
+// resource_id status tenant name entity
+func createAddCollectionStatement(d dialect, tableName string) string {
+	return insertStatement(d, tableName, []string{"resource_id", "status", "tenant", "name", "entity"}) + ";"
 }
 
-// createAddEntityStatement the order or arguments is:
-// table_name entity
-func createAddEntityStatement() string {
-	return `INSERT INTO ? (entity)
-	VALUES (?);`
+// createGetEntityStatement the order or arguments is:
+// resource_id [tenant]
+func createGetEntityStatement(tableName string, filterByTenant bool) string {
+	return fmt.Sprintf(`SELECT resource_id, entity, version FROM %s%s;`, tableName, whereClause("resource_id = ?", tenantCondition(filterByTenant)))
+}
+
+// createListEntitiesStatement the order or arguments is:
+// [tenant] [status] limit offset
+func createListEntitiesStatement(tableName string, filterByTenant bool, filterByStatus bool) string {
+	return fmt.Sprintf(`SELECT resource_id, entity FROM %s%s ORDER BY id LIMIT ? OFFSET ?;`, tableName, whereClause(tenantCondition(filterByTenant), statusCondition(filterByStatus)))
+}
+
+// createCountEntitiesStatement the order or arguments is:
+// [tenant] [status]
+func createCountEntitiesStatement(tableName string, filterByTenant bool, filterByStatus bool) string {
+	return fmt.Sprintf(`SELECT COUNT(*) FROM %s%s;`, tableName, whereClause(tenantCondition(filterByTenant), statusCondition(filterByStatus)))
+}
+
+// createUpdateEntityStatement only updates the row if the version has not changed since
+// the entity was read, the order or arguments is:
+// status entity resource_id version
+func createUpdateEntityStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET status = ?, entity = ?, version = version + 1 WHERE resource_id = ? AND version = ?;`, tableName)
+}
+
+// createUpdateCollectionStatement only updates the row if the version has not changed since
+// the collection was read, the order or arguments is:
+// name entity resource_id version
+func createUpdateCollectionStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET name = ?, entity = ?, version = version + 1 WHERE resource_id = ? AND version = ?;`, tableName)
+}
+
+// createDeleteEntityStatement the order or arguments is:
+// resource_id [tenant]
+func createDeleteEntityStatement(tableName string, filterByTenant bool) string {
+	return fmt.Sprintf(`DELETE FROM %s%s;`, tableName, whereClause("resource_id = ?", tenantCondition(filterByTenant)))
+}
+
+// whereClause returns the WHERE clause of the conditions, the empty conditions are skipped
+func whereClause(conditions ...string) string {
+	conditions = slices.DeleteFunc(conditions, func(condition string) bool { return condition == "" })
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func tenantCondition(filterByTenant bool) string {
+	if filterByTenant {
+		return "tenant = ?"
+	}
+	return ""
+}
+
+func statusCondition(filterByStatus bool) string {
+	if filterByStatus {
+		return "status = ?"
+	}
+	return ""
+}
+
+// claimableCondition matches the pending jobs that have not been claimed and the jobs
+// whose lease has expired because the replica running them has stopped, the order or
+// arguments is:
+// now
+func claimableCondition() string {
+	return fmt.Sprintf(`((status = '%s' AND lease_owner IS NULL) OR (status IN ('%s', '%s') AND lease_expires_at < ?))`, api.StatePending, api.StatePending, api.StateRunning)
+}
+
+// createListClaimableStatement the order or arguments is:
+// now limit
+func createListClaimableStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT resource_id FROM %s WHERE %s ORDER BY id LIMIT ?;`, tableName, claimableCondition())
+}
+
+// createClaimStatement only updates the row if the job is still claimable so that a
+// single replica wins the claim, the order or arguments is:
+// lease_owner lease_expires_at resource_id now
+func createClaimStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET lease_owner = ?, lease_expires_at = ? WHERE resource_id = ? AND %s;`, tableName, claimableCondition())
+}
+
+// createRenewLeaseStatement the order or arguments is:
+// lease_expires_at resource_id lease_owner
+func createRenewLeaseStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET lease_expires_at = ? WHERE resource_id = ? AND lease_owner = ?;`, tableName)
+}
+
+// createReleaseLeaseStatement the order or arguments is:
+// resource_id lease_owner
+func createReleaseLeaseStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET lease_owner = NULL, lease_expires_at = NULL WHERE resource_id = ? AND lease_owner = ?;`, tableName)
+}
+
+// createAddDeliveryStatement the order or arguments is:
+// resource_id job_id tenant status next_attempt_at entity payload
+func createAddDeliveryStatement(d dialect, tableName string) string {
+	return insertStatement(d, tableName, []string{"resource_id", "job_id", "tenant", "status", "next_attempt_at", "entity", "payload"}) + ";"
+}
+
+// createListDueDeliveriesStatement the order or arguments is:
+// now limit
+func createListDueDeliveriesStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT resource_id FROM %s WHERE status = '%s' AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?;`, tableName, api.DeliveryStatusPending)
+}
+
+// createClaimDeliveryStatement only updates the row if the delivery is still due so that a
+// single replica wins the claim, the order or arguments is:
+// lease_owner next_attempt_at resource_id now
+func createClaimDeliveryStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET lease_owner = ?, next_attempt_at = ? WHERE resource_id = ? AND status = '%s' AND next_attempt_at <= ?;`, tableName, api.DeliveryStatusPending)
+}
+
+// createGetDeliveryStatement the order or arguments is:
+// resource_id
+func createGetDeliveryStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT entity, payload FROM %s WHERE resource_id = ?;`, tableName)
+}
+
+// createUpdateDeliveryStatement only updates the row if the delivery is still claimed by the
+// owner, the order or arguments is:
+// status next_attempt_at entity resource_id lease_owner
+func createUpdateDeliveryStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET status = ?, next_attempt_at = ?, entity = ?, lease_owner = NULL WHERE resource_id = ? AND lease_owner = ?;`, tableName)
+}
+
+// createListJobDeliveriesStatement the order or arguments is:
+// job_id [tenant]
+func createListJobDeliveriesStatement(tableName string, filterByTenant bool) string {
+	return fmt.Sprintf(`SELECT entity FROM %s%s ORDER BY id;`, tableName, whereClause("job_id = ?", tenantCondition(filterByTenant)))
+}
+
+// createAddEventStatement the order or arguments is:
+// job_id tenant created_at entity
+func createAddEventStatement(d dialect, tableName string) string {
+	return insertStatement(d, tableName, []string{"job_id", "tenant", "created_at", "entity"}) + ";"
+}
+
+// createListEventsStatement the order or arguments is:
+// id limit
+func createListEventsStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT id, job_id, tenant, entity FROM %s WHERE id > ? ORDER BY id LIMIT ?;`, tableName)
+}
+
+// createListLastEventsStatement the order or arguments is:
+// limit
+func createListLastEventsStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT id, job_id, tenant, entity FROM %s ORDER BY id DESC LIMIT ?;`, tableName)
+}
+
+// createDeleteEventsStatement the order or arguments is:
+// created_at
+func createDeleteEventsStatement(tableName string) string {
+	return fmt.Sprintf(`DELETE FROM %s WHERE created_at < ?;`, tableName)
+}
+
+// createAddRecordingStatement the order or arguments is:
+// job_id tenant next_attempt_at entity
+func createAddRecordingStatement(d dialect, tableName string) string {
+	return insertStatement(d, tableName, []string{"job_id", "tenant", "next_attempt_at", "entity"}) + ";"
+}
+
+// createGetRecordingStatement the order or arguments is:
+// job_id
+func createGetRecordingStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT entity, lease_owner FROM %s WHERE job_id = ?;`, tableName)
+}
+
+// createUpdateRecordingStatement the order or arguments is:
+// next_attempt_at entity job_id
+func createUpdateRecordingStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET next_attempt_at = ?, entity = ? WHERE job_id = ?;`, tableName)
+}
+
+// createUpdateRecordingEntityStatement the order or arguments is:
+// entity job_id
+func createUpdateRecordingEntityStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET entity = ? WHERE job_id = ?;`, tableName)
+}
+
+// createListDueRecordingsStatement the order or arguments is:
+// now limit
+func createListDueRecordingsStatement(tableName string) string {
+	return fmt.Sprintf(`SELECT job_id FROM %s WHERE next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?;`, tableName)
+}
+
+// createClaimRecordingStatement only updates the row if the recording is still due so that a
+// single replica wins the claim, the order or arguments is:
+// lease_owner next_attempt_at job_id now
+func createClaimRecordingStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET lease_owner = ?, next_attempt_at = ? WHERE job_id = ? AND next_attempt_at <= ?;`, tableName)
+}
+
+// createReleaseRecordingStatement only updates the row if the recording is still claimed by the
+// owner, the order or arguments is:
+// next_attempt_at entity job_id lease_owner
+func createReleaseRecordingStatement(tableName string) string {
+	return fmt.Sprintf(`UPDATE %s SET next_attempt_at = ?, entity = ?, lease_owner = NULL WHERE job_id = ? AND lease_owner = ?;`, tableName)
+}
+
+// createDeleteRecordingStatement the order or arguments is:
+// job_id
+func createDeleteRecordingStatement(tableName string) string {
+	return fmt.Sprintf(`DELETE FROM %s WHERE job_id = ?;`, tableName)
 }
@@ -0,0 +1,82 @@
+package storage_sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql/migrations"
+)
+
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateStatus = "status"
+)
+
+func newMigrator(pool *sql.DB, d dialect, sqlConfig *config.SQLDatabaseConfig, logger *slog.Logger) (*migrations.Migrator, error) {
+	for _, tableConfig := range []*config.SQLTableConfig{&sqlConfig.Evaluations, &sqlConfig.Collections} {
+		if err := tableConfig.CheckConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return migrations.NewMigrator(pool, sqlConfig.Driver, migrations.Tables{
+		Evaluations: migrationTable(d, &sqlConfig.Evaluations),
+		Collections: migrationTable(d, &sqlConfig.Collections),
+	}, logger)
+}
+
+// migrationTable uses the JSON column type of the dialect unless the table configuration overrides it
+func migrationTable(d dialect, tableConfig *config.SQLTableConfig) migrations.Table {
+	jsonType := tableConfig.JSONFieldType
+	if jsonType == "" {
+		jsonType = d.JSONType()
+	}
+	return migrations.Table{Name: tableConfig.TableName, JSONType: jsonType}
+}
+
+// Migrate runs a single migration command (up, down or status) against the database
+// without creating the storage, this is used by the "eval_hub migrate" command.
+// The returned string is the migration status after the command has been run.
+func Migrate(sqlConfig *config.SQLDatabaseConfig, logger *slog.Logger, command string) (string, error) {
+	d, err := newDialect(sqlConfig.Driver)
+	if err != nil {
+		return "", err
+	}
+	pool, err := sql.Open(sqlConfig.Driver, sqlConfig.URL)
+	if err != nil {
+		return "", err
+	}
+	defer pool.Close()
+
+	migrator, err := newMigrator(pool, d, sqlConfig, logger)
+	if err != nil {
+		return "", err
+	}
+
+	switch command {
+	case MigrateUp:
+		err = migrator.Up()
+	case MigrateDown:
+		err = migrator.Down()
+	case MigrateStatus:
+		// nothing to do, the status is always returned
+	default:
+		return "", fmt.Errorf("unknown migrate command %q, expecting %s, %s or %s", command, MigrateUp, MigrateDown, MigrateStatus)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return "", err
+	}
+	statusJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(statusJSON), nil
+}
@@ -0,0 +1,346 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SchemaTable is the table used to record the applied migration versions
+const SchemaTable = "schema_migrations"
+
+// migrationLockID is the key of the Postgres advisory lock that serialises migrations
+// run by different service replicas against the same database
+const migrationLockID = 7_216_001
+
+//go:embed sqlite/*.sql pgx/*.sql
+var migrationFiles embed.FS
+
+// Table is the (configurable) name and JSON column type of a table
+type Table struct {
+	Name     string
+	JSONType string
+}
+
+// Tables are substituted into the migration files, i.e. {{.Evaluations.Name}} in a
+// migration file is replaced with the name of the evaluations table.
+type Tables struct {
+	Evaluations Table
+	Collections Table
+}
+
+// Migration is a numbered schema change with the statements to apply and revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is the state of a single migration in the database
+type MigrationState struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Status is the migration status of the database
+type Status struct {
+	CurrentVersion int              `json:"current_version"`
+	LatestVersion  int              `json:"latest_version"`
+	Migrations     []MigrationState `json:"migrations"`
+}
+
+// Migrator applies the migrations for a single driver (sqlite or pgx) to a database
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	logger     *slog.Logger
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations for the driver and renders them with the table names.
+// An error is returned if there are no migrations for the driver.
+func NewMigrator(db *sql.DB, driver string, tables Tables, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(driver, tables)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		driver:     driver,
+		logger:     logger,
+		migrations: migrations,
+	}, nil
+}
+
+// LatestVersion returns the version of the newest migration known to this build
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// CurrentVersion returns the version recorded in the schema table, 0 if no migrations have been applied
+func (m *Migrator) CurrentVersion() (int, error) {
+	if err := m.createSchemaTable(); err != nil {
+		return 0, err
+	}
+	return currentVersion(m.db)
+}
+
+// Up applies all the pending migrations in order. An error is returned without
+// changing the database if the database schema is newer than this build.
+func (m *Migrator) Up() error {
+	if err := m.createSchemaTable(); err != nil {
+		return err
+	}
+	version, err := currentVersion(m.db)
+	if err != nil {
+		return err
+	}
+	if version > m.LatestVersion() {
+		return fmt.Errorf("the database schema version %d is newer than the latest version %d supported by this service", version, m.LatestVersion())
+	}
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			continue
+		}
+		if err := m.apply(migration, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down reverts the most recently applied migration
+func (m *Migrator) Down() error {
+	if err := m.createSchemaTable(); err != nil {
+		return err
+	}
+	version, err := currentVersion(m.db)
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return fmt.Errorf("there are no migrations to revert")
+	}
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return m.apply(migration, false)
+		}
+	}
+	return fmt.Errorf("the database schema version %d is not known to this service", version)
+}
+
+// Status returns the current version and the state of each known migration
+func (m *Migrator) Status() (*Status, error) {
+	if err := m.createSchemaTable(); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(m.db)
+	if err != nil {
+		return nil, err
+	}
+	version, err := currentVersion(m.db)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{
+		CurrentVersion: version,
+		LatestVersion:  m.LatestVersion(),
+	}
+	for _, migration := range m.migrations {
+		state := MigrationState{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			state.AppliedAt = &appliedAt
+		}
+		status.Migrations = append(status.Migrations, state)
+	}
+	return status, nil
+}
+
+// apply runs the up (or down) statements of the migration and records the change
+// in the schema table in a single transaction
+func (m *Migrator) apply(migration Migration, up bool) error {
+	direction := "down"
+	statements := migration.Down
+	if up {
+		direction = "up"
+		statements = migration.Up
+	}
+	m.logger.Info("Applying database migration", "driver", m.driver, "version", migration.Version, "name", migration.Name, "direction", direction)
+
+	ctx := context.Background()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback() // this is a no-op once the transaction has been committed
+	}()
+
+	if m.driver == "pgx" {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", migrationLockID)); err != nil {
+			return err
+		}
+	}
+	// another replica might have applied (or reverted) this migration while we were waiting
+	version, err := currentVersion(tx)
+	if err != nil {
+		return err
+	}
+	if (up && version >= migration.Version) || (!up && version != migration.Version) {
+		m.logger.Info("Database migration already applied", "version", migration.Version, "direction", direction, "current_version", version)
+		return nil
+	}
+
+	for _, statement := range splitStatements(statements) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d (%s) %s failed: %w", migration.Version, migration.Name, direction, err)
+		}
+	}
+	// the version is an integer so it is safe to format it into the statement
+	if up {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, applied_at) VALUES (%d, CURRENT_TIMESTAMP)", SchemaTable, migration.Version))
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = %d", SchemaTable, migration.Version))
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) createSchemaTable() error {
+	_, err := m.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    version     INTEGER PRIMARY KEY,
+    applied_at  TIMESTAMP NOT NULL
+)`, SchemaTable))
+	return err
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func currentVersion(q queryer) (int, error) {
+	var version sql.NullInt64
+	if err := q.QueryRow(fmt.Sprintf("SELECT MAX(version) FROM %s", SchemaTable)).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+func appliedMigrations(q queryer) (map[int]time.Time, error) {
+	rows, err := q.Query(fmt.Sprintf("SELECT version, applied_at FROM %s", SchemaTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// loadMigrations reads the embedded migration files for the driver, the files are
+// named NNNN_name.up.sql and NNNN_name.down.sql
+func loadMigrations(driver string, tables Tables) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, driver)
+	if err != nil {
+		return nil, fmt.Errorf("no database migrations found for the driver %s", driver)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		base, up := strings.CutSuffix(fileName, ".up.sql")
+		if !up {
+			var down bool
+			base, down = strings.CutSuffix(fileName, ".down.sql")
+			if !down {
+				continue
+			}
+		}
+		versionString, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("invalid migration file name %s", fileName)
+		}
+		version, err := strconv.Atoi(versionString)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in the file name %s", fileName)
+		}
+		contents, err := renderMigration(path.Join(driver, fileName), tables)
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if up {
+			migration.Up = contents
+		} else {
+			migration.Down = contents
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) for the driver %s must have both an up and a down file", migration.Version, migration.Name, driver)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func renderMigration(fileName string, tables Tables) (string, error) {
+	contents, err := migrationFiles.ReadFile(fileName)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(fileName).Option("missingkey=error").Parse(string(contents))
+	if err != nil {
+		return "", fmt.Errorf("invalid migration file %s: %w", fileName, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, tables); err != nil {
+		return "", fmt.Errorf("invalid migration file %s: %w", fileName, err)
+	}
+	return buf.String(), nil
+}
+
+// splitStatements splits a migration into single statements as not all drivers
+// support executing multiple statements at once
+func splitStatements(contents string) []string {
+	statements := []string{}
+	for _, statement := range strings.Split(contents, ";") {
+		statement = strings.TrimSpace(statement)
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
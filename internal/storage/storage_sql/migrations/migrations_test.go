@@ -0,0 +1,146 @@
+package migrations_test
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql/migrations"
+
+	// import the sqlite driver - "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+func TestMigrator(t *testing.T) {
+	db := openDatabase(t)
+	migrator := createMigrator(t, db)
+
+	if migrator.LatestVersion() < 1 {
+		t.Fatalf("Expected at least one migration, got latest version %d", migrator.LatestVersion())
+	}
+
+	t.Run("up applies all the migrations", func(t *testing.T) {
+		if err := migrator.Up(); err != nil {
+			t.Fatalf("Up() returned error: %v", err)
+		}
+		version, err := migrator.CurrentVersion()
+		if err != nil {
+			t.Fatalf("CurrentVersion() returned error: %v", err)
+		}
+		if version != migrator.LatestVersion() {
+			t.Errorf("Expected version %d, got %d", migrator.LatestVersion(), version)
+		}
+		if _, err := db.Exec("SELECT id, entity FROM test_evaluations"); err != nil {
+			t.Errorf("Expected the evaluations table to exist: %v", err)
+		}
+	})
+
+	t.Run("up is idempotent", func(t *testing.T) {
+		if err := migrator.Up(); err != nil {
+			t.Fatalf("Up() returned error: %v", err)
+		}
+	})
+
+	t.Run("status reports the applied migrations", func(t *testing.T) {
+		status, err := migrator.Status()
+		if err != nil {
+			t.Fatalf("Status() returned error: %v", err)
+		}
+		if status.CurrentVersion != status.LatestVersion || len(status.Migrations) != status.LatestVersion {
+			t.Errorf("Unexpected status: %+v", status)
+		}
+		for _, migration := range status.Migrations {
+			if migration.AppliedAt == nil {
+				t.Errorf("Expected migration %d to be applied", migration.Version)
+			}
+		}
+	})
+
+	t.Run("down reverts the latest migration", func(t *testing.T) {
+		latest := migrator.LatestVersion()
+		if err := migrator.Down(); err != nil {
+			t.Fatalf("Down() returned error: %v", err)
+		}
+		version, err := migrator.CurrentVersion()
+		if err != nil {
+			t.Fatalf("CurrentVersion() returned error: %v", err)
+		}
+		if version != latest-1 {
+			t.Errorf("Expected version %d, got %d", latest-1, version)
+		}
+		if err := migrator.Up(); err != nil {
+			t.Fatalf("Up() returned error: %v", err)
+		}
+	})
+
+	t.Run("existing rows are given resource ids", func(t *testing.T) {
+		for migrator.LatestVersion() > 1 {
+			version, err := migrator.CurrentVersion()
+			if err != nil {
+				t.Fatalf("CurrentVersion() returned error: %v", err)
+			}
+			if version == 1 {
+				break
+			}
+			if err := migrator.Down(); err != nil {
+				t.Fatalf("Down() returned error: %v", err)
+			}
+		}
+		if _, err := db.Exec(`INSERT INTO test_evaluations (status, entity) VALUES ('pending', '{}')`); err != nil {
+			t.Fatalf("Failed to insert a row: %v", err)
+		}
+		if err := migrator.Up(); err != nil {
+			t.Fatalf("Up() returned error: %v", err)
+		}
+		var resourceID string
+		if err := db.QueryRow("SELECT resource_id FROM test_evaluations").Scan(&resourceID); err != nil {
+			t.Fatalf("Failed to read the resource id: %v", err)
+		}
+		if _, err := uuid.Parse(resourceID); err != nil {
+			t.Errorf("Expected a UUID resource id, got %q", resourceID)
+		}
+	})
+
+	t.Run("refuses a newer schema", func(t *testing.T) {
+		newer := migrator.LatestVersion() + 1
+		if _, err := db.Exec(fmt.Sprintf("INSERT INTO %s (version, applied_at) VALUES (%d, CURRENT_TIMESTAMP)", migrations.SchemaTable, newer)); err != nil {
+			t.Fatalf("Failed to record a newer version: %v", err)
+		}
+		err := migrator.Up()
+		if err == nil || !strings.Contains(err.Error(), "newer") {
+			t.Errorf("Expected an error for the newer schema, got %v", err)
+		}
+	})
+}
+
+func TestNewMigratorUnknownDriver(t *testing.T) {
+	_, err := migrations.NewMigrator(nil, "unknown", migrations.Tables{}, logging.FallbackLogger())
+	if err == nil {
+		t.Error("Expected an error for a driver without migrations")
+	}
+}
+
+func openDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func createMigrator(t *testing.T, db *sql.DB) *migrations.Migrator {
+	t.Helper()
+	migrator, err := migrations.NewMigrator(db, "sqlite", migrations.Tables{
+		Evaluations: migrations.Table{Name: "test_evaluations", JSONType: "TEXT"},
+		Collections: migrations.Table{Name: "test_collections", JSONType: "TEXT"},
+	}, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewMigrator() returned error: %v", err)
+	}
+	return migrator
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS {{.Collections.Name}};
+
+DROP INDEX IF EXISTS {{.Evaluations.Name}}_status_idx;
+
+DROP TABLE IF EXISTS {{.Evaluations.Name}};
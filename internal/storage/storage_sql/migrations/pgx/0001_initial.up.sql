@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS {{.Evaluations.Name}} (
+    id      BIGSERIAL PRIMARY KEY,
+    status  VARCHAR(32) NOT NULL,
+    entity  {{.Evaluations.JSONType}} NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_status_idx ON {{.Evaluations.Name}} (status);
+
+CREATE TABLE IF NOT EXISTS {{.Collections.Name}} (
+    id      BIGSERIAL PRIMARY KEY,
+    status  VARCHAR(32) NOT NULL,
+    entity  {{.Collections.JSONType}} NOT NULL
+);
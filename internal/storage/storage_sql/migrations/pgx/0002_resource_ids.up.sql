@@ -0,0 +1,16 @@
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN IF NOT EXISTS resource_id VARCHAR(36);
+
+-- generate version 4 UUIDs for any existing rows
+UPDATE {{.Evaluations.Name}} SET resource_id = gen_random_uuid()::text WHERE resource_id IS NULL;
+
+ALTER TABLE {{.Evaluations.Name}} ALTER COLUMN resource_id SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Evaluations.Name}}_resource_id_idx ON {{.Evaluations.Name}} (resource_id);
+
+ALTER TABLE {{.Collections.Name}} ADD COLUMN IF NOT EXISTS resource_id VARCHAR(36);
+
+UPDATE {{.Collections.Name}} SET resource_id = gen_random_uuid()::text WHERE resource_id IS NULL;
+
+ALTER TABLE {{.Collections.Name}} ALTER COLUMN resource_id SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Collections.Name}}_resource_id_idx ON {{.Collections.Name}} (resource_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS {{.Collections.Name}}_tenant_name_idx;
+
+ALTER TABLE {{.Collections.Name}} DROP COLUMN IF EXISTS name;
+
+ALTER TABLE {{.Collections.Name}} DROP COLUMN IF EXISTS tenant;
@@ -0,0 +1,9 @@
+-- the name of a collection is unique per tenant
+ALTER TABLE {{.Collections.Name}} ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE {{.Collections.Name}} ADD COLUMN IF NOT EXISTS name VARCHAR(255);
+
+-- the entity column can be configured as TEXT so it is cast to read the fields
+UPDATE {{.Collections.Name}} SET tenant = COALESCE(entity::jsonb->>'tenant', ''), name = entity::jsonb->>'name';
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Collections.Name}}_tenant_name_idx ON {{.Collections.Name}} (tenant, name);
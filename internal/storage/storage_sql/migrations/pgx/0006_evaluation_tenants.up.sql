@@ -0,0 +1,7 @@
+-- the evaluation jobs are scoped by tenant like the collections
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN IF NOT EXISTS tenant VARCHAR(255) NOT NULL DEFAULT '';
+
+-- the entity column can be configured as TEXT so it is cast to read the fields
+UPDATE {{.Evaluations.Name}} SET tenant = COALESCE(entity::jsonb->>'tenant', '');
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_tenant_idx ON {{.Evaluations.Name}} (tenant, status);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS {{.Collections.Name}}_resource_id_idx;
+
+ALTER TABLE {{.Collections.Name}} DROP COLUMN resource_id;
+
+DROP INDEX IF EXISTS {{.Evaluations.Name}}_resource_id_idx;
+
+ALTER TABLE {{.Evaluations.Name}} DROP COLUMN resource_id;
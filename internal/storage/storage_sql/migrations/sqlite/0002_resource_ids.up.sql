@@ -0,0 +1,12 @@
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN resource_id VARCHAR(36);
+
+-- generate version 4 UUIDs for any existing rows
+UPDATE {{.Evaluations.Name}} SET resource_id = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))) WHERE resource_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Evaluations.Name}}_resource_id_idx ON {{.Evaluations.Name}} (resource_id);
+
+ALTER TABLE {{.Collections.Name}} ADD COLUMN resource_id VARCHAR(36);
+
+UPDATE {{.Collections.Name}} SET resource_id = lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))) WHERE resource_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Collections.Name}}_resource_id_idx ON {{.Collections.Name}} (resource_id);
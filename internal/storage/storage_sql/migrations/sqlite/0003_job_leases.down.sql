@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS {{.Evaluations.Name}}_lease_idx;
+
+ALTER TABLE {{.Evaluations.Name}} DROP COLUMN lease_expires_at;
+
+ALTER TABLE {{.Evaluations.Name}} DROP COLUMN lease_owner;
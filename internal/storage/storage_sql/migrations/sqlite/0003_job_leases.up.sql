@@ -0,0 +1,6 @@
+-- the dispatcher claims a job by setting the lease owner, the lease expiry is in unix milliseconds
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN lease_owner VARCHAR(255);
+
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN lease_expires_at BIGINT;
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_lease_idx ON {{.Evaluations.Name}} (status, lease_expires_at);
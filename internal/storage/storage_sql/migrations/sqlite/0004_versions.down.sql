@@ -0,0 +1,3 @@
+ALTER TABLE {{.Collections.Name}} DROP COLUMN version;
+
+ALTER TABLE {{.Evaluations.Name}} DROP COLUMN version;
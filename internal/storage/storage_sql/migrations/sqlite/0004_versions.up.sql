@@ -0,0 +1,4 @@
+-- the version is incremented by every update so that concurrent updates are detected
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE {{.Collections.Name}} ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- the name of a collection is unique per tenant
+ALTER TABLE {{.Collections.Name}} ADD COLUMN tenant VARCHAR(255) NOT NULL DEFAULT '';
+
+ALTER TABLE {{.Collections.Name}} ADD COLUMN name VARCHAR(255);
+
+UPDATE {{.Collections.Name}} SET tenant = COALESCE(json_extract(entity, '$.tenant'), ''), name = json_extract(entity, '$.name');
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Collections.Name}}_tenant_name_idx ON {{.Collections.Name}} (tenant, name);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS {{.Evaluations.Name}}_tenant_idx;
+
+ALTER TABLE {{.Evaluations.Name}} DROP COLUMN tenant;
@@ -0,0 +1,6 @@
+-- the evaluation jobs are scoped by tenant like the collections
+ALTER TABLE {{.Evaluations.Name}} ADD COLUMN tenant VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE {{.Evaluations.Name}} SET tenant = COALESCE(json_extract(entity, '$.tenant'), '');
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_tenant_idx ON {{.Evaluations.Name}} (tenant, status);
@@ -0,0 +1,19 @@
+-- the outbox of the webhook deliveries, the deliveries are written with the job update and a due
+-- delivery is claimed by moving next_attempt_at (unix milliseconds) forward by the lease duration
+CREATE TABLE IF NOT EXISTS {{.Evaluations.Name}}_deliveries (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    resource_id      VARCHAR(36) NOT NULL,
+    job_id           VARCHAR(36) NOT NULL,
+    tenant           VARCHAR(255) NOT NULL DEFAULT '',
+    status           VARCHAR(32) NOT NULL,
+    next_attempt_at  BIGINT NOT NULL,
+    lease_owner      VARCHAR(255),
+    entity           {{.Evaluations.JSONType}} NOT NULL,
+    payload          {{.Evaluations.JSONType}} NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Evaluations.Name}}_deliveries_resource_id_idx ON {{.Evaluations.Name}}_deliveries (resource_id);
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_deliveries_job_idx ON {{.Evaluations.Name}}_deliveries (job_id);
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_deliveries_due_idx ON {{.Evaluations.Name}}_deliveries (status, next_attempt_at);
@@ -0,0 +1,11 @@
+-- the changes of the jobs streamed by the events endpoints, the events are written with the job
+-- change and read by every replica so that the event ids are the same on all the replicas
+CREATE TABLE IF NOT EXISTS {{.Evaluations.Name}}_events (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id      VARCHAR(36) NOT NULL,
+    tenant      VARCHAR(255) NOT NULL DEFAULT '',
+    created_at  BIGINT NOT NULL,
+    entity      {{.Evaluations.JSONType}} NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_events_created_at_idx ON {{.Evaluations.Name}}_events (created_at);
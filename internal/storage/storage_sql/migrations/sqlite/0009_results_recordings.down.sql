@@ -0,0 +1 @@
+DROP TABLE IF EXISTS {{.Evaluations.Name}}_recordings;
@@ -0,0 +1,15 @@
+-- the outbox of the results to record in MLflow, a job has a recording while some of its reported
+-- results have not been recorded and a due recording is claimed by moving next_attempt_at (unix
+-- milliseconds) forward by the lease duration
+CREATE TABLE IF NOT EXISTS {{.Evaluations.Name}}_recordings (
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id           VARCHAR(36) NOT NULL,
+    tenant           VARCHAR(255) NOT NULL DEFAULT '',
+    next_attempt_at  BIGINT NOT NULL,
+    lease_owner      VARCHAR(255),
+    entity           {{.Evaluations.JSONType}} NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS {{.Evaluations.Name}}_recordings_job_id_idx ON {{.Evaluations.Name}}_recordings (job_id);
+
+CREATE INDEX IF NOT EXISTS {{.Evaluations.Name}}_recordings_due_idx ON {{.Evaluations.Name}}_recordings (next_attempt_at);
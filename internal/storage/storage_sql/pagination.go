@@ -0,0 +1,33 @@
+package storage_sql
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// newPage creates the pagination links for a list response, the links keep the
+// query parameters of the original request and only change the offset and limit
+func newPage(ctx *executioncontext.ExecutionContext, limit int, offset int, count int, totalCount int) api.Page {
+	page := api.Page{
+		First:      &api.HRef{Href: pageHref(ctx, limit, 0)},
+		Limit:      limit,
+		TotalCount: totalCount,
+	}
+	if offset+count < totalCount {
+		page.Next = &api.HRef{Href: pageHref(ctx, limit, offset+count)}
+	}
+	return page
+}
+
+func pageHref(ctx *executioncontext.ExecutionContext, limit int, offset int) string {
+	query, err := url.ParseQuery(ctx.RawQuery)
+	if err != nil {
+		query = url.Values{}
+	}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	return ctx.BaseURL + ctx.URI + "?" + query.Encode()
+}
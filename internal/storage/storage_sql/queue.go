@@ -0,0 +1,83 @@
+package storage_sql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// claimCandidates is the number of claimable jobs read at once, more than one is read so
+// that a replica that loses the race for the first job can try the next one
+const claimCandidates = 10
+
+// ClaimEvaluationJob claims the oldest claimable job for the owner. The claim is a
+// conditional update so it is safe when several replicas claim at the same time, only
+// one of them updates the row.
+func (s *SQLStorage) ClaimEvaluationJob(ctx *executioncontext.ExecutionContext, owner string, leaseDuration time.Duration) (*api.EvaluationJobResource, error) {
+	tableName := s.sqlConfig.Evaluations.TableName
+	now := time.Now()
+
+	rows, err := s.query(createListClaimableStatement(tableName), now.UnixMilli(), claimCandidates)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{}
+	for rows.Next() {
+		var resourceID string
+		if err := rows.Scan(&resourceID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, resourceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(leaseDuration).UnixMilli()
+	for _, id := range candidates {
+		result, err := s.exec(createClaimStatement(tableName), owner, expiresAt, id, now.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if count == 1 {
+			evaluation, _, err := s.getEvaluationJob(ctx, s.pool, id)
+			return evaluation, err
+		}
+	}
+	return nil, nil
+}
+
+// RenewEvaluationJobLease extends the lease of a job held by the owner
+func (s *SQLStorage) RenewEvaluationJobLease(ctx *executioncontext.ExecutionContext, id string, owner string, leaseDuration time.Duration) error {
+	expiresAt := time.Now().Add(leaseDuration).UnixMilli()
+	return s.updateLease(createRenewLeaseStatement(s.sqlConfig.Evaluations.TableName), id, expiresAt, id, owner)
+}
+
+// ReleaseEvaluationJobLease releases the lease of a job held by the owner
+func (s *SQLStorage) ReleaseEvaluationJobLease(ctx *executioncontext.ExecutionContext, id string, owner string) error {
+	return s.updateLease(createReleaseLeaseStatement(s.sqlConfig.Evaluations.TableName), id, id, owner)
+}
+
+func (s *SQLStorage) updateLease(statement string, id string, args ...any) error {
+	result, err := s.exec(statement, args...)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("evaluation job %s %w", id, abstractions.ErrLeaseLost)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package storage_sql_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestClaimEvaluationJob(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+
+	t.Run("a pending job is claimed once", func(t *testing.T) {
+		claimed, err := storage.ClaimEvaluationJob(ctx, "replica-a", time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimEvaluationJob() returned error: %v", err)
+		}
+		if claimed == nil || claimed.ID != job.ID {
+			t.Fatalf("Expected job %s to be claimed, got %+v", job.ID, claimed)
+		}
+		again, err := storage.ClaimEvaluationJob(ctx, "replica-b", time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimEvaluationJob() returned error: %v", err)
+		}
+		if again != nil {
+			t.Errorf("Expected no job to claim, got %s", again.ID)
+		}
+	})
+
+	t.Run("only the owner can renew and release the lease", func(t *testing.T) {
+		if err := storage.RenewEvaluationJobLease(ctx, job.ID, "replica-a", time.Minute); err != nil {
+			t.Errorf("RenewEvaluationJobLease() returned error: %v", err)
+		}
+		if err := storage.RenewEvaluationJobLease(ctx, job.ID, "replica-b", time.Minute); !errors.Is(err, abstractions.ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost, got %v", err)
+		}
+		if err := storage.ReleaseEvaluationJobLease(ctx, job.ID, "replica-b"); !errors.Is(err, abstractions.ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost, got %v", err)
+		}
+	})
+
+	t.Run("a running job with an expired lease is recovered", func(t *testing.T) {
+		if err := storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: api.StateRunning}); err != nil {
+			t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+		}
+		if err := storage.RenewEvaluationJobLease(ctx, job.ID, "replica-a", -time.Second); err != nil {
+			t.Fatalf("RenewEvaluationJobLease() returned error: %v", err)
+		}
+		claimed, err := storage.ClaimEvaluationJob(ctx, "replica-b", time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimEvaluationJob() returned error: %v", err)
+		}
+		if claimed == nil || claimed.ID != job.ID {
+			t.Fatalf("Expected job %s to be recovered, got %+v", job.ID, claimed)
+		}
+		if err := storage.RenewEvaluationJobLease(ctx, job.ID, "replica-a", time.Minute); !errors.Is(err, abstractions.ErrLeaseLost) {
+			t.Errorf("Expected the previous owner to have lost the lease, got %v", err)
+		}
+	})
+
+	t.Run("finished jobs are not claimed", func(t *testing.T) {
+		if err := storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: api.StateCompleted}); err != nil {
+			t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+		}
+		if err := storage.ReleaseEvaluationJobLease(ctx, job.ID, "replica-b"); err != nil {
+			t.Fatalf("ReleaseEvaluationJobLease() returned error: %v", err)
+		}
+		claimed, err := storage.ClaimEvaluationJob(ctx, "replica-c", time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimEvaluationJob() returned error: %v", err)
+		}
+		if claimed != nil {
+			t.Errorf("Expected no job to claim, got %s", claimed.ID)
+		}
+	})
+}
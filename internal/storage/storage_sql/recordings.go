@@ -0,0 +1,217 @@
+package storage_sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// recordingsTable is the outbox of the MLflow recordings, it is named after the evaluations table
+func (s *SQLStorage) recordingsTable() string {
+	return s.sqlConfig.Evaluations.TableName + "_recordings"
+}
+
+// addRecording writes the benchmarks reported without a run id to the recording of the job in
+// the transaction of the results so that only the stored results are recorded. The transaction
+// has updated the job so the concurrent updates of the recording of the job are serialised. A
+// benchmark reported with a run id has been recorded by the runtime and is not recorded.
+func (s *SQLStorage) addRecording(tx *sql.Tx, evaluation *api.EvaluationJobResource, results *api.EvaluationJobResultsConfig) error {
+	if s.mlflow == nil || !s.mlflow.Enabled {
+		return nil
+	}
+	recording, leaseOwner, err := s.getRecording(tx, evaluation.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	found := err == nil
+	if !found {
+		recording = &abstractions.ResultsRecording{JobID: evaluation.ID, Benchmarks: map[string]int64{}}
+	}
+	for _, result := range results.Benchmarks {
+		if result.MLFlowRunID != nil {
+			delete(recording.Benchmarks, result.Name)
+		} else {
+			recording.Benchmarks[result.Name]++
+		}
+	}
+
+	now := time.Now().UnixMilli()
+	switch {
+	case !found && len(recording.Benchmarks) == 0:
+		return nil
+	case !found:
+		recordingJSON, err := json.Marshal(recording)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.dialect.Rebind(createAddRecordingStatement(s.dialect, s.recordingsTable())), evaluation.ID, string(evaluation.Tenant), now, string(recordingJSON))
+		return err
+	case !leaseOwner.Valid && len(recording.Benchmarks) == 0:
+		_, err := tx.Exec(s.dialect.Rebind(createDeleteRecordingStatement(s.recordingsTable())), evaluation.ID)
+		return err
+	}
+	recordingJSON, err := json.Marshal(recording)
+	if err != nil {
+		return err
+	}
+	// a claimed recording keeps its lease, the owner releases it as due when it completes
+	if leaseOwner.Valid {
+		_, err = tx.Exec(s.dialect.Rebind(createUpdateRecordingEntityStatement(s.recordingsTable())), string(recordingJSON), evaluation.ID)
+		return err
+	}
+	_, err = tx.Exec(s.dialect.Rebind(createUpdateRecordingStatement(s.recordingsTable())), now, string(recordingJSON), evaluation.ID)
+	return err
+}
+
+// ClaimResultsRecording claims the recording that has been due the longest, the claim moves the
+// next attempt of the recording forward by the lease duration so that the recording is claimed
+// again if the owner stops before completing it
+func (s *SQLStorage) ClaimResultsRecording(ctx *executioncontext.ExecutionContext, owner string, leaseDuration time.Duration) (*abstractions.ResultsRecording, error) {
+	tableName := s.recordingsTable()
+	now := time.Now()
+
+	rows, err := s.query(createListDueRecordingsStatement(tableName), now.UnixMilli(), claimCandidates)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{}
+	for rows.Next() {
+		var jobID string
+		if err := rows.Scan(&jobID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, jobID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	leaseExpiresAt := now.Add(leaseDuration).UnixMilli()
+	for _, jobID := range candidates {
+		result, err := s.exec(createClaimRecordingStatement(tableName), owner, leaseExpiresAt, jobID, now.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if count == 1 {
+			recording, _, err := s.getRecording(s.pool, jobID)
+			return recording, err
+		}
+	}
+	return nil, nil
+}
+
+// CompleteResultsRecording stores the run ids and the experiment URL of the recording with the
+// results of the job and releases the claim in the same transaction. The run id of a benchmark
+// is only stored if the result of the benchmark has no run id. The recorded benchmarks that have
+// not been reported again are removed from the recording, the recording is deleted once it has
+// no benchmarks and is due again otherwise. An error wrapping abstractions.ErrLeaseLost is
+// returned if the recording has been claimed by another owner in the meantime.
+func (s *SQLStorage) CompleteResultsRecording(ctx *executioncontext.ExecutionContext, recording *abstractions.ResultsRecording, owner string) error {
+	release := func(tx *sql.Tx, evaluation *api.EvaluationJobResource) error {
+		return s.releaseRecording(tx, recording, owner)
+	}
+	if !hasRecordedRuns(recording) {
+		return s.withTransaction(func(tx *sql.Tx) error {
+			return release(tx, nil)
+		})
+	}
+	err := s.updateEvaluationJobInTransaction(ctx, recording.JobID, func(evaluation *api.EvaluationJobResource) error {
+		if evaluation.Results == nil {
+			return nil
+		}
+		for i := range evaluation.Results.Benchmarks {
+			result := &evaluation.Results.Benchmarks[i]
+			if runID := recording.RunIDs[result.Name]; runID != "" && result.MLFlowRunID == nil {
+				result.MLFlowRunID = &runID
+			}
+		}
+		if recording.ExperimentURL != "" && evaluation.Results.MLFlowExperimentURL == nil {
+			evaluation.Results.MLFlowExperimentURL = &recording.ExperimentURL
+		}
+		return nil
+	}, release)
+	if errors.Is(err, abstractions.ErrNotFound) {
+		// the job has been deleted so there is nothing left to record
+		_, err = s.exec(createDeleteRecordingStatement(s.recordingsTable()), recording.JobID)
+	}
+	return err
+}
+
+// hasRecordedRuns returns true if the recording has run ids or an experiment URL to store
+func hasRecordedRuns(recording *abstractions.ResultsRecording) bool {
+	if recording.ExperimentURL != "" {
+		return true
+	}
+	for _, runID := range recording.RunIDs {
+		if runID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseRecording removes the recorded benchmarks from the stored recording and releases it
+func (s *SQLStorage) releaseRecording(tx *sql.Tx, recording *abstractions.ResultsRecording, owner string) error {
+	stored, leaseOwner, err := s.getRecording(tx, recording.JobID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err != nil || leaseOwner.String != owner {
+		return fmt.Errorf("MLflow recording of evaluation job %s %w", recording.JobID, abstractions.ErrLeaseLost)
+	}
+	for name := range recording.RunIDs {
+		if stored.Benchmarks[name] == recording.Benchmarks[name] {
+			delete(stored.Benchmarks, name)
+		}
+	}
+	if len(stored.Benchmarks) == 0 {
+		_, err := tx.Exec(s.dialect.Rebind(createDeleteRecordingStatement(s.recordingsTable())), recording.JobID)
+		return err
+	}
+
+	// the benchmarks that have been reported again are recorded now and the ones that could
+	// not be recorded are retried at the next attempt of the recorder
+	nextAttemptAt := time.Now()
+	stored.Attempts = 0
+	if recording.NextAttemptAt != nil {
+		nextAttemptAt = *recording.NextAttemptAt
+		stored.Attempts = recording.Attempts
+	}
+	recordingJSON, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(s.dialect.Rebind(createReleaseRecordingStatement(s.recordingsTable())), nextAttemptAt.UnixMilli(), string(recordingJSON), recording.JobID, owner)
+	return err
+}
+
+// getRecording returns the recording of the job and its owner, the error is sql.ErrNoRows if the
+// job has no recording
+func (s *SQLStorage) getRecording(q queryer, jobID string) (*abstractions.ResultsRecording, sql.NullString, error) {
+	var entity string
+	var leaseOwner sql.NullString
+	if err := q.QueryRow(s.dialect.Rebind(createGetRecordingStatement(s.recordingsTable())), jobID).Scan(&entity, &leaseOwner); err != nil {
+		return nil, leaseOwner, err
+	}
+	recording := &abstractions.ResultsRecording{}
+	if err := json.Unmarshal([]byte(entity), recording); err != nil {
+		return nil, leaseOwner, err
+	}
+	recording.JobID = jobID
+	if recording.Benchmarks == nil {
+		recording.Benchmarks = map[string]int64{}
+	}
+	return recording, leaseOwner, nil
+}
@@ -0,0 +1,136 @@
+package storage_sql_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestResultsRecordings(t *testing.T) {
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, nil, &config.MLflowConfig{Enabled: true, TrackingURI: "http://localhost:5000"}, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	ctx := createExecutionContext()
+	ctx.Tenant = "team-a"
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model:      api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "arc"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	report := func(t *testing.T, results ...api.BenchmarkResultConfig) {
+		t.Helper()
+		if err := storage.UpsertEvaluationJobResults(ctx, job.ID, &api.EvaluationJobResultsConfig{Benchmarks: results}); err != nil {
+			t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+		}
+	}
+	// the recorder has no tenant
+	recorderCtx := createExecutionContext()
+	claim := func(t *testing.T, owner string) *abstractions.ResultsRecording {
+		t.Helper()
+		recording, err := storage.ClaimResultsRecording(recorderCtx, owner, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimResultsRecording() returned error: %v", err)
+		}
+		return recording
+	}
+
+	runtimeRun := "runtime-run"
+	report(t, api.BenchmarkResultConfig{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}}, api.BenchmarkResultConfig{Name: "arc", MLFlowRunID: &runtimeRun})
+	recording := claim(t, "replica-a")
+
+	t.Run("the benchmarks reported without a run are recorded once", func(t *testing.T) {
+		if recording == nil || recording.JobID != job.ID || len(recording.Benchmarks) != 1 || recording.Benchmarks["mmlu"] != 1 {
+			t.Fatalf("Expected the recording of mmlu, got %+v", recording)
+		}
+		if other := claim(t, "replica-b"); other != nil {
+			t.Errorf("Expected the claimed recording not to be claimed again, got %+v", other)
+		}
+	})
+
+	t.Run("a benchmark reported again while it is recorded is recorded again", func(t *testing.T) {
+		report(t, api.BenchmarkResultConfig{Name: "mmlu", Metrics: map[string]any{"acc": 0.75}})
+		recording.RunIDs = map[string]string{"mmlu": "run-1"}
+		recording.ExperimentURL = "http://localhost:5000/#/experiments/1"
+		if err := storage.CompleteResultsRecording(recorderCtx, recording, "replica-a"); err != nil {
+			t.Fatalf("CompleteResultsRecording() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		mmlu, arc := got.Results.Benchmarks[0], got.Results.Benchmarks[1]
+		if mmlu.MLFlowRunID == nil || *mmlu.MLFlowRunID != "run-1" || mmlu.Metrics["acc"] != 0.75 || *arc.MLFlowRunID != runtimeRun {
+			t.Errorf("Expected the run ids to be stored with the results, got %+v", got.Results.Benchmarks)
+		}
+		if got.Results.MLFlowExperimentURL == nil || *got.Results.MLFlowExperimentURL != recording.ExperimentURL {
+			t.Errorf("Expected the experiment URL, got %v", got.Results.MLFlowExperimentURL)
+		}
+		again := claim(t, "replica-b")
+		if again == nil || again.Benchmarks["mmlu"] != 2 {
+			t.Fatalf("Expected mmlu to be recorded again, got %+v", again)
+		}
+		again.RunIDs = map[string]string{"mmlu": "run-1"}
+		if err := storage.CompleteResultsRecording(recorderCtx, again, "replica-b"); err != nil {
+			t.Fatalf("CompleteResultsRecording() returned error: %v", err)
+		}
+		if other := claim(t, "replica-a"); other != nil {
+			t.Errorf("Expected the completed recording to be deleted, got %+v", other)
+		}
+	})
+
+	t.Run("the failed recordings are retried after the backoff", func(t *testing.T) {
+		report(t, api.BenchmarkResultConfig{Name: "mmlu", Metrics: map[string]any{"acc": 0.8}})
+		failed := claim(t, "replica-a")
+		nextAttemptAt := time.Now().Add(time.Hour)
+		failed.RunIDs = map[string]string{}
+		failed.Attempts = 1
+		failed.NextAttemptAt = &nextAttemptAt
+		if err := storage.CompleteResultsRecording(recorderCtx, failed, "replica-a"); err != nil {
+			t.Fatalf("CompleteResultsRecording() returned error: %v", err)
+		}
+		if other := claim(t, "replica-b"); other != nil {
+			t.Errorf("Expected the recording not to be due before the backoff, got %+v", other)
+		}
+	})
+
+	t.Run("a recording can only be completed by its owner", func(t *testing.T) {
+		other, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+			Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		})
+		if err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+		if err := storage.UpsertEvaluationJobResults(ctx, other.ID, &api.EvaluationJobResultsConfig{Benchmarks: []api.BenchmarkResultConfig{{Name: "mmlu"}}}); err != nil {
+			t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+		}
+		recording := claim(t, "replica-a")
+		recording.RunIDs = map[string]string{"mmlu": "run-2"}
+		if err := storage.CompleteResultsRecording(recorderCtx, recording, "replica-b"); !errors.Is(err, abstractions.ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost, got %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, other.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Results.Benchmarks[0].MLFlowRunID != nil {
+			t.Errorf("Expected the run id not to be stored, got %s", *got.Results.Benchmarks[0].MLFlowRunID)
+		}
+	})
+}
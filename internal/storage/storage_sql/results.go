@@ -0,0 +1,109 @@
+package storage_sql
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// UpsertEvaluationJobResults stores the results reported for the benchmarks of the job, the result
+// of a benchmark that has already been reported is replaced so that the runtimes can safely retry.
+// An error wrapping abstractions.ErrInvalidArgument is returned for a benchmark that is not part of the job.
+// The benchmarks reported without a run id are written to the MLflow recording of the job with the results.
+func (s *SQLStorage) UpsertEvaluationJobResults(ctx *executioncontext.ExecutionContext, id string, results *api.EvaluationJobResultsConfig) error {
+	return s.updateEvaluationJobInTransaction(ctx, id, func(evaluation *api.EvaluationJobResource) error {
+		return mergeResults(evaluation, results)
+	}, func(tx *sql.Tx, evaluation *api.EvaluationJobResource) error {
+		return s.addRecording(tx, evaluation, results)
+	})
+}
+
+// mergeResults adds the reported results to the job and recomputes the counts
+func mergeResults(evaluation *api.EvaluationJobResource, results *api.EvaluationJobResultsConfig) error {
+	if evaluation.Results == nil {
+		evaluation.Results = &api.EvaluationJobResults{}
+	}
+	for _, result := range results.Benchmarks {
+		if !hasBenchmark(evaluation, result.Name) {
+			return fmt.Errorf("benchmark %s is not part of evaluation job %s %w", result.Name, evaluation.ID, abstractions.ErrInvalidArgument)
+		}
+		for name, value := range result.Artifacts {
+			if api.IsArtifactReference(value) && !hasArtifact(evaluation, value) {
+				return fmt.Errorf("artifact %s of benchmark %s references %s that has not been uploaded for evaluation job %s %w", name, result.Name, value, evaluation.ID, abstractions.ErrInvalidArgument)
+			}
+		}
+		benchmarkResult := api.EvaluationJobBenchmarkResult{
+			ID:          result.Name,
+			Name:        result.Name,
+			State:       api.StateCompleted,
+			Metrics:     result.Metrics,
+			Artifacts:   result.Artifacts,
+			MLFlowRunID: result.MLFlowRunID,
+			Error:       result.Error,
+		}
+		if result.Error != nil {
+			benchmarkResult.State = api.StateFailed
+		}
+		// the times are the ones of the benchmark run
+		for _, status := range evaluation.Status.Benchmarks {
+			if status.Name == result.Name {
+				benchmarkResult.StartedAt = status.StartedAt
+				benchmarkResult.CompletedAt = status.CompletedAt
+			}
+		}
+		index := slices.IndexFunc(evaluation.Results.Benchmarks, func(b api.EvaluationJobBenchmarkResult) bool {
+			return b.Name == result.Name
+		})
+		if index >= 0 {
+			// the run recorded in MLflow for the previous results is kept so that the results
+			// reported again are recorded in the same run
+			if benchmarkResult.MLFlowRunID == nil {
+				benchmarkResult.MLFlowRunID = evaluation.Results.Benchmarks[index].MLFlowRunID
+			}
+			evaluation.Results.Benchmarks[index] = benchmarkResult
+		} else {
+			evaluation.Results.Benchmarks = append(evaluation.Results.Benchmarks, benchmarkResult)
+		}
+	}
+	if results.AggregatedMetrics != nil {
+		evaluation.Results.AggregatedMetrics = results.AggregatedMetrics
+	}
+	if results.MLFlowExperimentURL != nil {
+		evaluation.Results.MLFlowExperimentURL = results.MLFlowExperimentURL
+	}
+
+	evaluation.Results.TotalEvaluations = max(len(evaluation.Benchmarks), len(evaluation.Results.Benchmarks))
+	evaluation.Results.CompletedEvaluations = 0
+	evaluation.Results.FailedEvaluations = 0
+	for _, benchmarkResult := range evaluation.Results.Benchmarks {
+		switch benchmarkResult.State {
+		case api.StateCompleted:
+			evaluation.Results.CompletedEvaluations++
+		case api.StateFailed:
+			evaluation.Results.FailedEvaluations++
+		}
+	}
+	return nil
+}
+
+// hasBenchmark returns true if the benchmark is requested by the job, any benchmark is accepted
+// for a job that does not list its benchmarks
+func hasBenchmark(evaluation *api.EvaluationJobResource, name string) bool {
+	if len(evaluation.Benchmarks) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(evaluation.Benchmarks, func(b api.BenchmarkConfig) bool {
+		return b.ID == name
+	})
+}
+
+// hasArtifact returns true if an artifact with the digest has been uploaded for the job
+func hasArtifact(evaluation *api.EvaluationJobResource, digest string) bool {
+	return slices.ContainsFunc(evaluation.Results.Artifacts, func(a api.ArtifactResource) bool {
+		return a.Digest == digest
+	})
+}
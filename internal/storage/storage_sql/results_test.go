@@ -0,0 +1,148 @@
+package storage_sql_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestUpsertEvaluationJobResults(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model:      api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		Benchmarks: []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}, {Ref: api.Ref{ID: "hellaswag"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	failure := "out of memory"
+
+	t.Run("the results are added and counted", func(t *testing.T) {
+		results := &api.EvaluationJobResultsConfig{
+			Benchmarks: []api.BenchmarkResultConfig{
+				{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}, Artifacts: map[string]string{"samples": "s3://bucket/mmlu.jsonl"}},
+				{Name: "hellaswag", Error: &failure},
+			},
+		}
+		if err := storage.UpsertEvaluationJobResults(ctx, job.ID, results); err != nil {
+			t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Results == nil || got.Results.TotalEvaluations != 2 || got.Results.CompletedEvaluations != 1 || got.Results.FailedEvaluations != 1 {
+			t.Fatalf("Unexpected results: %+v", got.Results)
+		}
+		if got.Results.Benchmarks[0].Metrics["acc"] != 0.5 || got.Results.Benchmarks[0].Artifacts["samples"] != "s3://bucket/mmlu.jsonl" {
+			t.Errorf("Unexpected mmlu result: %+v", got.Results.Benchmarks[0])
+		}
+	})
+
+	t.Run("reporting a benchmark again replaces its result", func(t *testing.T) {
+		results := &api.EvaluationJobResultsConfig{
+			Benchmarks: []api.BenchmarkResultConfig{{Name: "hellaswag", Metrics: map[string]any{"acc_norm": 0.75}}},
+		}
+		for range 2 {
+			if err := storage.UpsertEvaluationJobResults(ctx, job.ID, results); err != nil {
+				t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+			}
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if len(got.Results.Benchmarks) != 2 {
+			t.Fatalf("Expected 2 benchmark results, got %d", len(got.Results.Benchmarks))
+		}
+		if got.Results.CompletedEvaluations != 2 || got.Results.FailedEvaluations != 0 {
+			t.Errorf("Expected 2 completed and 0 failed evaluations, got %d and %d", got.Results.CompletedEvaluations, got.Results.FailedEvaluations)
+		}
+		if got.Results.Benchmarks[1].Error != nil || got.Results.Benchmarks[1].Metrics["acc_norm"] != 0.75 {
+			t.Errorf("Unexpected hellaswag result: %+v", got.Results.Benchmarks[1])
+		}
+	})
+
+	t.Run("the MLflow run of a benchmark reported again is kept", func(t *testing.T) {
+		runID := "run-1"
+		for _, result := range []api.BenchmarkResultConfig{
+			{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}, MLFlowRunID: &runID},
+			{Name: "mmlu", Metrics: map[string]any{"acc": 0.75}},
+		} {
+			if err := storage.UpsertEvaluationJobResults(ctx, job.ID, &api.EvaluationJobResultsConfig{Benchmarks: []api.BenchmarkResultConfig{result}}); err != nil {
+				t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+			}
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if mmlu := got.Results.Benchmarks[0]; mmlu.MLFlowRunID == nil || *mmlu.MLFlowRunID != runID || mmlu.Metrics["acc"] != 0.75 {
+			t.Errorf("Expected the run %s to be kept, got %+v", runID, mmlu)
+		}
+	})
+
+	t.Run("unknown benchmarks and jobs are rejected", func(t *testing.T) {
+		results := &api.EvaluationJobResultsConfig{
+			Benchmarks: []api.BenchmarkResultConfig{{Name: "arc"}},
+		}
+		if err := storage.UpsertEvaluationJobResults(ctx, job.ID, results); !errors.Is(err, abstractions.ErrInvalidArgument) {
+			t.Errorf("Expected ErrInvalidArgument, got %v", err)
+		}
+		if err := storage.UpsertEvaluationJobResults(ctx, "unknown", results); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestAggregationWhenTheLastBenchmarkFinishes(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	weight := 3.0
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		Benchmarks: []api.BenchmarkConfig{
+			{Ref: api.Ref{ID: "mmlu"}, Weight: &weight},
+			{Ref: api.Ref{ID: "hellaswag"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	results := &api.EvaluationJobResultsConfig{
+		Benchmarks: []api.BenchmarkResultConfig{
+			{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}},
+			{Name: "hellaswag", Metrics: map[string]any{"acc": 0.9}},
+		},
+	}
+	if err := storage.UpsertEvaluationJobResults(ctx, job.ID, results); err != nil {
+		t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+	}
+
+	for _, name := range []string{"mmlu", "hellaswag"} {
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Results.AggregatedMetrics != nil {
+			t.Errorf("Expected no aggregated metrics before %s has finished, got %v", name, got.Results.AggregatedMetrics)
+		}
+		if err := storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: name, State: api.StateCompleted}); err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+	}
+
+	got, err := storage.GetEvaluationJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetEvaluationJob() returned error: %v", err)
+	}
+	if score, ok := got.Results.AggregatedMetrics["score"].(float64); !ok || math.Abs(score-0.6) > 1e-9 {
+		t.Errorf("Expected the weighted score 0.6, got %v", got.Results.AggregatedMetrics["score"])
+	}
+}
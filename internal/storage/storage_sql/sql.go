@@ -3,10 +3,7 @@ package storage_sql
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
-	"fmt"
 	"log/slog"
-	"strconv"
 	"time"
 
 	// import the postgres driver - "pgx"
@@ -15,19 +12,50 @@ import (
 	_ "modernc.org/sqlite"
 
 	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/aggregation"
 	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
 	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
-	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
 )
 
 type SQLStorage struct {
-	sqlConfig *config.SQLDatabaseConfig
-	pool      *sql.DB
-}
-
-func NewSQLStorage(sqlConfig *config.SQLDatabaseConfig, logger *slog.Logger) (abstractions.Storage, error) {
+	sqlConfig  *config.SQLDatabaseConfig
+	dialect    dialect
+	pool       *sql.DB
+	aggregator *aggregation.Aggregator
+	webhooks   *config.WebhookConfig
+	mlflow     *config.MLflowConfig
+	bus        *events.Bus
+	logger     *slog.Logger
+
+	// eventsWake, stopEvents and eventsDone control the reading of the job events
+	eventsWake chan struct{}
+	stopEvents context.CancelFunc
+	eventsDone chan struct{}
+}
+
+// NewSQLStorage creates the storage and brings the database schema up to date, the aggregator
+// computes the aggregated metrics of the finished jobs and the default one is used when it is nil.
+// The webhook deliveries of the jobs are only written to the outbox when the webhooks are enabled,
+// the results to record in MLflow are only written to the outbox when the MLflow tracking is enabled
+// and the changes of the jobs are written to the database and published to the bus when it is
+// not nil, the events written by all the replicas are read until the storage is closed.
+func NewSQLStorage(sqlConfig *config.SQLDatabaseConfig, aggregator *aggregation.Aggregator, webhookConfig *config.WebhookConfig, mlflowConfig *config.MLflowConfig, bus *events.Bus, logger *slog.Logger) (abstractions.Storage, error) {
 	logger.Info("Creating SQL storage", "driver", sqlConfig.Driver, "url", sqlConfig.URL)
 
+	if aggregator == nil {
+		defaultAggregator, err := aggregation.NewAggregator(nil)
+		if err != nil {
+			return nil, err
+		}
+		aggregator = defaultAggregator
+	}
+
+	d, err := newDialect(sqlConfig.Driver)
+	if err != nil {
+		return nil, err
+	}
+
 	pool, err := sql.Open(sqlConfig.Driver, sqlConfig.URL)
 	if err != nil {
 		return nil, err
@@ -44,8 +72,15 @@ func NewSQLStorage(sqlConfig *config.SQLDatabaseConfig, logger *slog.Logger) (ab
 	}
 
 	storage := &SQLStorage{
-		sqlConfig: sqlConfig,
-		pool:      pool,
+		sqlConfig:  sqlConfig,
+		dialect:    d,
+		pool:       pool,
+		aggregator: aggregator,
+		webhooks:   webhookConfig,
+		mlflow:     mlflowConfig,
+		bus:        bus,
+		logger:     logger,
+		eventsWake: make(chan struct{}, 1),
 	}
 
 	logger.Info("Pinging SQL storage", "driver", sqlConfig.Driver, "url", sqlConfig.URL)
@@ -54,6 +89,22 @@ func NewSQLStorage(sqlConfig *config.SQLDatabaseConfig, logger *slog.Logger) (ab
 		return nil, err
 	}
 
+	// bring the database schema up to date, this fails if the schema is newer than this service
+	migrator, err := newMigrator(pool, d, sqlConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	err = migrator.Up()
+	if err != nil {
+		return nil, err
+	}
+
+	if bus != nil {
+		if err := storage.startEvents(); err != nil {
+			return nil, err
+		}
+	}
+
 	return storage, nil
 }
 
@@ -70,109 +121,46 @@ func (s *SQLStorage) GetDatasourceName() string {
 	return s.sqlConfig.Driver
 }
 
+// exec, query and queryRow convert the ? placeholders to the dialect placeholders
 func (s *SQLStorage) exec(query string, args ...any) (sql.Result, error) {
-	return s.pool.ExecContext(context.Background(), query, args...)
+	return s.pool.ExecContext(context.Background(), s.dialect.Rebind(query), args...)
 }
 
-func (s *SQLStorage) checkDatabase() error {
-	if s.sqlConfig.DatabaseName == "" {
-		return fmt.Errorf("database name is required")
-	}
-	_, err := s.exec(createDatabaseStatement(), s.sqlConfig.DatabaseName)
-	return err
+func (s *SQLStorage) query(query string, args ...any) (*sql.Rows, error) {
+	return s.pool.QueryContext(context.Background(), s.dialect.Rebind(query), args...)
 }
 
-func (s *SQLStorage) checkTable(tableConfig *config.SQLTableConfig) error {
-	if err := s.checkDatabase(); err != nil {
-		return err
-	}
-	if err := tableConfig.CheckConfig(); err != nil {
-		return err
-	}
-	_, err := s.exec(createTableStatement(), tableConfig.TableName, tableConfig.JSONFieldType)
-	return err
+func (s *SQLStorage) queryRow(query string, args ...any) *sql.Row {
+	return s.pool.QueryRowContext(context.Background(), s.dialect.Rebind(query), args...)
 }
 
-// CreateEvaluationJob creates a new evaluation job in the database
-// the evaluation job is stored in the evaluations table as a JSON string
-// the evaluation job is returned as a EvaluationJobResource
-// This should use transactions etc and requires cleaning up
-func (s *SQLStorage) CreateEvaluationJob(executionContext *executioncontext.ExecutionContext, evaluation *api.EvaluationJobConfig) (*api.EvaluationJobResource, error) {
-	if err := s.checkTable(&s.sqlConfig.Evaluations); err != nil {
-		return nil, err
+// tenantArgs returns the tenant argument of the statements that are filtered by tenant, the
+// operations of the service that are not made on behalf of a tenant are not filtered
+func tenantArgs(ctx *executioncontext.ExecutionContext) []any {
+	if ctx == nil || ctx.Tenant == "" {
+		return nil
 	}
-	evaluationJSON, err := json.Marshal(evaluation)
-	if err != nil {
-		return nil, err
-	}
-	result, err := s.exec(createAddEntityStatement(), s.sqlConfig.Evaluations.TableName, string(evaluationJSON))
-	if err != nil {
-		return nil, err
-	}
-	evaluationID, err := result.LastInsertId()
+	return []any{string(ctx.Tenant)}
+}
+
+// withTransaction runs fn in a transaction, the transaction is committed if fn
+// returns nil and rolled back otherwise
+func (s *SQLStorage) withTransaction(fn func(tx *sql.Tx) error) error {
+	tx, err := s.pool.BeginTx(context.Background(), nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	evaluationResource := &api.EvaluationJobResource{
-		Resource: api.Resource{
-			ID:        strconv.FormatInt(evaluationID, 10),
-			Tenant:    "TODO",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		},
-		EvaluationJobConfig: *evaluation,
-		Status: api.EvaluationJobStatus{
-			EvaluationJobState: api.EvaluationJobState{
-				State:   api.StatePending,
-				Message: "Evaluation job created",
-			},
-			Benchmarks: nil,
-		},
-		Results: nil,
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback() // ignore the error as we return the original one
+		return err
 	}
-	return evaluationResource, nil
-}
-
-func (s *SQLStorage) GetEvaluationJob(ctx *executioncontext.ExecutionContext, id string) (*api.EvaluationJobResource, error) {
-	return nil, nil
-}
-
-func (s *SQLStorage) GetEvaluationJobs(ctx *executioncontext.ExecutionContext, summary bool, limit int, offset int, statusFilter string) (*api.EvaluationJobResourceList, error) {
-	return nil, nil
-}
-
-func (s *SQLStorage) DeleteEvaluationJob(ctx *executioncontext.ExecutionContext, id string, hardDelete bool) error {
-	return nil
-}
-
-func (s *SQLStorage) UpdateBenchmarkStatusForJob(ctx *executioncontext.ExecutionContext, id string, status api.BenchmarkStatus) error {
-	return nil
-}
-
-func (s *SQLStorage) UpdateEvaluationJobStatus(ctx *executioncontext.ExecutionContext, id string, state api.EvaluationJobState) error {
-	return nil
-}
-
-func (s *SQLStorage) CreateCollection(ctx *executioncontext.ExecutionContext, collection *api.CollectionResource) error {
-	return nil
-}
-
-func (s *SQLStorage) GetCollection(ctx *executioncontext.ExecutionContext, id string, summary bool) (*api.CollectionResource, error) {
-	return nil, nil
-}
-
-func (s *SQLStorage) GetCollections(ctx *executioncontext.ExecutionContext, limit int, offset int) (*api.CollectionResourceList, error) {
-	return nil, nil
-}
-
-func (s *SQLStorage) UpdateCollection(ctx *executioncontext.ExecutionContext, collection *api.CollectionResource) error {
-	return nil
-}
-
-func (s *SQLStorage) DeleteCollection(ctx *executioncontext.ExecutionContext, id string) error {
-	return nil
+	return tx.Commit()
 }
 
 func (s *SQLStorage) Close() error {
+	if s.stopEvents != nil {
+		s.stopEvents()
+		<-s.eventsDone
+	}
 	return s.pool.Close()
 }
@@ -0,0 +1,237 @@
+package storage_sql_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestEvaluationJobCRUD(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	if _, err := uuid.Parse(job.ID); err != nil {
+		t.Fatalf("CreateEvaluationJob() returned a job without a UUID: %q", job.ID)
+	}
+
+	t.Run("get returns the stored job", func(t *testing.T) {
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.ID != job.ID || got.Model.Name != "test-model" || got.Status.State != api.StatePending {
+			t.Errorf("Unexpected job returned: %+v", got)
+		}
+	})
+
+	t.Run("get unknown job returns not found", func(t *testing.T) {
+		_, err := storage.GetEvaluationJob(ctx, uuid.NewString())
+		if !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("benchmark status updates derive the job state", func(t *testing.T) {
+		err := storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning})
+		if err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateRunning || got.Status.StartedAt == nil {
+			t.Errorf("Expected a started running job, got %+v", got.Status)
+		}
+		err = storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateCompleted})
+		if err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+		got, err = storage.GetEvaluationJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if len(got.Status.Benchmarks) != 1 || got.Status.Benchmarks[0].State != api.StateCompleted {
+			t.Errorf("Unexpected benchmark statuses: %+v", got.Status.Benchmarks)
+		}
+		benchmark := got.Status.Benchmarks[0]
+		if benchmark.StartedAt == nil || benchmark.CompletedAt == nil {
+			t.Errorf("Expected the benchmark times to be stamped: %+v", benchmark)
+		}
+		if got.Status.State != api.StateCompleted || got.Status.CompletedAt == nil {
+			t.Errorf("Expected a completed job, got %+v", got.Status)
+		}
+	})
+
+	t.Run("illegal transitions are rejected", func(t *testing.T) {
+		err := storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: api.StateRunning, Message: "running"})
+		if !errors.Is(err, abstractions.ErrInvalidTransition) {
+			t.Errorf("Expected ErrInvalidTransition, got %v", err)
+		}
+		err = storage.UpdateBenchmarkStatusForJob(ctx, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning})
+		if !errors.Is(err, abstractions.ErrInvalidTransition) {
+			t.Errorf("Expected ErrInvalidTransition, got %v", err)
+		}
+	})
+
+	t.Run("concurrent updates are not lost", func(t *testing.T) {
+		concurrent, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+			Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		})
+		if err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+		var wg sync.WaitGroup
+		for i := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status := api.BenchmarkStatus{Name: fmt.Sprintf("benchmark-%d", i), State: api.StateRunning}
+				if err := storage.UpdateBenchmarkStatusForJob(ctx, concurrent.ID, status); err != nil {
+					t.Errorf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		got, err := storage.GetEvaluationJob(ctx, concurrent.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if len(got.Status.Benchmarks) != 4 {
+			t.Errorf("Expected 4 benchmark statuses, got %+v", got.Status.Benchmarks)
+		}
+	})
+
+	t.Run("soft delete cancels and hard delete removes the job", func(t *testing.T) {
+		pending, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+			Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		})
+		if err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+		if err := storage.DeleteEvaluationJob(ctx, pending.ID, false); err != nil {
+			t.Fatalf("DeleteEvaluationJob() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(ctx, pending.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateCancelled {
+			t.Errorf("Expected state %s, got %s", api.StateCancelled, got.Status.State)
+		}
+		if err := storage.DeleteEvaluationJob(ctx, pending.ID, true); err != nil {
+			t.Fatalf("DeleteEvaluationJob() returned error: %v", err)
+		}
+		if _, err := storage.GetEvaluationJob(ctx, pending.ID); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound after hard delete, got %v", err)
+		}
+		if err := storage.DeleteEvaluationJob(ctx, pending.ID, true); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound when deleting twice, got %v", err)
+		}
+	})
+}
+
+func TestGetEvaluationJobs(t *testing.T) {
+	storage := createStorage(t)
+	ctx := createExecutionContext()
+
+	ids := []string{}
+	for i := range 5 {
+		job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+			Model: api.ModelRef{URL: "http://localhost:8000", Name: fmt.Sprintf("model-%d", i)},
+		})
+		if err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+		ids = append(ids, job.ID)
+	}
+	if err := storage.UpdateBenchmarkStatusForJob(ctx, ids[0], api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}); err != nil {
+		t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+	}
+	if err := storage.UpdateEvaluationJobStatus(ctx, ids[0], api.EvaluationJobState{State: api.StateRunning}); err != nil {
+		t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+	}
+
+	t.Run("pages through the jobs", func(t *testing.T) {
+		page, err := storage.GetEvaluationJobs(ctx, false, 2, 0, "")
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if page.TotalCount != 5 || len(page.Items) != 2 || page.Limit != 2 {
+			t.Errorf("Unexpected page: total %d, items %d, limit %d", page.TotalCount, len(page.Items), page.Limit)
+		}
+		if page.Next == nil || page.Next.Href != "http://localhost:8080/api/v1/evaluations/jobs?limit=2&offset=2" {
+			t.Errorf("Unexpected next link: %+v", page.Next)
+		}
+		last, err := storage.GetEvaluationJobs(ctx, false, 2, 4, "")
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if len(last.Items) != 1 || last.Next != nil {
+			t.Errorf("Unexpected last page: items %d, next %+v", len(last.Items), last.Next)
+		}
+	})
+
+	t.Run("filters by status", func(t *testing.T) {
+		page, err := storage.GetEvaluationJobs(ctx, false, 10, 0, string(api.StateRunning))
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if page.TotalCount != 1 || len(page.Items) != 1 || page.Items[0].ID != ids[0] {
+			t.Errorf("Unexpected filtered page: %+v", page)
+		}
+		if len(page.Items[0].Status.Benchmarks) != 1 {
+			t.Errorf("Expected the benchmark statuses in the full view")
+		}
+	})
+
+	t.Run("summary omits the benchmark statuses", func(t *testing.T) {
+		page, err := storage.GetEvaluationJobs(ctx, true, 10, 0, string(api.StateRunning))
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if len(page.Items) != 1 || page.Items[0].Status.Benchmarks != nil {
+			t.Errorf("Expected no benchmark statuses in the summary view: %+v", page.Items)
+		}
+	})
+}
+
+func createStorage(t *testing.T) abstractions.Storage {
+	t.Helper()
+	sqlConfig := &config.SQLDatabaseConfig{
+		Driver:       "sqlite",
+		URL:          fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		DatabaseName: "eval_hub",
+		Evaluations:  config.SQLTableConfig{TableName: "evaluations"},
+		Collections:  config.SQLTableConfig{TableName: "collections"},
+	}
+	storage, err := storage_sql.NewSQLStorage(sqlConfig, nil, nil, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func createExecutionContext() *executioncontext.ExecutionContext {
+	return &executioncontext.ExecutionContext{
+		Logger:  logging.FallbackLogger(),
+		BaseURL: "http://localhost:8080",
+		URI:     "/api/v1/evaluations/jobs",
+	}
+}
@@ -0,0 +1,97 @@
+package storage_sql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestTenantScoping(t *testing.T) {
+	storage := createStorage(t)
+	teamA := createExecutionContext()
+	teamA.Tenant = "team-a"
+	teamB := createExecutionContext()
+	teamB.Tenant = "team-b"
+	system := createExecutionContext()
+
+	job, err := storage.CreateEvaluationJob(teamA, &api.EvaluationJobConfig{
+		Model: api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	if job.Tenant != "team-a" {
+		t.Errorf("Expected the job of team-a, got %q", job.Tenant)
+	}
+	collection := &api.CollectionResource{CollectionConfig: api.CollectionConfig{Name: "reasoning"}}
+	if err := storage.CreateCollection(teamA, collection); err != nil {
+		t.Fatalf("CreateCollection() returned error: %v", err)
+	}
+
+	t.Run("the jobs of another tenant are not found", func(t *testing.T) {
+		if _, err := storage.GetEvaluationJob(teamB, job.ID); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := storage.UpdateEvaluationJobStatus(teamB, job.ID, api.EvaluationJobState{State: api.StateRunning}); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound when updating, got %v", err)
+		}
+		if err := storage.DeleteEvaluationJob(teamB, job.ID, true); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound when deleting, got %v", err)
+		}
+		page, err := storage.GetEvaluationJobs(teamB, false, 10, 0, "")
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if page.TotalCount != 0 || len(page.Items) != 0 {
+			t.Errorf("Expected no jobs for team-b, got %d", page.TotalCount)
+		}
+		page, err = storage.GetEvaluationJobs(teamA, false, 10, 0, string(api.StatePending))
+		if err != nil {
+			t.Fatalf("GetEvaluationJobs() returned error: %v", err)
+		}
+		if page.TotalCount != 1 || page.Items[0].ID != job.ID {
+			t.Errorf("Expected the pending job of team-a, got %+v", page.Items)
+		}
+	})
+
+	t.Run("the collections of another tenant are not found", func(t *testing.T) {
+		if _, err := storage.GetCollection(teamB, collection.ID, false); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		update := &api.CollectionResource{Resource: api.Resource{ID: collection.ID}, CollectionConfig: api.CollectionConfig{Name: "renamed"}}
+		if err := storage.UpdateCollection(teamB, update); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound when updating, got %v", err)
+		}
+		if err := storage.DeleteCollection(teamB, collection.ID); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound when deleting, got %v", err)
+		}
+		page, err := storage.GetCollections(teamB, 10, 0)
+		if err != nil {
+			t.Fatalf("GetCollections() returned error: %v", err)
+		}
+		if page.TotalCount != 0 {
+			t.Errorf("Expected no collections for team-b, got %d", page.TotalCount)
+		}
+	})
+
+	t.Run("the names of the collections are unique per tenant", func(t *testing.T) {
+		if err := storage.CreateCollection(teamB, &api.CollectionResource{CollectionConfig: api.CollectionConfig{Name: "reasoning"}}); err != nil {
+			t.Errorf("Expected team-b to use the name of a collection of team-a, got %v", err)
+		}
+	})
+
+	t.Run("the operations of the service are not scoped", func(t *testing.T) {
+		if err := storage.UpdateBenchmarkStatusForJob(system, job.ID, api.BenchmarkStatus{Name: "mmlu", State: api.StateRunning}); err != nil {
+			t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+		}
+		got, err := storage.GetEvaluationJob(teamA, job.ID)
+		if err != nil {
+			t.Fatalf("GetEvaluationJob() returned error: %v", err)
+		}
+		if got.Status.State != api.StateRunning || got.Tenant != "team-a" {
+			t.Errorf("Expected the running job of team-a, got %s of %q", got.Status.State, got.Tenant)
+		}
+	})
+}
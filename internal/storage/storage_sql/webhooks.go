@@ -0,0 +1,214 @@
+package storage_sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/statemachine"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// jobSnapshot is the state of a job before an update, it is compared with the updated
+// job to find the webhook events of the update
+type jobSnapshot struct {
+	state      api.State
+	benchmarks map[string]api.State
+}
+
+func snapshotJob(evaluation *api.EvaluationJobResource) jobSnapshot {
+	snapshot := jobSnapshot{state: evaluation.Status.State, benchmarks: map[string]api.State{}}
+	for _, benchmark := range evaluation.Status.Benchmarks {
+		snapshot.benchmarks[benchmark.Name] = benchmark.State
+	}
+	return snapshot
+}
+
+// deliveriesTable is the outbox of the webhook deliveries, it is named after the evaluations table
+func (s *SQLStorage) deliveriesTable() string {
+	return s.sqlConfig.Evaluations.TableName + "_deliveries"
+}
+
+// webhookEvents returns the events of the update of the job, there are no events when the
+// webhooks are disabled or when the job does not have a callback URL
+func (s *SQLStorage) webhookEvents(before jobSnapshot, evaluation *api.EvaluationJobResource, now time.Time) []api.WebhookEvent {
+	if s.webhooks == nil || !s.webhooks.Enabled || evaluation.CallbackURL == nil || *evaluation.CallbackURL == "" {
+		return nil
+	}
+	events := []api.WebhookEvent{}
+	if s.webhooks.BenchmarkEvents {
+		for _, benchmark := range evaluation.Status.Benchmarks {
+			if state, found := before.benchmarks[benchmark.Name]; found && state == benchmark.State {
+				continue
+			}
+			events = append(events, api.WebhookEvent{
+				ID:        uuid.NewString(),
+				Type:      api.WebhookEventBenchmarkUpdated,
+				CreatedAt: now,
+				Job:       evaluation,
+				Benchmark: &benchmark,
+			})
+		}
+	}
+	if !statemachine.IsTerminal(before.state) && statemachine.IsTerminal(evaluation.Status.State) {
+		events = append(events, api.WebhookEvent{
+			ID:        uuid.NewString(),
+			Type:      api.WebhookEventJobFinished,
+			CreatedAt: now,
+			Job:       evaluation,
+		})
+	}
+	return events
+}
+
+// addWebhookDeliveries writes the deliveries of the events to the outbox in the transaction
+// of the job update so that an event is only delivered if the update has been stored
+func (s *SQLStorage) addWebhookDeliveries(tx *sql.Tx, evaluation *api.EvaluationJobResource, events []api.WebhookEvent) error {
+	for _, event := range events {
+		delivery := &api.WebhookDeliveryResource{
+			ID:            event.ID,
+			JobID:         evaluation.ID,
+			Tenant:        evaluation.Tenant,
+			Event:         event.Type,
+			URL:           *evaluation.CallbackURL,
+			Status:        api.DeliveryStatusPending,
+			CreatedAt:     event.CreatedAt,
+			NextAttemptAt: &event.CreatedAt,
+			Attempts:      []api.DeliveryAttempt{},
+		}
+		deliveryJSON, err := json.Marshal(delivery)
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(s.dialect.Rebind(createAddDeliveryStatement(s.dialect, s.deliveriesTable())),
+			delivery.ID, delivery.JobID, string(delivery.Tenant), string(delivery.Status), event.CreatedAt.UnixMilli(), string(deliveryJSON), string(payload))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimWebhookDelivery claims the delivery that has been due the longest, the claim moves the
+// next attempt of the delivery forward by the lease duration so that the delivery is claimed
+// again if the owner stops before recording the attempt. The payload is the body of the request.
+func (s *SQLStorage) ClaimWebhookDelivery(ctx *executioncontext.ExecutionContext, owner string, leaseDuration time.Duration) (*api.WebhookDeliveryResource, []byte, error) {
+	tableName := s.deliveriesTable()
+	now := time.Now()
+
+	rows, err := s.query(createListDueDeliveriesStatement(tableName), now.UnixMilli(), claimCandidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates := []string{}
+	for rows.Next() {
+		var resourceID string
+		if err := rows.Scan(&resourceID); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		candidates = append(candidates, resourceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	leaseExpiresAt := now.Add(leaseDuration).UnixMilli()
+	for _, id := range candidates {
+		result, err := s.exec(createClaimDeliveryStatement(tableName), owner, leaseExpiresAt, id, now.UnixMilli())
+		if err != nil {
+			return nil, nil, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return nil, nil, err
+		}
+		if count == 1 {
+			return s.getWebhookDelivery(id)
+		}
+	}
+	return nil, nil, nil
+}
+
+// RecordWebhookDeliveryAttempt stores the delivery with its latest attempt and releases the
+// claim, an error wrapping abstractions.ErrLeaseLost is returned if the delivery has been
+// claimed by another owner in the meantime
+func (s *SQLStorage) RecordWebhookDeliveryAttempt(ctx *executioncontext.ExecutionContext, delivery *api.WebhookDeliveryResource, owner string) error {
+	// the next attempt is stored with a millisecond precision, the delivery is truncated so
+	// that the entity and the caller have the time that the delivery is claimed at
+	nextAttemptAt := int64(0)
+	if delivery.NextAttemptAt != nil {
+		truncated := delivery.NextAttemptAt.Truncate(time.Millisecond)
+		delivery.NextAttemptAt = &truncated
+		nextAttemptAt = truncated.UnixMilli()
+	}
+	deliveryJSON, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	result, err := s.exec(createUpdateDeliveryStatement(s.deliveriesTable()), string(delivery.Status), nextAttemptAt, string(deliveryJSON), delivery.ID, owner)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("webhook delivery %s %w", delivery.ID, abstractions.ErrLeaseLost)
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns the deliveries of the job ordered by creation or an error
+// wrapping abstractions.ErrNotFound if there is no such job
+func (s *SQLStorage) GetWebhookDeliveries(ctx *executioncontext.ExecutionContext, jobID string) (*api.WebhookDeliveryResourceList, error) {
+	if _, _, err := s.getEvaluationJob(ctx, s.pool, jobID); err != nil {
+		return nil, err
+	}
+	args := tenantArgs(ctx)
+	rows, err := s.query(createListJobDeliveriesStatement(s.deliveriesTable(), len(args) > 0), append([]any{jobID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []api.WebhookDeliveryResource{}
+	for rows.Next() {
+		var entity string
+		if err := rows.Scan(&entity); err != nil {
+			return nil, err
+		}
+		delivery := api.WebhookDeliveryResource{}
+		if err := json.Unmarshal([]byte(entity), &delivery); err != nil {
+			return nil, err
+		}
+		items = append(items, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &api.WebhookDeliveryResourceList{Items: items}, nil
+}
+
+func (s *SQLStorage) getWebhookDelivery(id string) (*api.WebhookDeliveryResource, []byte, error) {
+	var entity string
+	var payload string
+	if err := s.queryRow(createGetDeliveryStatement(s.deliveriesTable()), id).Scan(&entity, &payload); err != nil {
+		return nil, nil, err
+	}
+	delivery := &api.WebhookDeliveryResource{}
+	if err := json.Unmarshal([]byte(entity), delivery); err != nil {
+		return nil, nil, err
+	}
+	return delivery, []byte(payload), nil
+}
@@ -0,0 +1,125 @@
+package storage_sql_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+func TestWebhookOutbox(t *testing.T) {
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, &config.WebhookConfig{Enabled: true, BenchmarkEvents: true}, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+
+	ctx := createExecutionContext()
+	ctx.Tenant = "team-a"
+	callbackURL := "http://localhost:9000/hooks"
+	createJob := func(t *testing.T, callbackURL *string) *api.EvaluationJobResource {
+		t.Helper()
+		job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+			Model:       api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+			Benchmarks:  []api.BenchmarkConfig{{Ref: api.Ref{ID: "mmlu"}}},
+			CallbackURL: callbackURL,
+		})
+		if err != nil {
+			t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+		}
+		return job
+	}
+	runJob := func(t *testing.T, id string) {
+		t.Helper()
+		for _, state := range []api.State{api.StateRunning, api.StateCompleted} {
+			if err := storage.UpdateBenchmarkStatusForJob(ctx, id, api.BenchmarkStatus{Name: "mmlu", State: state}); err != nil {
+				t.Fatalf("UpdateBenchmarkStatusForJob() returned error: %v", err)
+			}
+		}
+	}
+
+	t.Run("the jobs without a callback URL have no deliveries", func(t *testing.T) {
+		job := createJob(t, nil)
+		runJob(t, job.ID)
+		deliveries, err := storage.GetWebhookDeliveries(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetWebhookDeliveries() returned error: %v", err)
+		}
+		if len(deliveries.Items) != 0 {
+			t.Errorf("Expected no deliveries, got %+v", deliveries.Items)
+		}
+	})
+
+	t.Run("the benchmark transitions and the end of the job are written to the outbox", func(t *testing.T) {
+		job := createJob(t, &callbackURL)
+		runJob(t, job.ID)
+		// the job has already finished so the results do not add an event
+		if err := storage.UpsertEvaluationJobResults(ctx, job.ID, &api.EvaluationJobResultsConfig{
+			Benchmarks: []api.BenchmarkResultConfig{{Name: "mmlu", Metrics: map[string]any{"acc": 0.5}}},
+		}); err != nil {
+			t.Fatalf("UpsertEvaluationJobResults() returned error: %v", err)
+		}
+
+		deliveries, err := storage.GetWebhookDeliveries(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetWebhookDeliveries() returned error: %v", err)
+		}
+		events := []api.WebhookEventType{}
+		for _, delivery := range deliveries.Items {
+			events = append(events, delivery.Event)
+			if delivery.Status != api.DeliveryStatusPending || delivery.URL != callbackURL || delivery.Tenant != "team-a" {
+				t.Errorf("Unexpected delivery %+v", delivery)
+			}
+		}
+		expected := []api.WebhookEventType{api.WebhookEventBenchmarkUpdated, api.WebhookEventBenchmarkUpdated, api.WebhookEventJobFinished}
+		if fmt.Sprint(events) != fmt.Sprint(expected) {
+			t.Errorf("Expected the events %v, got %v", expected, events)
+		}
+
+		other := createExecutionContext()
+		other.Tenant = "team-b"
+		if _, err := storage.GetWebhookDeliveries(other, job.ID); !errors.Is(err, abstractions.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for another tenant, got %v", err)
+		}
+	})
+
+	t.Run("a claimed delivery is only recorded by its owner", func(t *testing.T) {
+		delivery, payload, err := storage.ClaimWebhookDelivery(ctx, "replica-1", time.Minute)
+		if err != nil || delivery == nil {
+			t.Fatalf("ClaimWebhookDelivery() returned %v %v", delivery, err)
+		}
+		if len(payload) == 0 {
+			t.Errorf("Expected the payload of the event")
+		}
+		delivery.Status = api.DeliveryStatusDelivered
+		delivery.NextAttemptAt = nil
+		if err := storage.RecordWebhookDeliveryAttempt(ctx, delivery, "replica-2"); !errors.Is(err, abstractions.ErrLeaseLost) {
+			t.Errorf("Expected ErrLeaseLost for another owner, got %v", err)
+		}
+		if err := storage.RecordWebhookDeliveryAttempt(ctx, delivery, "replica-1"); err != nil {
+			t.Errorf("RecordWebhookDeliveryAttempt() returned error: %v", err)
+		}
+
+		// the other deliveries are still due and the recorded one is not claimed again
+		for range 2 {
+			next, _, err := storage.ClaimWebhookDelivery(ctx, "replica-1", time.Minute)
+			if err != nil || next == nil || next.ID == delivery.ID {
+				t.Fatalf("Expected another due delivery, got %v %v", next, err)
+			}
+		}
+		if next, _, err := storage.ClaimWebhookDelivery(ctx, "replica-1", time.Minute); err != nil || next != nil {
+			t.Errorf("Expected no due delivery while the others are claimed, got %v %v", next, err)
+		}
+	})
+}
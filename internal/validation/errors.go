@@ -0,0 +1,78 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// BodyLoc is the first element of the location of the fields of a request body
+const BodyLoc = "body"
+
+// ValidationErrors converts the error returned when unmarshalling and validating a request
+// body into the validation errors of the response, the errors that are not validation
+// errors are reported for the whole body
+func ValidationErrors(err error) []api.ValidationError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []api.ValidationError{{
+			Loc:  []any{BodyLoc},
+			Msg:  err.Error(),
+			Type: "value_error.jsondecode",
+		}}
+	}
+	details := make([]api.ValidationError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		details = append(details, api.ValidationError{
+			Loc:  namespaceLoc(fieldError.Namespace()),
+			Msg:  message(fieldError),
+			Type: "value_error." + fieldError.Tag(),
+		})
+	}
+	return details
+}
+
+// namespaceLoc converts the validator namespace, for example EvaluationJobConfig.benchmarks[0].limit,
+// into a location, the name of the struct is replaced by the body
+func namespaceLoc(namespace string) []any {
+	loc := []any{BodyLoc}
+	parts := strings.Split(namespace, ".")
+	for _, part := range parts[1:] {
+		name, indexes, _ := strings.Cut(part, "[")
+		if name != "" {
+			loc = append(loc, name)
+		}
+		if indexes == "" {
+			continue
+		}
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			if i, err := strconv.Atoi(index); err == nil {
+				loc = append(loc, i)
+			} else {
+				loc = append(loc, index)
+			}
+		}
+	}
+	return loc
+}
+
+func message(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "field required"
+	case "url":
+		return "invalid URL"
+	case "gt":
+		return fmt.Sprintf("ensure this value is greater than %s", fieldError.Param())
+	case "gte":
+		return fmt.Sprintf("ensure this value is greater than or equal to %s", fieldError.Param())
+	case "min":
+		return fmt.Sprintf("ensure this value has at least %s items", fieldError.Param())
+	default:
+		return fmt.Sprintf("failed on the %s validation", fieldError.Tag())
+	}
+}
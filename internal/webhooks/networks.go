@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// parseNetworks parses the CIDRs of the denied networks
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	parsed := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("the denied webhook network %q is not a valid CIDR: %w", network, err)
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
+// newTransport returns the transport of the deliveries. The address of each connection is
+// checked when it is dialled, after the host name of the callback URL has been resolved, so
+// that a callback URL can not reach a denied network even when its name resolves to another
+// address later. The proxy of the environment is not used as the address of the proxy would
+// be checked instead of the address of the receiver.
+func newTransport(timeout time.Duration, denied []*net.IPNet) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		ControlContext: func(_ context.Context, _ string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("the webhook address %s is not an IP address", host)
+			}
+			for _, network := range denied {
+				if network.Contains(ip) {
+					return fmt.Errorf("the webhook address %s is in the denied network %s", ip, network)
+				}
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: timeout,
+	}
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// The headers of the requests sent to the callback URLs
+const (
+	EventHeader     = "X-Eval-Hub-Event"
+	DeliveryHeader  = "X-Eval-Hub-Delivery"
+	TimestampHeader = "X-Eval-Hub-Timestamp"
+	SignatureHeader = "X-Eval-Hub-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns the signature of the body sent at the timestamp (unix seconds), the signature is
+// sha256= followed by the hex encoded HMAC-SHA256 of <timestamp>.<body> with the secret. The
+// timestamp is signed so that the receivers can reject the requests that are replayed later.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature is the signature of the body sent at the timestamp
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// parseTenantSecrets parses the signing secrets of the tenants, one secret per line as
+// <tenant>:<secret>. The empty lines and the lines starting with # are ignored.
+func parseTenantSecrets(secrets string) (map[api.Tenant]string, error) {
+	tenantSecrets := map[api.Tenant]string{}
+	scanner := bufio.NewScanner(strings.NewReader(secrets))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		tenant, secret, found := strings.Cut(text, ":")
+		tenant = strings.TrimSpace(tenant)
+		secret = strings.TrimSpace(secret)
+		if !found || tenant == "" || secret == "" {
+			return nil, fmt.Errorf("the webhook secret on line %d must be <tenant>:<secret>", line)
+		}
+		tenantSecrets[api.Tenant(tenant)] = secret
+	}
+	return tenantSecrets, nil
+}
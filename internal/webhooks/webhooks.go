@@ -0,0 +1,267 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/constants"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/metrics"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// maxResponseBody is the number of bytes of the response read so that the connection can be reused
+const maxResponseBody = 4096
+
+// Dispatcher delivers the webhook events written to the outbox by the storage, at most Workers
+// events are delivered at the same time by a replica. A failed delivery is retried with an
+// exponential backoff and is dead-lettered once it has been attempted MaxAttempts times.
+type Dispatcher struct {
+	config        *config.WebhookConfig
+	storage       abstractions.Storage
+	client        *http.Client
+	tenantSecrets map[api.Tenant]string
+	logger        *slog.Logger
+	owner         string
+
+	slots   chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	workers sync.WaitGroup
+}
+
+// NewDispatcher returns nil when the webhooks are not enabled
+func NewDispatcher(webhookConfig *config.WebhookConfig, storage abstractions.Storage, logger *slog.Logger) (*Dispatcher, error) {
+	if webhookConfig == nil || !webhookConfig.Enabled {
+		return nil, nil
+	}
+	if err := webhookConfig.CheckConfig(); err != nil {
+		return nil, err
+	}
+	tenantSecrets, err := parseTenantSecrets(webhookConfig.TenantSecrets)
+	if err != nil {
+		return nil, err
+	}
+	if webhookConfig.Secret == "" && len(tenantSecrets) == 0 {
+		return nil, fmt.Errorf("the webhooks are enabled without a signing secret")
+	}
+	deniedNetworks, err := parseNetworks(webhookConfig.DeniedNetworks)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "eval-hub"
+	}
+	owner := fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	logger.Info("Creating webhook dispatcher", "owner", owner, "workers", webhookConfig.Workers, "benchmark_events", webhookConfig.BenchmarkEvents, "denied_networks", webhookConfig.DeniedNetworks)
+	return &Dispatcher{
+		config:  webhookConfig,
+		storage: storage,
+		client: &http.Client{
+			Timeout:   webhookConfig.Timeout,
+			Transport: newTransport(webhookConfig.Timeout, deniedNetworks),
+			// a redirect is a failed delivery, the callback URL must be the receiver
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		tenantSecrets: tenantSecrets,
+		logger:        logger.With("webhook_dispatcher", owner),
+		owner:         owner,
+		slots:         make(chan struct{}, webhookConfig.Workers),
+	}, nil
+}
+
+// Start starts delivering the due events in the background
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go d.run(ctx)
+}
+
+// Stop stops claiming deliveries and waits for the running deliveries until the context is
+// done, the deliveries that are not recorded are claimed again once their lease has expired
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+	<-d.done
+
+	finished := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries are still running: %w", ctx.Err())
+	}
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.claimDeliveries()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimDeliveries claims deliveries until all the workers are busy or no delivery is due,
+// a claimed delivery is leased for twice the request timeout
+func (d *Dispatcher) claimDeliveries() {
+	for {
+		select {
+		case d.slots <- struct{}{}:
+		default:
+			return
+		}
+		delivery, payload, err := d.storage.ClaimWebhookDelivery(d.executionContext(""), d.owner, 2*d.config.Timeout)
+		if err != nil {
+			d.logger.Error("Failed to claim a webhook delivery", "error", err.Error())
+		}
+		if delivery == nil {
+			<-d.slots
+			return
+		}
+		d.workers.Add(1)
+		go func() {
+			defer d.workers.Done()
+			defer func() { <-d.slots }()
+			d.deliver(delivery, payload)
+		}()
+	}
+}
+
+// deliver makes one attempt to deliver the event and records it, the delivery is retried
+// after the backoff of the attempt unless it has been attempted MaxAttempts times
+func (d *Dispatcher) deliver(delivery *api.WebhookDeliveryResource, payload []byte) {
+	ctx := d.executionContext(delivery.JobID)
+	// the times of the deliveries are stored with a millisecond precision
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	attempt := api.DeliveryAttempt{AttemptedAt: now}
+
+	secret, found := d.tenantSecrets[delivery.Tenant]
+	if !found {
+		secret = d.config.Secret
+	}
+	// the event can not be signed without a secret so the delivery is not retried
+	retry := secret != ""
+	if retry {
+		statusCode, err := d.send(delivery, payload, secret, now)
+		attempt.StatusCode = statusCode
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	} else {
+		attempt.Error = fmt.Sprintf("there is no signing secret for the tenant %q", delivery.Tenant)
+	}
+	delivery.Attempts = append(delivery.Attempts, attempt)
+
+	outcome := "failed"
+	switch {
+	case attempt.Error == "":
+		delivery.Status = api.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		outcome = string(api.DeliveryStatusDelivered)
+	case !retry || len(delivery.Attempts) >= d.config.MaxAttempts:
+		delivery.Status = api.DeliveryStatusDeadLetter
+		delivery.NextAttemptAt = nil
+		outcome = string(api.DeliveryStatusDeadLetter)
+	default:
+		nextAttemptAt := now.Add(d.backoff(len(delivery.Attempts)))
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+	metrics.WebhookDeliveryAttemptsTotal.WithLabelValues(outcome).Inc()
+	ctx.Logger.Info("Attempted webhook delivery", "delivery_id", delivery.ID, "event", delivery.Event, "attempt", len(delivery.Attempts), "outcome", outcome, "error", attempt.Error)
+
+	if err := d.storage.RecordWebhookDeliveryAttempt(ctx, delivery, d.owner); err != nil {
+		ctx.Logger.Error("Failed to record the webhook delivery attempt", "delivery_id", delivery.ID, "error", err.Error())
+	}
+}
+
+// send POSTs the signed payload to the callback URL, any response other than a 2xx is an error
+func (d *Dispatcher) send(delivery *api.WebhookDeliveryResource, payload []byte, secret string, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody)) // ignore the error as only the status is used
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the time to wait after the given number of failed attempts, the wait is
+// doubled after each attempt up to the max backoff
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	backoff := d.config.InitialBackoff
+	for i := 1; i < attempts && backoff < d.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.config.MaxBackoff)
+}
+
+// executionContext returns the context used for the storage calls, there is no request so
+// the job id is used as the request id
+func (d *Dispatcher) executionContext(jobID string) *executioncontext.ExecutionContext {
+	logger := d.logger
+	if jobID != "" {
+		logger = logger.With("job_id", jobID)
+	}
+	return executioncontext.NewExecutionContext(
+		context.Background(),
+		jobID,
+		logger,
+		"",
+		"",
+		"",
+		"",
+		nil,
+		nil,
+		jobID,
+		"",
+		"",
+		constants.DefaultJobTimeout,
+		constants.DefaultJobRetryAttempts,
+		make(map[string]interface{}),
+		nil,
+		"",
+	)
+}
@@ -0,0 +1,277 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julpayne/eval-hub-backend-svc/internal/abstractions"
+	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/executioncontext"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/storage/storage_sql"
+	"github.com/julpayne/eval-hub-backend-svc/internal/webhooks"
+	"github.com/julpayne/eval-hub-backend-svc/pkg/api"
+)
+
+// receiver records the events with a valid signature and fails the first failures requests
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	events   []api.WebhookEvent
+	invalid  int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	timestamp, _ := strconv.ParseInt(req.Header.Get(webhooks.TimestampHeader), 10, 64)
+	if !webhooks.Verify("secret-a", timestamp, body, req.Header.Get(webhooks.SignatureHeader)) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	event := api.WebhookEvent{}
+	if err := json.Unmarshal(body, &event); err != nil || req.Header.Get(webhooks.DeliveryHeader) != event.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *receiver) received() ([]api.WebhookEvent, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]api.WebhookEvent{}, r.events...), r.invalid
+}
+
+func TestDispatcher(t *testing.T) {
+	webhookConfig := &config.WebhookConfig{
+		Enabled:        true,
+		PollInterval:   10 * time.Millisecond,
+		MaxAttempts:    3,
+		InitialBackoff: 20 * time.Millisecond,
+		MaxBackoff:     40 * time.Millisecond,
+		TenantSecrets:  "# the secrets of the tenants\nteam-a: secret-a\n",
+		// the receivers of the tests listen on the loopback address
+		DeniedNetworks: []string{},
+	}
+
+	t.Run("delivers the signed event when the job finishes", func(t *testing.T) {
+		storage, dispatcher := createDispatcher(t, webhookConfig)
+		r := &receiver{}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		job := finishJob(t, storage, "team-a", server.URL)
+		deliveries := waitForDeliveries(t, storage, "team-a", job.ID, api.DeliveryStatusDelivered)
+		stopDispatcher(t, dispatcher)
+
+		events, invalid := r.received()
+		if len(events) != 1 || invalid != 0 {
+			t.Fatalf("Expected one event with a valid signature, got %d events and %d invalid", len(events), invalid)
+		}
+		if events[0].Type != api.WebhookEventJobFinished || events[0].Job.ID != job.ID || events[0].Job.Status.State != api.StateCompleted {
+			t.Errorf("Unexpected event %+v", events[0])
+		}
+		if delivery := deliveries[0]; len(delivery.Attempts) != 1 || delivery.Attempts[0].StatusCode != http.StatusNoContent || delivery.DeliveredAt == nil {
+			t.Errorf("Unexpected delivery %+v", delivery)
+		}
+	})
+
+	t.Run("retries the failed deliveries with backoff", func(t *testing.T) {
+		storage, dispatcher := createDispatcher(t, webhookConfig)
+		r := &receiver{failures: 2}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		job := finishJob(t, storage, "team-a", server.URL)
+		deliveries := waitForDeliveries(t, storage, "team-a", job.ID, api.DeliveryStatusDelivered)
+		stopDispatcher(t, dispatcher)
+
+		attempts := deliveries[0].Attempts
+		if len(attempts) != 3 || attempts[0].StatusCode != http.StatusServiceUnavailable || attempts[0].Error == "" || attempts[2].Error != "" {
+			t.Fatalf("Expected two failed attempts and a successful one, got %+v", attempts)
+		}
+		if wait := attempts[2].AttemptedAt.Sub(attempts[1].AttemptedAt); wait < 2*webhookConfig.InitialBackoff {
+			t.Errorf("Expected the backoff to double, waited %s", wait)
+		}
+	})
+
+	t.Run("dead-letters the delivery after the max attempts", func(t *testing.T) {
+		storage, dispatcher := createDispatcher(t, webhookConfig)
+		r := &receiver{failures: 10}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		job := finishJob(t, storage, "team-a", server.URL)
+		deliveries := waitForDeliveries(t, storage, "team-a", job.ID, api.DeliveryStatusDeadLetter)
+		stopDispatcher(t, dispatcher)
+
+		if len(deliveries[0].Attempts) != webhookConfig.MaxAttempts || deliveries[0].NextAttemptAt != nil {
+			t.Errorf("Expected %d attempts and no next attempt, got %+v", webhookConfig.MaxAttempts, deliveries[0])
+		}
+	})
+
+	t.Run("dead-letters the delivery of a tenant without a secret", func(t *testing.T) {
+		storage, dispatcher := createDispatcher(t, webhookConfig)
+		r := &receiver{}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		job := finishJob(t, storage, "team-b", server.URL)
+		deliveries := waitForDeliveries(t, storage, "team-b", job.ID, api.DeliveryStatusDeadLetter)
+		stopDispatcher(t, dispatcher)
+
+		if len(deliveries[0].Attempts) != 1 || deliveries[0].Attempts[0].StatusCode != 0 {
+			t.Errorf("Expected a single attempt without a request, got %+v", deliveries[0].Attempts)
+		}
+		if events, invalid := r.received(); len(events) != 0 || invalid != 0 {
+			t.Errorf("Expected no request to the receiver")
+		}
+	})
+
+	t.Run("does not connect to the denied networks", func(t *testing.T) {
+		deniedConfig := *webhookConfig
+		deniedConfig.DeniedNetworks = nil
+		deniedConfig.MaxAttempts = 1
+		storage, dispatcher := createDispatcher(t, &deniedConfig)
+		r := &receiver{}
+		server := httptest.NewServer(r)
+		defer server.Close()
+
+		job := finishJob(t, storage, "team-a", server.URL)
+		deliveries := waitForDeliveries(t, storage, "team-a", job.ID, api.DeliveryStatusDeadLetter)
+		stopDispatcher(t, dispatcher)
+
+		if attempts := deliveries[0].Attempts; len(attempts) != 1 || !strings.Contains(attempts[0].Error, "denied network 127.0.0.0/8") {
+			t.Errorf("Expected the connection to the loopback address to be refused, got %+v", attempts)
+		}
+		if events, invalid := r.received(); len(events) != 0 || invalid != 0 {
+			t.Errorf("Expected no request to the receiver")
+		}
+	})
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"type":"job.finished"}`)
+	signature := webhooks.Sign("secret", 1700000000, body)
+	if !webhooks.Verify("secret", 1700000000, body, signature) {
+		t.Errorf("Expected the signature %s to be verified", signature)
+	}
+	if webhooks.Verify("secret", 1700000001, body, signature) || webhooks.Verify("other", 1700000000, body, signature) {
+		t.Errorf("Expected the signature to depend on the timestamp and the secret")
+	}
+}
+
+func TestNewDispatcherErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		webhookConfig *config.WebhookConfig
+	}{
+		{"no secret", &config.WebhookConfig{Enabled: true}},
+		{"invalid tenant secret", &config.WebhookConfig{Enabled: true, TenantSecrets: "team-a"}},
+		{"invalid denied network", &config.WebhookConfig{Enabled: true, Secret: "secret", DeniedNetworks: []string{"10.0.0.0"}}},
+		{"max backoff less than the initial backoff", &config.WebhookConfig{Enabled: true, Secret: "secret", InitialBackoff: time.Minute, MaxBackoff: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := webhooks.NewDispatcher(tt.webhookConfig, nil, logging.FallbackLogger()); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+
+	dispatcher, err := webhooks.NewDispatcher(&config.WebhookConfig{Enabled: false}, nil, logging.FallbackLogger())
+	if err != nil || dispatcher != nil {
+		t.Errorf("Expected no dispatcher when the webhooks are not enabled, got %v %v", dispatcher, err)
+	}
+}
+
+func createDispatcher(t *testing.T, webhookConfig *config.WebhookConfig) (abstractions.Storage, *webhooks.Dispatcher) {
+	t.Helper()
+	storage, err := storage_sql.NewSQLStorage(&config.SQLDatabaseConfig{
+		Driver:      "sqlite",
+		URL:         fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()),
+		Evaluations: config.SQLTableConfig{TableName: "evaluations"},
+		Collections: config.SQLTableConfig{TableName: "collections"},
+	}, nil, webhookConfig, nil, nil, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewSQLStorage() returned error: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	dispatcher, err := webhooks.NewDispatcher(webhookConfig, storage, logging.FallbackLogger())
+	if err != nil {
+		t.Fatalf("NewDispatcher() returned error: %v", err)
+	}
+	dispatcher.Start()
+	return storage, dispatcher
+}
+
+func stopDispatcher(t *testing.T, dispatcher *webhooks.Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dispatcher.Stop(ctx); err != nil {
+		t.Errorf("Stop() returned error: %v", err)
+	}
+}
+
+// finishJob creates a job of the tenant with the callback URL and completes it
+func finishJob(t *testing.T, storage abstractions.Storage, tenant api.Tenant, callbackURL string) *api.EvaluationJobResource {
+	t.Helper()
+	ctx := createExecutionContext(tenant)
+	job, err := storage.CreateEvaluationJob(ctx, &api.EvaluationJobConfig{
+		Model:       api.ModelRef{URL: "http://localhost:8000", Name: "test-model"},
+		CallbackURL: &callbackURL,
+	})
+	if err != nil {
+		t.Fatalf("CreateEvaluationJob() returned error: %v", err)
+	}
+	for _, state := range []api.State{api.StateRunning, api.StateCompleted} {
+		if err := storage.UpdateEvaluationJobStatus(ctx, job.ID, api.EvaluationJobState{State: state}); err != nil {
+			t.Fatalf("UpdateEvaluationJobStatus() returned error: %v", err)
+		}
+	}
+	return job
+}
+
+// waitForDeliveries waits until the deliveries of the job have the status
+func waitForDeliveries(t *testing.T, storage abstractions.Storage, tenant api.Tenant, jobID string, status api.DeliveryStatus) []api.WebhookDeliveryResource {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := storage.GetWebhookDeliveries(createExecutionContext(tenant), jobID)
+		if err != nil {
+			t.Fatalf("GetWebhookDeliveries() returned error: %v", err)
+		}
+		if len(deliveries.Items) > 0 && deliveries.Items[0].Status == status {
+			return deliveries.Items
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the %s deliveries, got %+v", status, deliveries.Items)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func createExecutionContext(tenant api.Tenant) *executioncontext.ExecutionContext {
+	ctx := executioncontext.NewExecutionContext(context.Background(), "test", logging.FallbackLogger(), "", "", "", "", nil, nil, "", "", "", time.Minute, 0, nil, nil, "")
+	ctx.Tenant = tenant
+	return ctx
+}
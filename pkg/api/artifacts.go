@@ -0,0 +1,33 @@
+package api
+
+import (
+	"strings"
+	"time"
+)
+
+// ArtifactDigestPrefix is the prefix of the digests of the artifacts, the digest of an artifact
+// is the prefix followed by the lowercase hex encoding of the SHA-256 hash of its content
+const ArtifactDigestPrefix = "sha256:"
+
+// ArtifactResource represents a file uploaded for an evaluation job, the file is optionally
+// uploaded for a benchmark of the job and the name is unique per benchmark
+type ArtifactResource struct {
+	Digest      string    `json:"digest"`
+	Name        string    `json:"name"`
+	Benchmark   string    `json:"benchmark,omitempty"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArtifactResourceList represents the artifacts of an evaluation job
+type ArtifactResourceList struct {
+	TotalCount int                `json:"total_count"`
+	Items      []ArtifactResource `json:"items"`
+}
+
+// IsArtifactReference returns true if the value of an artifact of a benchmark result is the
+// digest of an uploaded artifact rather than a path or a URL
+func IsArtifactReference(value string) bool {
+	return strings.HasPrefix(value, ArtifactDigestPrefix)
+}
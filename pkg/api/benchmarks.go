@@ -8,6 +8,8 @@ type BenchmarkResource struct {
 	Category    string   `json:"category,omitempty"`
 	ProviderID  string   `json:"provider_id"`
 	Tags        []string `json:"tags,omitempty"`
+	// ParametersSchema is the JSON Schema of the benchmark parameters
+	ParametersSchema map[string]any `json:"parameters_schema,omitempty"`
 }
 
 // BenchmarkResourceList represents list of benchmarks
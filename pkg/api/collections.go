@@ -1,10 +1,11 @@
 package api
 
-// CollectionConfig represents request to create a collection
+// CollectionConfig represents request to create a collection, the configuration of the
+// benchmarks is copied into the evaluation jobs that reference the collection
 type CollectionConfig struct {
-	Name        string   `json:"name"`
-	Description *string  `json:"description,omitempty"`
-	Benchmarks  []string `json:"benchmarks"`
+	Name        string            `json:"name" validate:"required"`
+	Description *string           `json:"description,omitempty"`
+	Benchmarks  []BenchmarkConfig `json:"benchmarks" validate:"dive"`
 }
 
 // CollectionResource represents collection resource
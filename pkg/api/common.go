@@ -1,6 +1,9 @@
 package api
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ------------------------------------------------------------------------------------------------
 // General naming conventions:
@@ -22,6 +25,9 @@ const (
 	PatchOpReplace PatchOp = "replace"
 	PatchOpAdd     PatchOp = "add"
 	PatchOpRemove  PatchOp = "remove"
+	PatchOpTest    PatchOp = "test"
+	PatchOpMove    PatchOp = "move"
+	PatchOpCopy    PatchOp = "copy"
 )
 
 type Ref struct {
@@ -37,14 +43,30 @@ type Error struct {
 	Detail string `json:"detail"`
 }
 
-// PatchOperation represents a single patch operation
+// ValidationError represents a field of the request that is not valid, the location is the
+// path of the field starting with "body", for example ["body", "benchmarks", 0, "limit"]
+type ValidationError struct {
+	Loc  []any  `json:"loc"`
+	Msg  string `json:"msg"`
+	Type string `json:"type"`
+}
+
+// HTTPValidationError represents the error response of a request that is not valid
+type HTTPValidationError struct {
+	Detail []ValidationError `json:"detail"`
+}
+
+// PatchOperation represents a single patch operation, the paths are JSON pointers (RFC 6901)
+// and from is the source of the move and copy operations. The value is kept as JSON so that
+// a missing value can be told apart from null, it is empty when the value is missing.
 type PatchOperation struct {
-	Op    PatchOp `json:"op"`
-	Path  string  `json:"path"`
-	Value any     `json:"value,omitempty"`
+	Op    PatchOp         `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value"`
 }
 
-// Patch represents a list of patch operations
+// Patch represents a list of patch operations (RFC 6902)
 type Patch []PatchOperation
 
 // Resource represents base resource fields
@@ -53,6 +75,9 @@ type Resource struct {
 	Tenant    Tenant    `json:"tenant"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Owner is the subject of the principal that created the resource, it is empty when the
+	// authentication is not enabled
+	Owner string `json:"owner,omitempty"`
 }
 
 // Page represents generic pagination schema
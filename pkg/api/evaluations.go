@@ -15,15 +15,19 @@ const (
 
 // ModelRef represents model specification for evaluation requests
 type ModelRef struct {
-	URL  string `json:"url"`
+	URL  string `json:"url" validate:"required,url"`
 	Name string `json:"name"`
 }
 
 // BenchmarkRef represents a reference to a benchmark
 type BenchmarkConfig struct {
 	Ref
-	Limit      *int           `json:"limit,omitempty"`
+	// ProviderID is resolved from the catalog when the benchmark id is unique
+	ProviderID string         `json:"provider_id,omitempty"`
+	Limit      *int           `json:"limit,omitempty" validate:"omitempty,gt=0"`
 	Parameters map[string]any `json:"parameters,omitempty"`
+	Weight     *float64       `json:"weight,omitempty" validate:"omitempty,gte=0"`
+	Category   string         `json:"category,omitempty"`
 }
 
 // ExperimentConfig represents configuration for MLFlow experiment tracking
@@ -55,18 +59,22 @@ type EvaluationJobState struct {
 // EvaluationStatus represents evaluation status
 type EvaluationJobStatus struct {
 	EvaluationJobState
-	Benchmarks []BenchmarkStatus `json:"benchmarks,omitempty"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Benchmarks  []BenchmarkStatus `json:"benchmarks,omitempty"`
 }
 
 // EvaluationJobBenchmarkResult represents benchmark result in evaluation job
 type EvaluationJobBenchmarkResult struct {
-	ID          string         `json:"id"`
-	Name        string         `json:"name"`
-	State       State          `json:"state"`
-	StartedAt   *time.Time     `json:"started_at,omitempty"`
-	CompletedAt *time.Time     `json:"completed_at,omitempty"`
-	Metrics     map[string]any `json:"metrics,omitempty"`
-	Error       *string        `json:"error,omitempty"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	State       State             `json:"state"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Metrics     map[string]any    `json:"metrics,omitempty"`
+	Artifacts   map[string]string `json:"artifacts,omitempty"`
+	MLFlowRunID *string           `json:"mlflow_run_id,omitempty"`
+	Error       *string           `json:"error,omitempty"`
 }
 
 // EvaluationJobResults represents results section for EvaluationJobResource
@@ -77,17 +85,35 @@ type EvaluationJobResults struct {
 	Benchmarks           []EvaluationJobBenchmarkResult `json:"benchmarks,omitempty"`
 	AggregatedMetrics    map[string]any                 `json:"aggregated_metrics,omitempty"`
 	MLFlowExperimentURL  *string                        `json:"mlflow_experiment_url,omitempty"`
+	Artifacts            []ArtifactResource             `json:"artifacts,omitempty"`
+}
+
+// BenchmarkResultConfig represents the result of a benchmark reported by a runtime or an adapter,
+// an artifact is a path, a URL or the digest of an artifact uploaded for the job
+type BenchmarkResultConfig struct {
+	Name        string            `json:"name" validate:"required"`
+	Metrics     map[string]any    `json:"metrics,omitempty"`
+	Artifacts   map[string]string `json:"artifacts,omitempty"`
+	MLFlowRunID *string           `json:"mlflow_run_id,omitempty"`
+	Error       *string           `json:"error,omitempty"`
+}
+
+// EvaluationJobResultsConfig represents the results payload for an evaluation job
+type EvaluationJobResultsConfig struct {
+	Benchmarks          []BenchmarkResultConfig `json:"benchmarks" validate:"dive"`
+	AggregatedMetrics   map[string]any          `json:"aggregated_metrics,omitempty"`
+	MLFlowExperimentURL *string                 `json:"mlflow_experiment_url,omitempty"`
 }
 
 // EvaluationJobConfig represents evaluation job request schema
 type EvaluationJobConfig struct {
 	Model          ModelRef          `json:"model" validate:"required"`
-	Benchmarks     []BenchmarkConfig `json:"benchmarks"`
+	Benchmarks     []BenchmarkConfig `json:"benchmarks" validate:"dive"`
 	Collection     Ref               `json:"collection"`
 	Experiment     ExperimentConfig  `json:"experiment"`
-	TimeoutMinutes *int              `json:"timeout_minutes,omitempty"`
-	RetryAttempts  *int              `json:"retry_attempts,omitempty"`
-	CallbackURL    *string           `json:"callback_url,omitempty"`
+	TimeoutMinutes *int              `json:"timeout_minutes,omitempty" validate:"omitempty,gt=0"`
+	RetryAttempts  *int              `json:"retry_attempts,omitempty" validate:"omitempty,gte=0"`
+	CallbackURL    *string           `json:"callback_url,omitempty" validate:"omitempty,http_url"`
 }
 
 // EvaluationJobResource represents evaluation job resource response
@@ -98,6 +124,32 @@ type EvaluationJobResource struct {
 	Results *EvaluationJobResults `json:"results,omitempty"`
 }
 
+// BenchmarkSummary represents the state and the headline metric of a benchmark in the job summary
+type BenchmarkSummary struct {
+	Name            string   `json:"name"`
+	State           State    `json:"state"`
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	Metric          string   `json:"metric,omitempty"`
+	Value           *float64 `json:"value,omitempty"`
+	Message         string   `json:"message,omitempty"`
+}
+
+// EvaluationJobSummary represents the compact report of an evaluation job
+type EvaluationJobSummary struct {
+	ID                   string             `json:"id"`
+	State                State              `json:"state"`
+	Message              string             `json:"message,omitempty"`
+	StartedAt            *time.Time         `json:"started_at,omitempty"`
+	CompletedAt          *time.Time         `json:"completed_at,omitempty"`
+	DurationSeconds      *float64           `json:"duration_seconds,omitempty"`
+	TotalEvaluations     int                `json:"total_evaluations"`
+	CompletedEvaluations int                `json:"completed_evaluations"`
+	FailedEvaluations    int                `json:"failed_evaluations"`
+	Score                *float64           `json:"score,omitempty"`
+	Passed               *bool              `json:"passed,omitempty"`
+	Benchmarks           []BenchmarkSummary `json:"benchmarks"`
+}
+
 // EvaluationJobResourceList represents list of evaluation job resources with pagination
 type EvaluationJobResourceList struct {
 	Page
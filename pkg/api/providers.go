@@ -9,6 +9,7 @@ type SupportedBenchmark struct {
 type ProviderResource struct {
 	ID                  string               `json:"id"`
 	Label               string               `json:"label"`
+	Description         string               `json:"description,omitempty"`
 	SupportedBenchmarks []SupportedBenchmark `json:"supported_benchmarks,omitempty"`
 }
 
@@ -0,0 +1,59 @@
+package api
+
+import "time"
+
+// WebhookEventType represents the type of the events sent to the callback URL of a job
+type WebhookEventType string
+
+const (
+	// WebhookEventJobFinished is sent once when the job reaches a terminal state
+	WebhookEventJobFinished WebhookEventType = "job.finished"
+	// WebhookEventBenchmarkUpdated is sent when the state of a benchmark of the job changes
+	WebhookEventBenchmarkUpdated WebhookEventType = "benchmark.updated"
+)
+
+// DeliveryStatus represents the delivery status enum
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending    DeliveryStatus = "pending"
+	DeliveryStatusDelivered  DeliveryStatus = "delivered"
+	DeliveryStatusDeadLetter DeliveryStatus = "dead_letter"
+)
+
+// WebhookEvent represents the JSON body POSTed to the callback URL, the job is the job at the
+// time of the event and the benchmark is only set for the benchmark events
+type WebhookEvent struct {
+	ID        string                 `json:"id"`
+	Type      WebhookEventType       `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Job       *EvaluationJobResource `json:"job"`
+	Benchmark *BenchmarkStatus       `json:"benchmark,omitempty"`
+}
+
+// DeliveryAttempt represents a single attempt to deliver an event, the status code is not set
+// when no response was received
+type DeliveryAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// WebhookDeliveryResource represents the delivery of an event to the callback URL of a job
+type WebhookDeliveryResource struct {
+	ID            string            `json:"id"`
+	JobID         string            `json:"job_id"`
+	Tenant        Tenant            `json:"tenant,omitempty"`
+	Event         WebhookEventType  `json:"event"`
+	URL           string            `json:"url"`
+	Status        DeliveryStatus    `json:"status"`
+	CreatedAt     time.Time         `json:"created_at"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time        `json:"delivered_at,omitempty"`
+	Attempts      []DeliveryAttempt `json:"attempts"`
+}
+
+// WebhookDeliveryResourceList represents the deliveries of a job ordered by creation
+type WebhookDeliveryResourceList struct {
+	Items []WebhookDeliveryResource `json:"items"`
+}
@@ -15,8 +15,11 @@ import (
 	"time"
 
 	"github.com/julpayne/eval-hub-backend-svc/cmd/eval_hub/server"
+	"github.com/julpayne/eval-hub-backend-svc/internal/catalog"
 	"github.com/julpayne/eval-hub-backend-svc/internal/config"
+	"github.com/julpayne/eval-hub-backend-svc/internal/events"
 	"github.com/julpayne/eval-hub-backend-svc/internal/logging"
+	"github.com/julpayne/eval-hub-backend-svc/internal/logstore"
 	"github.com/julpayne/eval-hub-backend-svc/internal/storage"
 	"github.com/julpayne/eval-hub-backend-svc/internal/validation"
 
@@ -103,11 +106,20 @@ func (a *apiFeature) startLocalServer(port int) error {
 		return fmt.Errorf("failed to load service config: %w", err)
 	}
 	serviceConfig.Service.Port = port
-	storage, err := storage.NewStorage(serviceConfig, logger)
+	bus := events.NewBus(events.DefaultHistorySize)
+	storage, err := storage.NewStorage(serviceConfig, bus, logger)
 	if err != nil {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
-	a.server, err = server.NewServer(logger, serviceConfig, storage, validate)
+	logStore, err := logstore.NewLogStore(serviceConfig, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create log store: %w", err)
+	}
+	providerCatalog, err := catalog.NewCatalog(serviceConfig.Catalog, logger)
+	if err != nil {
+		return fmt.Errorf("failed to load catalog: %w", err)
+	}
+	a.server, err = server.NewServer(logger, serviceConfig, storage, validate, server.Dependencies{Catalog: providerCatalog, Bus: bus, LogStore: logStore})
 	if err != nil {
 		return err
 	}